@@ -0,0 +1,53 @@
+// backend/cmd/api/bootstrap.go
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"backend/internal/config"
+	"backend/internal/models"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bootstrapAdmin creates the first admin from BOOTSTRAP_ADMIN_EMAIL and
+// BOOTSTRAP_ADMIN_PASSWORD. It does nothing once any admin exists, so the
+// variables can be removed after the first start. It refuses an email that
+// already has an account: promoting it would hand admin to whoever
+// registered that address, under their own password.
+func bootstrapAdmin(db *sql.DB, cfg config.Bootstrap) error {
+	email, password := cfg.AdminEmail, cfg.AdminPassword
+	if email == "" || password == "" {
+		return nil
+	}
+
+	var hasAdmin bool
+	if err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM users WHERE role = 'admin')").Scan(&hasAdmin); err != nil {
+		return fmt.Errorf("failed to check for admins: %w", err)
+	}
+	if hasAdmin {
+		return nil
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	result, err := db.Exec(`
+		INSERT INTO users (email, password_hash, name, role, email_verified)
+		VALUES ($1, $2, $3, $4, true)
+		ON CONFLICT (email) DO NOTHING
+	`, email, string(hashedPassword), cfg.AdminName, models.RoleAdmin)
+	if err != nil {
+		return fmt.Errorf("failed to create admin: %w", err)
+	}
+	if created, _ := result.RowsAffected(); created == 0 {
+		return fmt.Errorf("an account with %s already exists; choose another BOOTSTRAP_ADMIN_EMAIL", email)
+	}
+
+	slog.Info("Bootstrapped admin account", "email", email)
+	return nil
+}
@@ -1,95 +1,209 @@
-// ==================== FILE 11: backend/cmd/server/main.go ====================
+// backend/cmd/api/main.go
 package main
 
 import (
+	"context"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
+	"backend/internal/apikey"
+	"backend/internal/config"
 	"backend/internal/database"
 	"backend/internal/handlers"
+	"backend/internal/idempotency"
+	"backend/internal/loginguard"
+	"backend/internal/mailer"
+	"backend/internal/metrics"
 	"backend/internal/middleware"
+	"backend/internal/rbac"
+	"backend/internal/session"
+	"backend/internal/token"
+	"backend/internal/tracing"
 )
 
 func main() {
-	db, err := database.InitDB()
+	// migrate only needs the database, so it runs before the rest of the
+	// configuration is validated and before tracing and metrics start.
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrateCommand(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Invalid configuration:\n", err)
+	}
+
+	logger := newLogger(cfg.Log)
+	slog.SetDefault(logger)
+	slog.Info("Configuration loaded", "env", cfg.Env)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Fatal("Failed to configure tracing:", err)
+	}
+
+	db, err := database.InitDB(cfg.Database.URL)
 	if err != nil {
 		log.Fatal("Failed to initialize database:", err)
 	}
 	defer db.Close()
+	metrics.RegisterDB(db)
+
+	if cfg.Database.AutoMigrate {
+		migrator, err := database.NewMigrator(db)
+		if err != nil {
+			log.Fatal("Failed to load migrations:", err)
+		}
+		applied, err := migrator.Up(context.Background())
+		if err != nil {
+			log.Fatal("Failed to migrate database:", err)
+		}
+		for _, m := range applied {
+			slog.Info("Applied migration", "version", m.Version, "name", m.Name)
+		}
+	}
 
-	if err := database.CreateTables(db); err != nil {
-		log.Fatal("Failed to create tables:", err)
-	}
-
-	authHandler := handlers.NewAuthHandler(db)
-	productHandler := handlers.NewProductHandler(db)
-	orderHandler := handlers.NewOrderHandler(db)
-	artisanHandler := handlers.NewArtisanHandler(db)
-	adminHandler := handlers.NewAdminHandler(db)
-	reviewHandler := handlers.NewReviewHandler(db)
-	aiHandler := handlers.NewAIHandler(db)
-	paymentHandler := handlers.NewPaymentHandler(db)
-	videoCallHandler := handlers.NewVideoCallHandler(db)
-
-	mux := http.NewServeMux()
-
-	// Public routes
-	mux.HandleFunc("POST /api/auth/register", authHandler.Register)
-	mux.HandleFunc("POST /api/auth/login", authHandler.Login)
-	mux.HandleFunc("GET /api/products", productHandler.ListProducts)
-	mux.HandleFunc("GET /api/products/{id}", productHandler.GetProduct)
-	mux.HandleFunc("GET /api/categories", productHandler.ListCategories)
-	mux.HandleFunc("GET /api/artisans/{id}", artisanHandler.GetArtisanProfile)
-
-	// Protected routes - Buyer
-	mux.HandleFunc("POST /api/orders", middleware.Auth(orderHandler.CreateOrder))
-	mux.HandleFunc("GET /api/orders", middleware.Auth(orderHandler.GetUserOrders))
-	mux.HandleFunc("GET /api/orders/{id}", middleware.Auth(orderHandler.GetOrderDetails))
-	mux.HandleFunc("POST /api/reviews", middleware.Auth(reviewHandler.CreateReview))
-
-	mux.HandleFunc("GET /api/products/{id}/reviews", reviewHandler.GetProductReviews)
-
-	// Protected routes - Artisan
-	mux.HandleFunc("POST /api/artisan/onboard", middleware.Auth(artisanHandler.OnboardArtisan))
-	mux.HandleFunc("PUT /api/artisan/profile", middleware.Auth(middleware.ArtisanOnly(artisanHandler.UpdateProfile)))
-	mux.HandleFunc("POST /api/artisan/products", middleware.Auth(middleware.ArtisanOnly(productHandler.CreateProduct)))
-	mux.HandleFunc("PUT /api/artisan/products/{id}", middleware.Auth(middleware.ArtisanOnly(productHandler.UpdateProduct)))
-	mux.HandleFunc("GET /api/artisan/orders", middleware.Auth(middleware.ArtisanOnly(orderHandler.GetArtisanOrders)))
-	mux.HandleFunc("PUT /api/artisan/orders/{id}/status", middleware.Auth(middleware.ArtisanOnly(orderHandler.UpdateOrderStatus)))
-	mux.HandleFunc("POST /api/artisan/orders/{id}/progress", middleware.Auth(middleware.ArtisanOnly(orderHandler.AddProgressUpdate)))
-
-	// AI routes
-	mux.HandleFunc("POST /api/ai/generate-story", middleware.Auth(middleware.ArtisanOnly(aiHandler.GenerateProductStory)))
-	mux.HandleFunc("GET /api/ai/confidence-score/{productId}", aiHandler.GetConfidenceScore)
-	mux.HandleFunc("GET /api/ai/delivery-eta/{orderId}", middleware.Auth(aiHandler.GetDeliveryETA))
-
-	// Admin routes
-	mux.HandleFunc("GET /api/admin/pending-artisans", middleware.Auth(middleware.AdminOnly(adminHandler.GetPendingArtisans)))
-	mux.HandleFunc("PUT /api/admin/artisans/{id}/verify", middleware.Auth(middleware.AdminOnly(adminHandler.VerifyArtisan)))
-	mux.HandleFunc("GET /api/admin/pending-products", middleware.Auth(middleware.AdminOnly(adminHandler.GetPendingProducts)))
-	mux.HandleFunc("PUT /api/admin/products/{id}/approve", middleware.Auth(middleware.AdminOnly(adminHandler.ApproveProduct)))
-	mux.HandleFunc("POST /api/admin/categories", middleware.Auth(middleware.AdminOnly(adminHandler.CreateCategory)))
-	mux.HandleFunc("GET /api/admin/analytics", middleware.Auth(middleware.AdminOnly(adminHandler.GetAnalytics)))
-
-	// Payment
-	mux.HandleFunc("POST /api/orders/with-payment", middleware.Auth(orderHandler.CreateOrderWithPayment))
-	mux.HandleFunc("GET /api/artisan/earnings", middleware.Auth(middleware.ArtisanOnly(paymentHandler.GetArtisanEarnings)))
-	handler := middleware.CORS(mux)
-
-	// Video Call
-	mux.HandleFunc("POST /api/video-call/request", middleware.Auth(videoCallHandler.RequestCall))
-	mux.HandleFunc("GET /api/video-call/pending", middleware.Auth(middleware.ArtisanOnly(videoCallHandler.GetPendingCalls)))
-	mux.HandleFunc("PUT /api/video-call/{id}/accept", middleware.Auth(middleware.ArtisanOnly(videoCallHandler.AcceptCall)))
-	mux.HandleFunc("GET /api/video-call/{id}/status", middleware.Auth(videoCallHandler.GetCallStatus))
-
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
-
-	log.Printf("Server starting on port %s", port)
-	if err := http.ListenAndServe(":"+port, handler); err != nil {
+	if err := bootstrapAdmin(db, cfg.Bootstrap); err != nil {
+		log.Fatal("Failed to bootstrap admin:", err)
+	}
+
+	mail := mailer.New(cfg.Mail)
+
+	corsConfig, err := middleware.NewCORSConfig(cfg.CORS.AllowedOrigins, cfg.CORS.AdminOrigins, cfg.CORS.PublicOrigins,
+		cfg.CORS.AllowCredentials, cfg.CORS.MaxAge)
+	if err != nil {
+		log.Fatal("Failed to configure CORS:", err)
+	}
+
+	if err := middleware.UseTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatal("Failed to configure trusted proxies:", err)
+	}
+
+	tokenService, err := token.Load(cfg.JWT)
+	if err != nil {
+		log.Fatal("Failed to load signing keys:", err)
+	}
+	slog.Info("Signing tokens", "key_id", tokenService.ActiveKeyID())
+
+	sessionStore := session.NewStore(db, cfg.Auth.RefreshTokenTTL)
+	middleware.UseSessions(sessionStore)
+	middleware.UseTokens(tokenService)
+
+	if err := rbac.Seed(context.Background(), db); err != nil {
+		log.Fatal("Failed to seed permissions:", err)
+	}
+	permissionStore := rbac.NewStore(db)
+	middleware.UsePermissions(permissionStore)
+
+	apiKeyStore := apikey.NewStore(db)
+	middleware.UseAPIKeys(apiKeyStore)
+
+	loginGuard := loginguard.New(db)
+	idempotencyStore := idempotency.NewStore(db)
+	middleware.UseIdempotency(idempotencyStore)
+
+	purgeCtx, stopPurges := context.WithCancel(context.Background())
+	defer stopPurges()
+	go runPurges(purgeCtx, purgeInterval, map[string]purger{
+		"idempotency_keys": idempotencyStore,
+		"login_attempts":   loginGuard,
+	})
+
+	healthHandler := handlers.NewHealthHandler(db)
+	mux := routes(routeHandlers{
+		auth:         handlers.NewAuthHandler(db, cfg, sessionStore, tokenService, mail, loginGuard, apiKeyStore),
+		product:      handlers.NewProductHandler(db, cfg),
+		order:        handlers.NewOrderHandler(db, cfg),
+		artisan:      handlers.NewArtisanHandler(db),
+		admin:        handlers.NewAdminHandler(db, cfg, sessionStore, tokenService, mail, loginGuard, permissionStore, apiKeyStore),
+		review:       handlers.NewReviewHandler(db),
+		ai:           handlers.NewAIHandler(db, cfg),
+		payment:      handlers.NewPaymentHandler(db, cfg),
+		videoCall:    handlers.NewVideoCallHandler(db),
+		address:      handlers.NewAddressHandler(db),
+		keys:         handlers.NewKeysHandler(tokenService),
+		apiKey:       handlers.NewAPIKeyHandler(apiKeyStore),
+		health:       healthHandler,
+		metricsToken: cfg.Metrics.Token,
+	})
+
+	handler := middleware.Logging(logger, middleware.Tracing(middleware.Metrics(middleware.CORS(corsConfig, mux))))
+
+	port := strconv.Itoa(cfg.Server.Port)
+
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      60 * time.Second,
+		IdleTimeout:       120 * time.Second,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Server starting", "port", port)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case err := <-serverErr:
 		log.Fatal("Server failed:", err)
+	case sig := <-signals:
+		slog.Info("Shutting down", "signal", sig.String())
+	}
+
+	// Fail readiness first so the load balancer stops sending new requests,
+	// then let in-flight ones (checkouts in particular) run to completion.
+	healthHandler.Drain()
+	stopPurges()
+	time.Sleep(drainDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Error("Graceful shutdown timed out, closing remaining connections", "error", err)
+		server.Close()
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
+	}
+	slog.Info("Server stopped")
+}
+
+const (
+	// drainDelay gives the orchestrator time to notice /readyz failing
+	// before the listener closes.
+	drainDelay = 5 * time.Second
+	// shutdownTimeout bounds how long in-flight requests may take to finish;
+	// keep drainDelay plus this under the orchestrator's termination grace period.
+	shutdownTimeout = 20 * time.Second
+)
+
+// newLogger builds the process logger. Format text switches to
+// human-readable output for local development.
+func newLogger(cfg config.Log) *slog.Logger {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		level = slog.LevelInfo
+	}
+	opts := &slog.HandlerOptions{Level: level}
+
+	if cfg.Format == "text" {
+		return slog.New(slog.NewTextHandler(os.Stdout, opts))
 	}
+	return slog.New(slog.NewJSONHandler(os.Stdout, opts))
 }
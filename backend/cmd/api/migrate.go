@@ -0,0 +1,108 @@
+// backend/cmd/api/migrate.go
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"backend/internal/config"
+	"backend/internal/database"
+)
+
+var errMigrateUsage = errors.New("usage: api migrate up | down [steps] | status")
+
+// migrateCommand connects with only the database configuration and runs
+// the migrate subcommand.
+func migrateCommand(args []string) error {
+	cfg, err := config.LoadDatabase()
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	db, err := database.InitDB(cfg.URL)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return runMigrate(context.Background(), db, args, os.Stdout)
+}
+
+// runMigrate implements the migrate subcommand:
+//
+//	migrate up            apply every pending migration
+//	migrate down [steps]  revert the latest steps migrations (default 1)
+//	migrate status        list migrations and when each was applied
+func runMigrate(ctx context.Context, db *sql.DB, args []string, out io.Writer) error {
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return errMigrateUsage
+	}
+
+	switch args[0] {
+	case "up":
+		if len(args) > 1 {
+			return errMigrateUsage
+		}
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Fprintf(out, "applied  %04d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(out, "no pending migrations")
+		}
+		return err
+
+	case "down":
+		steps := 1
+		if len(args) == 2 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("steps must be a positive number, not %q", args[1])
+			}
+		} else if len(args) > 2 {
+			return errMigrateUsage
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Fprintf(out, "reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(reverted) == 0 {
+			fmt.Fprintln(out, "no applied migrations")
+		}
+		return err
+
+	case "status":
+		if len(args) > 1 {
+			return errMigrateUsage
+		}
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format(time.RFC3339)
+			}
+			if s.Unknown {
+				applied += " (not in this build)"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return w.Flush()
+
+	default:
+		return errMigrateUsage
+	}
+}
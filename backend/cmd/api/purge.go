@@ -0,0 +1,45 @@
+// backend/cmd/api/purge.go
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// purgeInterval is how often rows past their retention period are deleted.
+const purgeInterval = time.Hour
+
+// purger deletes the rows of one table that are past their retention period
+// and reports how many it removed.
+type purger interface {
+	Purge(ctx context.Context) (int64, error)
+}
+
+// runPurges purges every table once straight away and then every interval,
+// until ctx is done. Failures are logged and retried on the next tick.
+func runPurges(ctx context.Context, interval time.Duration, tables map[string]purger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for table, p := range tables {
+			removed, err := p.Purge(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					slog.Error("Failed to purge expired rows", "table", table, "error", err)
+				}
+				continue
+			}
+			if removed > 0 {
+				slog.Info("Purged expired rows", "table", table, "rows", removed)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// backend/cmd/api/purge_test.go
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type countingPurger struct {
+	mu    sync.Mutex
+	calls int
+	err   error
+}
+
+func (p *countingPurger) Purge(ctx context.Context) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	return 1, p.err
+}
+
+func (p *countingPurger) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls
+}
+
+func TestRunPurgesRepeatsUntilCancelled(t *testing.T) {
+	ok := &countingPurger{}
+	failing := &countingPurger{err: errors.New("connection refused")}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		runPurges(ctx, time.Millisecond, map[string]purger{"ok": ok, "failing": failing})
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for ok.count() < 3 || failing.count() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("purges ran %d and %d times, want at least 3 each; a failing table must not stop the others", ok.count(), failing.count())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("runPurges did not return after its context was cancelled")
+	}
+}
+
+func TestRunPurgesStartsImmediately(t *testing.T) {
+	p := &countingPurger{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	runPurges(ctx, time.Hour, map[string]purger{"t": p})
+	if p.count() != 1 {
+		t.Errorf("purged %d times before the first tick, want 1", p.count())
+	}
+}
@@ -0,0 +1,215 @@
+// backend/cmd/api/routes.go
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"backend/internal/apikey"
+	"backend/internal/handlers"
+	"backend/internal/metrics"
+	"backend/internal/middleware"
+	"backend/internal/openapi"
+	"backend/internal/ratelimit"
+	"backend/internal/rbac"
+)
+
+// routeHandlers is everything routes needs to serve the API.
+type routeHandlers struct {
+	auth         *handlers.AuthHandler
+	product      *handlers.ProductHandler
+	order        *handlers.OrderHandler
+	artisan      *handlers.ArtisanHandler
+	admin        *handlers.AdminHandler
+	review       *handlers.ReviewHandler
+	ai           *handlers.AIHandler
+	payment      *handlers.PaymentHandler
+	videoCall    *handlers.VideoCallHandler
+	address      *handlers.AddressHandler
+	keys         *handlers.KeysHandler
+	apiKey       *handlers.APIKeyHandler
+	health       *handlers.HealthHandler
+	metricsToken string
+}
+
+// routeTable is a ServeMux that remembers the patterns registered on it, so
+// tests can check them against the OpenAPI document.
+type routeTable struct {
+	*http.ServeMux
+	patterns   []string
+	deprecated []string
+}
+
+// The unversioned /api routes are v1. They stay as they are for the shipped
+// frontend; routes listed in v1Superseded have a /api/v2 replacement at the
+// same path and announce their removal.
+var (
+	v1DeprecatedAt = time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)
+	v1Sunset       = time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+
+	v1Superseded = map[string]bool{
+		"GET /api/products":               true,
+		"GET /api/products/{id}/reviews":  true,
+		"GET /api/orders":                 true,
+		"GET /api/artisan/orders":         true,
+		"GET /api/admin/pending-artisans": true,
+		"GET /api/admin/pending-products": true,
+		"GET /api/admin/orders":           true,
+		"GET /api/video-call/pending":     true,
+	}
+)
+
+func newRouteTable() *routeTable {
+	return &routeTable{ServeMux: http.NewServeMux()}
+}
+
+func (t *routeTable) Handle(pattern string, handler http.Handler) {
+	t.ServeMux.Handle(pattern, handler)
+	t.patterns = append(t.patterns, pattern)
+}
+
+func (t *routeTable) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	t.ServeMux.HandleFunc(pattern, handler)
+	t.patterns = append(t.patterns, pattern)
+}
+
+// v1 registers a route given as /api/... both there and under /api/v1.
+func (t *routeTable) v1(pattern string, handler http.HandlerFunc) {
+	method, path, _ := strings.Cut(pattern, " ")
+	versioned := method + " /api/v1" + strings.TrimPrefix(path, "/api")
+
+	if v1Superseded[pattern] {
+		handler = middleware.Deprecated(v1DeprecatedAt, v1Sunset, "/api/v2"+strings.TrimPrefix(path, "/api"), handler)
+		t.deprecated = append(t.deprecated, pattern, versioned)
+	}
+	t.HandleFunc(pattern, handler)
+	t.HandleFunc(versioned, handler)
+}
+
+// routes registers every endpoint. Document new ones in internal/openapi.
+func routes(h routeHandlers) *routeTable {
+	// Rate limits per route group. Anonymous endpoints are keyed on the
+	// client IP, authenticated ones on the user.
+	authLimit := middleware.RateLimit("auth", ratelimit.PerMinute(20, 10), middleware.ByIP)
+	registerLimit := middleware.RateLimit("register", ratelimit.PerHour(5, 5), middleware.ByIP)
+	emailLimit := middleware.RateLimit("email", ratelimit.PerHour(5, 3), middleware.ByUser)
+	orderLimit := middleware.RateLimit("orders", ratelimit.PerHour(30, 10), middleware.ByUser)
+	reviewLimit := middleware.RateLimit("reviews", ratelimit.PerHour(10, 5), middleware.ByUser)
+	videoCallLimit := middleware.RateLimit("video_calls", ratelimit.PerHour(10, 3), middleware.ByUser)
+	aiLimit := middleware.RateLimit("ai", ratelimit.PerHour(30, 10), middleware.ByUser)
+
+	// Cache lifetimes for the public catalog. Once they run out clients
+	// revalidate with the ETag, so writes show up by then at the latest.
+	listingCache := middleware.Cache("public, max-age=30")
+	productCache := middleware.Cache("public, max-age=60")
+	categoryCache := middleware.Cache("public, max-age=300")
+
+	mux := newRouteTable()
+
+	// Operations
+	mux.Handle("GET /metrics", metrics.Handler(h.metricsToken))
+	mux.HandleFunc("GET /healthz", h.health.Healthz)
+	mux.HandleFunc("GET /readyz", h.health.Readyz)
+	mux.HandleFunc("GET /api/openapi.json", openapi.Handler())
+	mux.HandleFunc("GET /api/docs", openapi.DocsHandler("/api/openapi.json"))
+
+	// Public routes
+	mux.HandleFunc("GET /.well-known/jwks.json", h.keys.JWKS)
+	mux.v1("POST /api/auth/register", registerLimit(h.auth.Register))
+	mux.v1("POST /api/auth/login", authLimit(h.auth.Login))
+	mux.v1("POST /api/auth/refresh", authLimit(h.auth.Refresh))
+	mux.v1("POST /api/auth/logout", middleware.Auth(h.auth.Logout))
+	mux.v1("POST /api/auth/forgot-password", authLimit(h.auth.ForgotPassword))
+	mux.v1("POST /api/auth/reset-password", authLimit(h.auth.ResetPassword))
+	mux.v1("POST /api/auth/verify-email", authLimit(h.auth.VerifyEmail))
+	mux.v1("POST /api/auth/resend-verification", middleware.Auth(emailLimit(h.auth.ResendVerification)))
+	mux.v1("POST /api/auth/accept-invitation", authLimit(h.auth.AcceptInvitation))
+	mux.v1("POST /api/auth/login/2fa", authLimit(h.auth.VerifyTwoFactorLogin))
+	mux.v1("POST /api/auth/2fa/setup", middleware.Auth(h.auth.SetupTwoFactor))
+	mux.v1("POST /api/auth/2fa/enable", middleware.Auth(h.auth.EnableTwoFactor))
+	mux.v1("POST /api/auth/2fa/disable", middleware.Auth(h.auth.DisableTwoFactor))
+	mux.v1("POST /api/auth/2fa/recovery-codes", middleware.Auth(h.auth.RegenerateRecoveryCodes))
+	mux.v1("GET /api/me", middleware.Auth(h.auth.GetMe))
+	mux.v1("PUT /api/me", middleware.Auth(h.auth.UpdateMe))
+	mux.v1("DELETE /api/me", middleware.Auth(h.auth.DeleteAccount))
+	mux.v1("PUT /api/me/password", middleware.Auth(h.auth.ChangePassword))
+	mux.v1("GET /api/me/export", middleware.Auth(h.auth.ExportData))
+	mux.v1("GET /api/products", listingCache(h.product.ListProducts))
+	mux.v1("GET /api/products/{id}", productCache(h.product.GetProduct))
+	mux.v1("GET /api/categories", categoryCache(h.product.ListCategories))
+	mux.v1("GET /api/artisans/{id}", h.artisan.GetArtisanProfile)
+
+	// Protected routes - Buyer
+	mux.v1("POST /api/orders", middleware.Auth(middleware.Idempotent(orderLimit(h.order.CreateOrder))))
+	mux.v1("GET /api/orders", middleware.Auth(h.order.GetUserOrders))
+	mux.v1("GET /api/orders/{id}", middleware.Auth(h.order.GetOrderDetails))
+	mux.v1("GET /api/addresses", middleware.Auth(h.address.ListAddresses))
+	mux.v1("POST /api/addresses", middleware.Auth(h.address.CreateAddress))
+	mux.v1("PUT /api/addresses/{id}", middleware.Auth(h.address.UpdateAddress))
+	mux.v1("DELETE /api/addresses/{id}", middleware.Auth(h.address.DeleteAddress))
+	mux.v1("POST /api/reviews", middleware.Auth(middleware.Idempotent(reviewLimit(h.review.CreateReview))))
+
+	mux.v1("GET /api/products/{id}/reviews", h.review.GetProductReviews)
+
+	// Protected routes - Artisan
+	mux.v1("POST /api/artisan/onboard", middleware.Auth(middleware.VerifiedOnly(h.artisan.OnboardArtisan)))
+	mux.v1("PUT /api/artisan/profile", middleware.RequireScope(apikey.ScopeProfileWrite, middleware.Auth(middleware.RequirePermission(rbac.ArtisanProfile)(h.artisan.UpdateProfile))))
+	mux.v1("POST /api/artisan/products", middleware.RequireScope(apikey.ScopeProductsWrite, middleware.Auth(middleware.RequirePermission(rbac.ProductsWrite)(h.product.CreateProduct))))
+	mux.v1("PUT /api/artisan/products/{id}", middleware.RequireScope(apikey.ScopeProductsWrite, middleware.Auth(middleware.RequirePermission(rbac.ProductsWrite)(h.product.UpdateProduct))))
+	mux.v1("GET /api/artisan/orders", middleware.RequireScope(apikey.ScopeOrdersRead, middleware.Auth(middleware.RequirePermission(rbac.OrdersFulfill)(h.order.GetArtisanOrders))))
+	mux.v1("PUT /api/artisan/orders/{id}/status", middleware.RequireScope(apikey.ScopeOrdersWrite, middleware.Auth(middleware.RequirePermission(rbac.OrdersFulfill)(h.order.UpdateOrderStatus))))
+	mux.v1("POST /api/artisan/orders/{id}/progress", middleware.RequireScope(apikey.ScopeOrdersWrite, middleware.Auth(middleware.RequirePermission(rbac.OrdersFulfill)(h.order.AddProgressUpdate))))
+
+	// API keys are managed with a logged-in session only, never with another key
+	mux.v1("POST /api/artisan/api-keys", middleware.Auth(middleware.RequirePermission(rbac.APIKeysManage)(h.apiKey.CreateKey)))
+	mux.v1("GET /api/artisan/api-keys", middleware.Auth(middleware.RequirePermission(rbac.APIKeysManage)(h.apiKey.ListKeys)))
+	mux.v1("DELETE /api/artisan/api-keys/{id}", middleware.Auth(middleware.RequirePermission(rbac.APIKeysManage)(h.apiKey.RevokeKey)))
+
+	// AI routes
+	mux.v1("POST /api/ai/generate-story", middleware.Auth(aiLimit(middleware.RequirePermission(rbac.AIStories)(h.ai.GenerateProductStory))))
+	mux.v1("GET /api/ai/confidence-score/{productId}", h.ai.GetConfidenceScore)
+	mux.v1("GET /api/ai/delivery-eta/{orderId}", middleware.Auth(h.ai.GetDeliveryETA))
+
+	// Admin routes
+	mux.v1("GET /api/admin/pending-artisans", middleware.Auth(middleware.RequirePermission(rbac.ArtisansApprove)(h.admin.GetPendingArtisans)))
+	mux.v1("PUT /api/admin/artisans/{id}/verify", middleware.Auth(middleware.RequirePermission(rbac.ArtisansApprove)(h.admin.VerifyArtisan)))
+	mux.v1("GET /api/admin/pending-products", middleware.Auth(middleware.RequirePermission(rbac.ProductsApprove)(h.admin.GetPendingProducts)))
+	mux.v1("PUT /api/admin/products/{id}/approve", middleware.Auth(middleware.RequirePermission(rbac.ProductsApprove)(h.admin.ApproveProduct)))
+	mux.v1("POST /api/admin/categories", middleware.Auth(middleware.RequirePermission(rbac.CategoriesManage)(h.admin.CreateCategory)))
+	mux.v1("GET /api/admin/analytics", middleware.Auth(middleware.RequirePermission(rbac.AnalyticsRead)(h.admin.GetAnalytics)))
+	mux.v1("GET /api/admin/orders", middleware.Auth(middleware.RequirePermission(rbac.OrdersRead)(h.admin.ListOrders)))
+	mux.v1("POST /api/admin/users/{id}/revoke-sessions", middleware.Auth(middleware.RequirePermission(rbac.UsersManage)(h.admin.RevokeUserSessions)))
+	mux.v1("POST /api/admin/users/{id}/unlock", middleware.Auth(middleware.RequirePermission(rbac.UsersManage)(h.admin.UnlockUser)))
+	mux.v1("PUT /api/admin/users/{id}/role", middleware.Auth(middleware.RequirePermission(rbac.SecurityManage)(h.admin.UpdateUserRole)))
+	mux.v1("GET /api/admin/lockouts", middleware.Auth(middleware.RequirePermission(rbac.UsersManage)(h.admin.ListLockouts)))
+	mux.v1("POST /api/admin/invitations", middleware.Auth(middleware.RequirePermission(rbac.InvitationsManage)(h.admin.CreateInvitation)))
+	mux.v1("GET /api/admin/invitations", middleware.Auth(middleware.RequirePermission(rbac.InvitationsManage)(h.admin.ListInvitations)))
+	mux.v1("DELETE /api/admin/invitations/{id}", middleware.Auth(middleware.RequirePermission(rbac.InvitationsManage)(h.admin.RevokeInvitation)))
+	mux.v1("GET /api/admin/security/roles", middleware.Auth(middleware.RequirePermission(rbac.SecurityManage)(h.admin.ListRolePolicies)))
+	mux.v1("GET /api/admin/security/permissions", middleware.Auth(middleware.RequirePermission(rbac.SecurityManage)(h.admin.ListPermissions)))
+	mux.v1("PUT /api/admin/security/roles/{role}/permissions", middleware.Auth(middleware.RequirePermission(rbac.SecurityManage)(h.admin.UpdateRolePermissions)))
+	mux.v1("PUT /api/admin/security/roles/{role}", middleware.Auth(middleware.RequirePermission(rbac.SecurityManage)(h.admin.UpdateRolePolicy)))
+
+	// Payment
+	mux.v1("POST /api/orders/with-payment", middleware.Auth(middleware.VerifiedOnly(middleware.Idempotent(orderLimit(h.order.CreateOrderWithPayment)))))
+	mux.v1("GET /api/artisan/earnings", middleware.RequireScope(apikey.ScopeEarningsRead, middleware.Auth(middleware.RequirePermission(rbac.EarningsRead)(h.payment.GetArtisanEarnings))))
+
+	// Video Call
+	mux.v1("POST /api/video-call/request", middleware.Auth(middleware.VerifiedOnly(middleware.Idempotent(videoCallLimit(h.videoCall.RequestCall)))))
+	mux.v1("GET /api/video-call/pending", middleware.Auth(middleware.RequirePermission(rbac.VideoCallsAnswer)(h.videoCall.GetPendingCalls)))
+	mux.v1("PUT /api/video-call/{id}/accept", middleware.Auth(middleware.RequirePermission(rbac.VideoCallsAnswer)(h.videoCall.AcceptCall)))
+	mux.v1("GET /api/video-call/{id}/status", middleware.Auth(h.videoCall.GetCallStatus))
+
+	// v2: list endpoints return a page of results with pagination details
+	mux.HandleFunc("GET /api/v2/products", listingCache(h.product.ListProductsPage))
+	mux.HandleFunc("GET /api/v2/products/{id}/reviews", h.review.GetProductReviewsPage)
+	mux.HandleFunc("GET /api/v2/orders", middleware.Auth(h.order.GetUserOrdersPage))
+	mux.HandleFunc("GET /api/v2/artisan/orders", middleware.RequireScope(apikey.ScopeOrdersRead, middleware.Auth(middleware.RequirePermission(rbac.OrdersFulfill)(h.order.GetArtisanOrdersPage))))
+	mux.HandleFunc("GET /api/v2/admin/pending-artisans", middleware.Auth(middleware.RequirePermission(rbac.ArtisansApprove)(h.admin.GetPendingArtisansPage)))
+	mux.HandleFunc("GET /api/v2/admin/pending-products", middleware.Auth(middleware.RequirePermission(rbac.ProductsApprove)(h.admin.GetPendingProductsPage)))
+	mux.HandleFunc("GET /api/v2/admin/orders", middleware.Auth(middleware.RequirePermission(rbac.OrdersRead)(h.admin.ListOrdersPage)))
+	mux.HandleFunc("GET /api/v2/video-call/pending", middleware.Auth(middleware.RequirePermission(rbac.VideoCallsAnswer)(h.videoCall.GetPendingCallsPage)))
+
+	return mux
+}
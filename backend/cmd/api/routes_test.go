@@ -0,0 +1,113 @@
+// backend/cmd/api/routes_test.go
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"backend/internal/openapi"
+)
+
+// Handlers are never called here; nil ones are enough to register routes.
+func registeredRoutes() []string {
+	patterns := append([]string(nil), routes(routeHandlers{}).patterns...)
+	sort.Strings(patterns)
+	return patterns
+}
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	documented := map[string]bool{}
+	for _, route := range openapi.Routes() {
+		documented[route] = true
+	}
+
+	for _, pattern := range registeredRoutes() {
+		if !documented[pattern] {
+			t.Errorf("%q is registered on the mux but missing from the OpenAPI document (internal/openapi/spec.go)", pattern)
+		}
+	}
+}
+
+func TestEveryDocumentedRouteExists(t *testing.T) {
+	registered := map[string]bool{}
+	for _, pattern := range registeredRoutes() {
+		registered[pattern] = true
+	}
+
+	for _, route := range openapi.Routes() {
+		if !registered[route] {
+			t.Errorf("%q is in the OpenAPI document but no handler is registered for it", route)
+		}
+	}
+}
+
+func TestDeprecatedRoutesAreDocumented(t *testing.T) {
+	deprecated := map[string]bool{}
+	for _, pattern := range routes(routeHandlers{}).deprecated {
+		deprecated[pattern] = true
+	}
+
+	for path, item := range openapi.Spec().Paths {
+		for method, op := range item {
+			route := strings.ToUpper(method) + " " + path
+			if op.Deprecated != deprecated[route] {
+				t.Errorf("%q: deprecated is %v in the OpenAPI document but %v on the mux", route, op.Deprecated, deprecated[route])
+			}
+		}
+	}
+}
+
+func TestOpenAPIDocumentIsServed(t *testing.T) {
+	rec := httptest.NewRecorder()
+	routes(routeHandlers{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/openapi.json: status %d", rec.Code)
+	}
+	var doc openapi.Document
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("GET /api/openapi.json: invalid JSON: %v", err)
+	}
+	if doc.OpenAPI == "" || len(doc.Paths) == 0 {
+		t.Fatalf("GET /api/openapi.json: document has no version or paths")
+	}
+
+	for name, schema := range doc.Components.Schemas {
+		if schema == nil {
+			t.Errorf("schema %s is empty", name)
+		}
+	}
+}
+
+func TestSupersededRoutesHaveARegisteredSuccessor(t *testing.T) {
+	mux := routes(routeHandlers{})
+	registered := map[string]bool{}
+	for _, pattern := range mux.patterns {
+		registered[pattern] = true
+	}
+	deprecated := map[string]bool{}
+	for _, pattern := range mux.deprecated {
+		deprecated[pattern] = true
+	}
+
+	for pattern := range v1Superseded {
+		method, path, _ := strings.Cut(pattern, " ")
+		successor := method + " /api/v2" + strings.TrimPrefix(path, "/api")
+		if !registered[successor] {
+			t.Errorf("%q is deprecated but %q is not registered", pattern, successor)
+		}
+		if versioned := method + " /api/v1" + strings.TrimPrefix(path, "/api"); !deprecated[pattern] || !deprecated[versioned] {
+			t.Errorf("%q should be deprecated both unversioned and under /api/v1", pattern)
+		}
+	}
+
+	for _, pattern := range mux.deprecated {
+		if strings.Contains(pattern, " /api/v2/") {
+			t.Errorf("%q is a v2 route but is marked deprecated", pattern)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// backend/internal/apikey/apikey.go
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/securetoken"
+)
+
+// Prefix marks a bearer credential as an API key rather than a JWT.
+const Prefix = "crk_"
+
+// Scopes an artisan can grant to a key.
+const (
+	ScopeProductsWrite = "products:write"
+	ScopeOrdersRead    = "orders:read"
+	ScopeOrdersWrite   = "orders:write"
+	ScopeProfileWrite  = "profile:write"
+	ScopeEarningsRead  = "earnings:read"
+)
+
+var Scopes = []string{ScopeProductsWrite, ScopeOrdersRead, ScopeOrdersWrite, ScopeProfileWrite, ScopeEarningsRead}
+
+var (
+	ErrInvalidKey   = errors.New("invalid or revoked API key")
+	ErrUnknownScope = errors.New("unknown scope")
+	ErrNotFound     = errors.New("API key not found")
+)
+
+type Key struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (k *Key) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Owner is the account a verified key acts on behalf of.
+type Owner struct {
+	UserID        int
+	Email         string
+	Role          models.UserRole
+	EmailVerified bool
+}
+
+// Store persists API keys. Only a SHA-256 hash of each key is kept.
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Create issues a key for the user and returns it with the plaintext secret,
+// which cannot be recovered later.
+func (s *Store) Create(ctx context.Context, userID int, name string, scopes []string, expiresAt *time.Time) (*Key, string, error) {
+	for _, scope := range scopes {
+		if !validScope(scope) {
+			return nil, "", fmt.Errorf("%w: %s", ErrUnknownScope, scope)
+		}
+	}
+
+	secret, _, err := securetoken.New()
+	if err != nil {
+		return nil, "", err
+	}
+	plaintext := Prefix + secret
+
+	key := &Key{UserID: userID, Name: name, Prefix: plaintext[:len(Prefix)+6], Scopes: scopes, ExpiresAt: expiresAt}
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, userID, name, key.Prefix, securetoken.Hash(plaintext), strings.Join(scopes, " "), expiresAt).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	return key, plaintext, nil
+}
+
+// List returns the user's keys, including revoked ones, newest first.
+func (s *Store) List(ctx context.Context, userID int) ([]Key, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, name, prefix, scopes, expires_at, last_used_at, revoked_at, created_at
+		FROM api_keys WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []Key{}
+	for rows.Next() {
+		var k Key
+		var scopes string
+		if err := rows.Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, &scopes, &k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt, &k.CreatedAt); err != nil {
+			return nil, err
+		}
+		k.Scopes = strings.Fields(scopes)
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// Revoke disables one of the user's keys.
+func (s *Store) Revoke(ctx context.Context, userID, keyID int) error {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`, keyID, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RevokeAll disables every active key the user holds, for when the account
+// itself is compromised and signing it out is not enough.
+func (s *Store) RevokeAll(ctx context.Context, userID int) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Verify looks up an active key and the account it belongs to.
+func (s *Store) Verify(ctx context.Context, plaintext string) (*Key, *Owner, error) {
+	var k Key
+	var owner Owner
+	var scopes string
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE api_keys k SET last_used_at = NOW()
+		FROM users u
+		WHERE k.key_hash = $1 AND u.id = k.user_id
+			AND k.revoked_at IS NULL AND (k.expires_at IS NULL OR k.expires_at > NOW())
+		RETURNING k.id, k.user_id, k.name, k.prefix, k.scopes, k.expires_at, k.last_used_at, k.created_at,
+			u.email, u.role, u.email_verified
+	`, securetoken.Hash(plaintext)).Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, &scopes, &k.ExpiresAt, &k.LastUsedAt, &k.CreatedAt,
+		&owner.Email, &owner.Role, &owner.EmailVerified)
+	if err == sql.ErrNoRows {
+		return nil, nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	k.Scopes = strings.Fields(scopes)
+	owner.UserID = k.UserID
+	return &k, &owner, nil
+}
+
+func validScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
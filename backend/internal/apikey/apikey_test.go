@@ -0,0 +1,149 @@
+// backend/internal/apikey/apikey_test.go
+package apikey
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/database/dbtest"
+)
+
+func TestHasScope(t *testing.T) {
+	k := &Key{Scopes: []string{ScopeOrdersRead, ScopeProductsWrite}}
+
+	for _, tt := range []struct {
+		scope string
+		want  bool
+	}{
+		{ScopeOrdersRead, true},
+		{ScopeProductsWrite, true},
+		{ScopeOrdersWrite, false},
+		{"", false},
+		{"orders", false},
+	} {
+		if got := k.HasScope(tt.scope); got != tt.want {
+			t.Errorf("HasScope(%q) = %v, want %v", tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestCreateRejectsUnknownScopes(t *testing.T) {
+	// The scope check runs before the store touches the database.
+	s := NewStore(nil)
+	if _, _, err := s.Create(context.Background(), 1, "ci", []string{ScopeOrdersRead, "admin:all"}, nil); !errors.Is(err, ErrUnknownScope) {
+		t.Errorf("Create = %v, want ErrUnknownScope", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	s := NewStore(db)
+	userID, email := dbtest.CreateUser(t, db)
+
+	create := func(expiresAt *time.Time) (*Key, string) {
+		key, plaintext, err := s.Create(ctx, userID, "sync", []string{ScopeOrdersRead}, expiresAt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key, plaintext
+	}
+	future, past := time.Now().Add(time.Hour), time.Now().Add(-time.Hour)
+
+	active, activeSecret := create(nil)
+	_, expiringSecret := create(&future)
+	_, expiredSecret := create(&past)
+	revoked, revokedSecret := create(nil)
+	if err := s.Revoke(ctx, userID, revoked.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(activeSecret, Prefix) || !strings.HasPrefix(activeSecret, active.Prefix) {
+		t.Errorf("secret %q does not start with %s and its display prefix %s", activeSecret, Prefix, active.Prefix)
+	}
+
+	tests := []struct {
+		name      string
+		plaintext string
+		wantErr   error
+	}{
+		{"active key", activeSecret, nil},
+		{"key that has not expired yet", expiringSecret, nil},
+		{"expired key", expiredSecret, ErrInvalidKey},
+		{"revoked key", revokedSecret, ErrInvalidKey},
+		{"unknown key", Prefix + "unknown", ErrInvalidKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, owner, err := s.Verify(ctx, tt.plaintext)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if owner.UserID != userID || owner.Email != email || !key.HasScope(ScopeOrdersRead) || key.LastUsedAt == nil {
+				t.Errorf("Verify = %+v, %+v", key, owner)
+			}
+		})
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	s := NewStore(db)
+	userID, _ := dbtest.CreateUser(t, db)
+	otherID, _ := dbtest.CreateUser(t, db)
+
+	key, _, err := s.Create(ctx, userID, "sync", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Revoke(ctx, otherID, key.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("revoking another user's key: %v, want ErrNotFound", err)
+	}
+	if err := s.Revoke(ctx, userID, key.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Revoke(ctx, userID, key.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("revoking a key twice: %v, want ErrNotFound", err)
+	}
+}
+
+func TestRevokeAll(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	s := NewStore(db)
+	userID, _ := dbtest.CreateUser(t, db)
+	otherID, _ := dbtest.CreateUser(t, db)
+
+	var secrets []string
+	for i := 0; i < 2; i++ {
+		_, secret, err := s.Create(ctx, userID, "sync", []string{ScopeProductsWrite}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		secrets = append(secrets, secret)
+	}
+	_, otherSecret, err := s.Create(ctx, otherID, "sync", []string{ScopeProductsWrite}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := s.RevokeAll(ctx, userID); err != nil || n != 2 {
+		t.Fatalf("RevokeAll = %d, %v; want 2 keys revoked", n, err)
+	}
+	for _, secret := range secrets {
+		if _, _, err := s.Verify(ctx, secret); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("a key survived RevokeAll: %v", err)
+		}
+	}
+	if _, _, err := s.Verify(ctx, otherSecret); err != nil {
+		t.Errorf("RevokeAll revoked another user's key: %v", err)
+	}
+}
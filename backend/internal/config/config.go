@@ -0,0 +1,333 @@
+// backend/internal/config/config.go
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	Development = "development"
+	Production  = "production"
+)
+
+// Config is everything the server can be configured with. Each field can be
+// set in the YAML file named by CONFIG_FILE and overridden by the variable
+// in its env tag; see config.example.yaml for the defaults.
+type Config struct {
+	// Env is development or production. Production refuses settings that
+	// are only safe on a laptop.
+	Env string `yaml:"env" env:"APP_ENV"`
+	// BaseURL is the frontend origin used to build links in emails.
+	BaseURL string `yaml:"base_url" env:"APP_BASE_URL"`
+
+	Server    Server    `yaml:"server"`
+	Database  Database  `yaml:"database"`
+	Log       Log       `yaml:"log"`
+	JWT       JWT       `yaml:"jwt"`
+	Auth      Auth      `yaml:"auth"`
+	CORS      CORS      `yaml:"cors"`
+	Mail      Mail      `yaml:"mail"`
+	Metrics   Metrics   `yaml:"metrics"`
+	Tracing   Tracing   `yaml:"tracing"`
+	Commerce  Commerce  `yaml:"commerce"`
+	Bootstrap Bootstrap `yaml:"bootstrap"`
+}
+
+type Server struct {
+	Port int `yaml:"port" env:"PORT"`
+	// TrustedProxies lists the reverse proxies, as CIDRs or addresses, whose
+	// X-Forwarded-For is believed. Leave empty when clients connect directly.
+	TrustedProxies []string `yaml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+}
+
+type Database struct {
+	URL string `yaml:"url" env:"DATABASE_URL"`
+	// AutoMigrate applies pending migrations on start. Turn it off to run
+	// them separately with the migrate subcommand.
+	AutoMigrate bool `yaml:"auto_migrate" env:"DB_AUTO_MIGRATE"`
+}
+
+type Log struct {
+	// Level is the minimum level logged: debug, info, warn or error.
+	Level string `yaml:"level" env:"LOG_LEVEL"`
+	// Format is json, or text for human-readable local output.
+	Format string `yaml:"format" env:"LOG_FORMAT"`
+}
+
+// JWT says where the token signing keys come from. See token.Load.
+type JWT struct {
+	KeysDir     string `yaml:"keys_dir" env:"JWT_KEYS_DIR"`
+	PrivateKey  string `yaml:"private_key" env:"JWT_PRIVATE_KEY"`
+	ActiveKeyID string `yaml:"active_kid" env:"JWT_ACTIVE_KID"`
+}
+
+// Ephemeral reports whether no signing key is configured, so a throwaway
+// one is generated on every start.
+func (j JWT) Ephemeral() bool {
+	return j.KeysDir == "" && j.PrivateKey == ""
+}
+
+type Auth struct {
+	// AccessTokenTTL is kept short because refresh tokens carry the
+	// long-lived session.
+	AccessTokenTTL time.Duration `yaml:"access_token_ttl" env:"ACCESS_TOKEN_TTL"`
+	// RefreshTokenTTL is how long a refresh token stays usable without being
+	// rotated.
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" env:"REFRESH_TOKEN_TTL"`
+}
+
+type CORS struct {
+	AllowedOrigins []string `yaml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+	// AdminOrigins defaults to AllowedOrigins.
+	AdminOrigins     []string      `yaml:"admin_origins" env:"CORS_ADMIN_ORIGINS"`
+	PublicOrigins    []string      `yaml:"public_origins" env:"CORS_PUBLIC_ORIGINS"`
+	AllowCredentials bool          `yaml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	MaxAge           time.Duration `yaml:"max_age" env:"CORS_MAX_AGE"`
+}
+
+type Mail struct {
+	// Driver is smtp, or outbox to write messages to OutboxDir so local
+	// development works without a mail server.
+	Driver    string `yaml:"driver" env:"MAIL_DRIVER"`
+	From      string `yaml:"from" env:"MAIL_FROM"`
+	OutboxDir string `yaml:"outbox_dir" env:"MAIL_OUTBOX_DIR"`
+	SMTP      SMTP   `yaml:"smtp"`
+}
+
+type SMTP struct {
+	Host     string `yaml:"host" env:"SMTP_HOST"`
+	Port     string `yaml:"port" env:"SMTP_PORT"`
+	Username string `yaml:"username" env:"SMTP_USERNAME"`
+	Password string `yaml:"password" env:"SMTP_PASSWORD"`
+}
+
+type Metrics struct {
+	// Token, when set, is required as a bearer token on /metrics.
+	Token string `yaml:"token" env:"METRICS_TOKEN"`
+}
+
+type Tracing struct {
+	// Exporter is otlp, console or none. The OTLP exporter reads the
+	// standard OTEL_EXPORTER_OTLP_* variables itself.
+	Exporter string `yaml:"exporter" env:"OTEL_TRACES_EXPORTER"`
+}
+
+type Commerce struct {
+	// PlatformFeeRate is the share of each order kept by Craftora.
+	PlatformFeeRate float64 `yaml:"platform_fee_rate" env:"PLATFORM_FEE_RATE"`
+	// ShippingBuffer is added to a product's crafting time to estimate delivery.
+	ShippingBuffer time.Duration `yaml:"shipping_buffer" env:"SHIPPING_BUFFER"`
+}
+
+// PlatformFee is Craftora's share of amount, rounded to the cent so it and
+// the artisan's remainder add up to amount exactly.
+func (c Commerce) PlatformFee(amount float64) float64 {
+	return math.Round(amount*c.PlatformFeeRate*100) / 100
+}
+
+// Bootstrap creates the first admin on a fresh database. It does nothing
+// once any admin exists, so it can be removed after the first start.
+type Bootstrap struct {
+	AdminEmail    string `yaml:"admin_email" env:"BOOTSTRAP_ADMIN_EMAIL"`
+	AdminPassword string `yaml:"admin_password" env:"BOOTSTRAP_ADMIN_PASSWORD"`
+	AdminName     string `yaml:"admin_name" env:"BOOTSTRAP_ADMIN_NAME"`
+}
+
+// Default returns the settings used for anything not configured.
+func Default() Config {
+	return Config{
+		Env:      Development,
+		BaseURL:  "http://localhost:5173",
+		Server:   Server{Port: 8080},
+		Database: Database{AutoMigrate: true},
+		Log:      Log{Level: "info", Format: "json"},
+		Auth: Auth{
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 30 * 24 * time.Hour,
+		},
+		CORS: CORS{
+			AllowedOrigins: []string{"http://localhost:5173"},
+			PublicOrigins:  []string{"*"},
+			MaxAge:         10 * time.Minute,
+		},
+		Mail: Mail{
+			Driver:    "outbox",
+			From:      "Craftora <no-reply@craftora.local>",
+			OutboxDir: "outbox",
+			SMTP:      SMTP{Port: "587"},
+		},
+		Tracing: Tracing{Exporter: "none"},
+		Commerce: Commerce{
+			PlatformFeeRate: 0.10,
+			ShippingBuffer:  72 * time.Hour,
+		},
+		Bootstrap: Bootstrap{AdminName: "Administrator"},
+	}
+}
+
+// Load reads the configuration: defaults, then the YAML file named by
+// CONFIG_FILE if set, then environment variables (a .env file is read into
+// the environment first). The result is validated.
+func Load() (*Config, error) {
+	cfg, err := read()
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// LoadDatabase reads the configuration like Load but only requires the
+// database settings, for commands such as migrate that need nothing else.
+func LoadDatabase() (*Database, error) {
+	cfg, err := read()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Database.URL == "" {
+		return nil, errors.New("DATABASE_URL is required")
+	}
+	return &cfg.Database, nil
+}
+
+func read() (*Config, error) {
+	// A missing .env is normal outside local development.
+	_ = godotenv.Load()
+
+	cfg := Default()
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := cfg.readFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := applyEnv(&cfg); err != nil {
+		return nil, err
+	}
+
+	for _, s := range []*string{&cfg.Env, &cfg.Log.Level, &cfg.Log.Format, &cfg.Mail.Driver, &cfg.Tracing.Exporter} {
+		*s = strings.ToLower(*s)
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if len(cfg.CORS.AdminOrigins) == 0 {
+		cfg.CORS.AdminOrigins = cfg.CORS.AllowedOrigins
+	}
+	return &cfg, nil
+}
+
+func (c *Config) readFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Production reports whether the server runs in production mode.
+func (c *Config) Production() bool {
+	return c.Env == Production
+}
+
+// Validate reports every problem with the configuration at once.
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.Env != Development && c.Env != Production {
+		fail("APP_ENV must be %s or %s, not %q", Development, Production, c.Env)
+	}
+	if !strings.HasPrefix(c.BaseURL, "http://") && !strings.HasPrefix(c.BaseURL, "https://") {
+		fail("APP_BASE_URL %q must include the scheme", c.BaseURL)
+	}
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		fail("PORT %d is out of range", c.Server.Port)
+	}
+	if c.Database.URL == "" {
+		fail("DATABASE_URL is required")
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		fail("LOG_LEVEL %q must be debug, info, warn or error", c.Log.Level)
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		fail("LOG_FORMAT %q must be json or text", c.Log.Format)
+	}
+
+	if c.Auth.AccessTokenTTL <= 0 {
+		fail("ACCESS_TOKEN_TTL must be positive")
+	}
+	if c.Auth.RefreshTokenTTL <= c.Auth.AccessTokenTTL {
+		fail("REFRESH_TOKEN_TTL must be longer than ACCESS_TOKEN_TTL")
+	}
+	if c.CORS.MaxAge < 0 {
+		fail("CORS_MAX_AGE must not be negative")
+	}
+
+	switch c.Mail.Driver {
+	case "outbox":
+	case "smtp":
+		if c.Mail.SMTP.Host == "" {
+			fail("SMTP_HOST is required with MAIL_DRIVER=smtp")
+		}
+	default:
+		fail("MAIL_DRIVER %q must be smtp or outbox", c.Mail.Driver)
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "otlp", "console", "stdout":
+	default:
+		fail("OTEL_TRACES_EXPORTER %q must be otlp, console or none", c.Tracing.Exporter)
+	}
+
+	if c.Commerce.PlatformFeeRate < 0 || c.Commerce.PlatformFeeRate >= 1 {
+		fail("PLATFORM_FEE_RATE %v must be at least 0 and below 1", c.Commerce.PlatformFeeRate)
+	}
+	if c.Commerce.ShippingBuffer < 0 {
+		fail("SHIPPING_BUFFER must not be negative")
+	}
+
+	if c.Production() {
+		// An ephemeral key signs every restart's tokens differently and
+		// can't be shared between instances.
+		if c.JWT.Ephemeral() {
+			fail("production requires a signing key: set JWT_KEYS_DIR or JWT_PRIVATE_KEY")
+		}
+		// The outbox only writes messages to disk, so verification and
+		// password reset emails would never reach anyone.
+		if c.Mail.Driver == "outbox" {
+			fail("production requires MAIL_DRIVER=smtp; the outbox driver never delivers mail")
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// parseDuration accepts Go durations ("15m", "72h") and, for variables that
+// used to take seconds, a plain number of seconds.
+func parseDuration(s string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(s); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,167 @@
+// backend/internal/config/env_test.go
+package config
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyEnv(t *testing.T) {
+	t.Setenv("PORT", "9090")
+	t.Setenv("DB_AUTO_MIGRATE", "false")
+	t.Setenv("ACCESS_TOKEN_TTL", "900")
+	t.Setenv("REFRESH_TOKEN_TTL", "720h")
+	t.Setenv("CORS_ALLOWED_ORIGINS", " https://craftora.in, ,https://www.craftora.in ")
+	t.Setenv("CORS_MAX_AGE", "5m")
+	t.Setenv("PLATFORM_FEE_RATE", "0.125")
+	t.Setenv("SMTP_HOST", "  smtp.craftora.in  ")
+
+	cfg := Default()
+	if err := applyEnv(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"PORT", cfg.Server.Port, 9090},
+		{"DB_AUTO_MIGRATE", cfg.Database.AutoMigrate, false},
+		{"ACCESS_TOKEN_TTL in seconds", cfg.Auth.AccessTokenTTL, 15 * time.Minute},
+		{"REFRESH_TOKEN_TTL as a duration", cfg.Auth.RefreshTokenTTL, 30 * 24 * time.Hour},
+		{"CORS_ALLOWED_ORIGINS", cfg.CORS.AllowedOrigins, []string{"https://craftora.in", "https://www.craftora.in"}},
+		{"CORS_MAX_AGE", cfg.CORS.MaxAge, 5 * time.Minute},
+		{"PLATFORM_FEE_RATE", cfg.Commerce.PlatformFeeRate, 0.125},
+		{"SMTP_HOST in a nested struct", cfg.Mail.SMTP.Host, "smtp.craftora.in"},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestApplyEnvLeavesUnsetFieldsAlone(t *testing.T) {
+	clearEnv(t)
+
+	cfg := Default()
+	if err := applyEnv(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if want := Default(); !reflect.DeepEqual(cfg, want) {
+		t.Errorf("applyEnv with no variables set changed the defaults:\n got %+v\nwant %+v", cfg, want)
+	}
+}
+
+func TestApplyEnvRejectsBadValues(t *testing.T) {
+	tests := []struct {
+		name, value string
+	}{
+		{"PORT", "eighty"},
+		{"DB_AUTO_MIGRATE", "sometimes"},
+		{"ACCESS_TOKEN_TTL", "15 minutes"},
+		{"CORS_MAX_AGE", "-"},
+		{"PLATFORM_FEE_RATE", "10%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.name, tt.value)
+			cfg := Default()
+			err := applyEnv(&cfg)
+			if err == nil || !strings.Contains(err.Error(), "invalid "+tt.name) {
+				t.Errorf("applyEnv with %s=%q: err = %v, want one naming the variable", tt.name, tt.value, err)
+			}
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"900", 15 * time.Minute, false},
+		{"0", 0, false},
+		{"15m", 15 * time.Minute, false},
+		{"72h", 72 * time.Hour, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"-5s", -5 * time.Second, false},
+		{"1.5", 0, true},
+		{"ten", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseDuration(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseDuration(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// Every env-tagged field must be a kind setField handles; "1" parses as each
+// of them.
+func TestEveryEnvFieldIsSupported(t *testing.T) {
+	var check func(v reflect.Value)
+	check = func(v reflect.Value) {
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.Type.Kind() == reflect.Struct {
+				check(v.Field(i))
+				continue
+			}
+			name := field.Tag.Get("env")
+			if name == "" {
+				continue
+			}
+			if err := setField(v.Field(i), "1"); err != nil {
+				t.Errorf("%s (%s.%s): %v", name, v.Type().Name(), field.Name, err)
+			}
+		}
+	}
+	check(reflect.ValueOf(&Config{}).Elem())
+}
+
+func TestSetFieldRejectsUnsupportedTypes(t *testing.T) {
+	var target struct {
+		Counts []int
+		Limit  uint
+		Extra  map[string]string
+	}
+	v := reflect.ValueOf(&target).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		err := setField(v.Field(i), "1")
+		if err == nil || !strings.Contains(err.Error(), "unsupported field type") {
+			t.Errorf("%s: err = %v, want an unsupported type error", v.Type().Field(i).Name, err)
+		}
+	}
+}
+
+// clearEnv unsets every variable Config reads, restoring them after the test.
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range append(envNames(reflect.TypeOf(Config{})), "CONFIG_FILE") {
+		if old, ok := os.LookupEnv(name); ok {
+			t.Setenv(name, old)
+			os.Unsetenv(name)
+		}
+	}
+}
+
+func envNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			names = append(names, envNames(field.Type)...)
+		} else if name := field.Tag.Get("env"); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,91 @@
+// backend/internal/database/dbtest/dbtest.go
+
+// Package dbtest gives integration tests a database of their own. They run
+// against the Postgres server in TEST_DATABASE_URL and are skipped when it
+// is unset, so `go test ./...` still works without one.
+package dbtest
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+
+	"backend/internal/database"
+)
+
+// EnvURL names the variable holding the test server's connection URL.
+const EnvURL = "TEST_DATABASE_URL"
+
+// Schema connects to a new, empty schema that is dropped when the test ends.
+// Packages are tested in parallel, so each test gets its own tables.
+func Schema(t testing.TB) *sql.DB {
+	t.Helper()
+	url := os.Getenv(EnvURL)
+	if url == "" {
+		t.Skip(EnvURL + " is not set; skipping database test")
+	}
+
+	config, err := pgx.ParseConfig(url)
+	if err != nil {
+		t.Fatalf("%s: %v", EnvURL, err)
+	}
+	admin := stdlib.OpenDB(*config.Copy())
+	t.Cleanup(func() { admin.Close() })
+
+	suffix := make([]byte, 6)
+	rand.Read(suffix)
+	schema := "test_" + hex.EncodeToString(suffix)
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec("DROP SCHEMA " + schema + " CASCADE"); err != nil {
+			t.Errorf("failed to drop schema %s: %v", schema, err)
+		}
+	})
+
+	config.RuntimeParams["search_path"] = schema
+	db := stdlib.OpenDB(*config)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// Open is Schema with every migration applied.
+func Open(t testing.TB) *sql.DB {
+	t.Helper()
+	db := Schema(t)
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("failed to migrate test schema: %v", err)
+	}
+	return db
+}
+
+// CreateUser inserts a buyer with a unique email and returns its ID and email.
+func CreateUser(t testing.TB, db *sql.DB) (int, string) {
+	t.Helper()
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	email := "user-" + hex.EncodeToString(suffix) + "@example.com"
+
+	var id int
+	err := db.QueryRow(`
+		INSERT INTO users (email, password_hash, name)
+		VALUES ($1, 'x', 'Test User')
+		RETURNING id
+	`, email).Scan(&id)
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	return id, email
+}
@@ -0,0 +1,89 @@
+// backend/internal/database/migrate_integration_test.go
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"backend/internal/database"
+	"backend/internal/database/dbtest"
+)
+
+func TestMigratorUpDownStatus(t *testing.T) {
+	db := dbtest.Schema(t)
+	ctx := context.Background()
+	m, err := database.NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) == 0 || applied[0].Version != 1 {
+		t.Fatalf("Up applied %+v, want every migration from 0001", applied)
+	}
+	if again, err := m.Up(ctx); err != nil || len(again) != 0 {
+		t.Errorf("second Up = %+v, %v; want nothing to apply", again, err)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if s.AppliedAt == nil || s.Unknown {
+			t.Errorf("after Up, status of %04d_%s = %+v", s.Version, s.Name, s)
+		}
+	}
+
+	latest := applied[len(applied)-1]
+	reverted, err := m.Down(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reverted) != 1 || reverted[0].Version != latest.Version {
+		t.Errorf("Down(1) reverted %+v, want only %04d", reverted, latest.Version)
+	}
+	statuses, err = m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := statuses[len(statuses)-1]; last.Version != latest.Version || last.AppliedAt != nil {
+		t.Errorf("after Down, status of the latest migration = %+v", last)
+	}
+
+	if _, err := m.Down(ctx, len(applied)); err != nil {
+		t.Fatal(err)
+	}
+	if reapplied, err := m.Up(ctx); err != nil || len(reapplied) != len(applied) {
+		t.Errorf("Up after reverting everything = %d migrations, %v; want %d", len(reapplied), err, len(applied))
+	}
+}
+
+func TestMigratorReportsVersionsFromANewerRelease(t *testing.T) {
+	db := dbtest.Schema(t)
+	ctx := context.Background()
+	m, err := database.NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO schema_migrations (version, name) VALUES (9999, 'from_the_future')"); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := statuses[len(statuses)-1]; last.Version != 9999 || !last.Unknown || last.AppliedAt == nil {
+		t.Errorf("status of an unknown applied version = %+v", last)
+	}
+	if _, err := m.Down(ctx, 1); err == nil {
+		t.Error("Down reverted a migration this build has no file for")
+	}
+}
@@ -0,0 +1,86 @@
+// backend/internal/database/migrate_test.go
+package database
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func migrationFS(names ...string) fstest.MapFS {
+	files := fstest.MapFS{}
+	for _, name := range names {
+		files["migrations/"+name] = &fstest.MapFile{Data: []byte("-- " + name)}
+	}
+	return files
+}
+
+func TestLoadMigrationsPairsAndOrders(t *testing.T) {
+	files := migrationFS(
+		"0010_add_reviews.down.sql", "0010_add_reviews.up.sql",
+		"0002_add_orders.up.sql", "0002_add_orders.down.sql",
+		"0001_initial.up.sql", "0001_initial.down.sql",
+	)
+
+	migrations, err := loadMigrations(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		version int
+		name    string
+	}{{1, "initial"}, {2, "add_orders"}, {10, "add_reviews"}}
+	if len(migrations) != len(want) {
+		t.Fatalf("loaded %d migrations, want %d", len(migrations), len(want))
+	}
+	for i, m := range migrations {
+		if m.Version != want[i].version || m.Name != want[i].name {
+			t.Errorf("migration %d = %04d_%s, want %04d_%s", i, m.Version, m.Name, want[i].version, want[i].name)
+		}
+		prefix := fmt.Sprintf("-- %04d_%s", m.Version, m.Name)
+		if m.up != prefix+".up.sql" || m.down != prefix+".down.sql" {
+			t.Errorf("migration %04d paired up %q with down %q", m.Version, m.up, m.down)
+		}
+	}
+}
+
+func TestLoadMigrationsRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		want  string
+	}{
+		{"missing down file", migrationFS("0001_initial.up.sql"), "needs both an up and a down file"},
+		{"missing up file", migrationFS("0001_initial.down.sql"), "needs both an up and a down file"},
+		{"no version", migrationFS("initial.up.sql", "initial.down.sql"), "name must look like"},
+		{"not up or down", migrationFS("0001_initial.sql"), "name must look like"},
+		{"two names for one version", migrationFS("0001_initial.up.sql", "0001_other.down.sql"), "has two names"},
+		{"no migrations directory", fstest.MapFS{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadMigrations(tt.files)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("loadMigrations error = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrationsLoad(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 || migrations[0].Version != 1 {
+		t.Fatalf("embedded migrations must start at 0001, got %+v", migrations)
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %04d_%s breaks the sequence; want version %d", m.Version, m.Name, i+1)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+-- Craftora schema as created by database.CreateTables before versioned
+-- migrations. Every statement is idempotent so databases created that way
+-- adopt this migration without changes.
+
+CREATE TABLE IF NOT EXISTS users (
+	id SERIAL PRIMARY KEY,
+	email VARCHAR(255) UNIQUE NOT NULL,
+	password_hash VARCHAR(255) NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	role VARCHAR(50) NOT NULL DEFAULT 'buyer',
+	email_verified BOOLEAN NOT NULL DEFAULT FALSE,
+	deleted_at TIMESTAMP,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Accounts created before email verification existed are treated as verified
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE users ALTER COLUMN email_verified SET DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS artisans (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+	business_name VARCHAR(255) NOT NULL,
+	craft_type VARCHAR(100) NOT NULL,
+	region VARCHAR(100) NOT NULL,
+	bio TEXT,
+	verification_docs TEXT,
+	is_verified BOOLEAN DEFAULT FALSE,
+	rating DECIMAL(3,2) DEFAULT 0,
+	total_orders INTEGER DEFAULT 0,
+	completion_rate DECIMAL(5,2) DEFAULT 0,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS categories (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(100) NOT NULL,
+	slug VARCHAR(100) UNIQUE NOT NULL,
+	description TEXT,
+	image_url TEXT
+);
+
+CREATE TABLE IF NOT EXISTS products (
+	id SERIAL PRIMARY KEY,
+	artisan_id INTEGER REFERENCES artisans(id) ON DELETE CASCADE,
+	category_id INTEGER REFERENCES categories(id),
+	name VARCHAR(255) NOT NULL,
+	description TEXT,
+	ai_story TEXT,
+	price DECIMAL(10,2) NOT NULL,
+	material_cost DECIMAL(10,2),
+	labor_cost DECIMAL(10,2),
+	platform_fee DECIMAL(10,2),
+	materials TEXT,
+	crafting_time INTEGER,
+	image_urls TEXT,
+	stock INTEGER DEFAULT 0,
+	is_approved BOOLEAN DEFAULT FALSE,
+	rating DECIMAL(3,2) DEFAULT 0,
+	review_count INTEGER DEFAULT 0,
+	confidence_score DECIMAL(5,2) DEFAULT 0,
+	sustainability_score INTEGER DEFAULT 0,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS orders (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+	product_id INTEGER REFERENCES products(id),
+	artisan_id INTEGER REFERENCES artisans(id),
+	quantity INTEGER NOT NULL,
+	total_amount DECIMAL(10,2) NOT NULL,
+	status VARCHAR(50) NOT NULL DEFAULT 'pending',
+	shipping_address TEXT NOT NULL,
+	estimated_eta TIMESTAMP,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS order_progress (
+	id SERIAL PRIMARY KEY,
+	order_id INTEGER REFERENCES orders(id) ON DELETE CASCADE,
+	stage VARCHAR(100) NOT NULL,
+	description TEXT,
+	image_url TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS reviews (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+	product_id INTEGER REFERENCES products(id) ON DELETE CASCADE,
+	order_id INTEGER REFERENCES orders(id),
+	rating INTEGER NOT NULL CHECK (rating >= 1 AND rating <= 5),
+	comment TEXT,
+	media_urls TEXT,
+	sentiment_score DECIMAL(5,2),
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS payments (
+	id SERIAL PRIMARY KEY,
+	order_id INTEGER REFERENCES orders(id) ON DELETE CASCADE,
+	amount DECIMAL(10,2) NOT NULL,
+	platform_fee DECIMAL(10,2) NOT NULL,
+	artisan_amount DECIMAL(10,2) NOT NULL,
+	payment_method VARCHAR(50) DEFAULT 'demo',
+	payment_status VARCHAR(50) DEFAULT 'pending',
+	transaction_id VARCHAR(255),
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS video_call_requests (
+	id SERIAL PRIMARY KEY,
+	buyer_id INTEGER REFERENCES users(id),
+	artisan_id INTEGER REFERENCES artisans(id),
+	product_id INTEGER REFERENCES products(id),
+	room_name VARCHAR(255) NOT NULL,
+	status VARCHAR(50) DEFAULT 'pending',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+	refresh_token_hash VARCHAR(64) UNIQUE NOT NULL,
+	previous_token_hash VARCHAR(64),
+	user_agent TEXT,
+	ip_address VARCHAR(64),
+	expires_at TIMESTAMP NOT NULL,
+	revoked_at TIMESTAMP,
+	last_used_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS password_resets (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+	token_hash VARCHAR(64) UNIQUE NOT NULL,
+	expires_at TIMESTAMP NOT NULL,
+	used_at TIMESTAMP,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS email_verifications (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+	token_hash VARCHAR(64) UNIQUE NOT NULL,
+	expires_at TIMESTAMP NOT NULL,
+	used_at TIMESTAMP,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS admin_invitations (
+	id SERIAL PRIMARY KEY,
+	email VARCHAR(255) NOT NULL,
+	invited_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+	expires_at TIMESTAMP NOT NULL,
+	accepted_at TIMESTAMP,
+	revoked_at TIMESTAMP,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS user_totp (
+	user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+	secret VARCHAR(64) NOT NULL,
+	enabled BOOLEAN NOT NULL DEFAULT FALSE,
+	last_used_step BIGINT NOT NULL DEFAULT 0,
+	enabled_at TIMESTAMP,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+	code_hash VARCHAR(64) NOT NULL,
+	used_at TIMESTAMP,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS login_challenges (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+	token_hash VARCHAR(64) UNIQUE NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	expires_at TIMESTAMP NOT NULL,
+	used_at TIMESTAMP,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS role_policies (
+	role VARCHAR(50) PRIMARY KEY,
+	require_two_factor BOOLEAN NOT NULL DEFAULT FALSE,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS login_attempts (
+	id SERIAL PRIMARY KEY,
+	email VARCHAR(255) NOT NULL,
+	ip_address VARCHAR(64),
+	success BOOLEAN NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS account_lockouts (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+	email VARCHAR(255),
+	ip_address VARCHAR(64),
+	scope VARCHAR(20) NOT NULL,
+	failed_attempts INTEGER NOT NULL,
+	locked_until TIMESTAMP NOT NULL,
+	unlocked_at TIMESTAMP,
+	unlocked_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	name VARCHAR(100) NOT NULL,
+	prefix VARCHAR(20) NOT NULL,
+	key_hash VARCHAR(64) UNIQUE NOT NULL,
+	scopes TEXT NOT NULL,
+	expires_at TIMESTAMP,
+	last_used_at TIMESTAMP,
+	revoked_at TIMESTAMP,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS addresses (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	name VARCHAR(255) NOT NULL,
+	line1 VARCHAR(255) NOT NULL,
+	line2 VARCHAR(255) NOT NULL DEFAULT '',
+	city VARCHAR(100) NOT NULL,
+	state VARCHAR(100) NOT NULL,
+	pin_code VARCHAR(20) NOT NULL,
+	country VARCHAR(100) NOT NULL,
+	phone VARCHAR(20) NOT NULL,
+	is_default BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Orders keep a copy of the address they shipped to
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS address_id INTEGER REFERENCES addresses(id) ON DELETE SET NULL;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS shipping_snapshot JSONB;
+
+CREATE TABLE IF NOT EXISTS permissions (
+	name VARCHAR(100) PRIMARY KEY,
+	description TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+	role VARCHAR(50) NOT NULL,
+	permission VARCHAR(100) NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+	PRIMARY KEY (role, permission)
+);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	idempotency_key VARCHAR(255) NOT NULL,
+	fingerprint VARCHAR(64) NOT NULL,
+	status_code INTEGER,
+	content_type VARCHAR(100),
+	response_body BYTEA,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	completed_at TIMESTAMP,
+	UNIQUE (user_id, idempotency_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payments_order ON payments(order_id);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_previous_token ON sessions(previous_token_hash);
+
+CREATE INDEX IF NOT EXISTS idx_password_resets_user ON password_resets(user_id);
+
+CREATE INDEX IF NOT EXISTS idx_email_verifications_user ON email_verifications(user_id);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user ON recovery_codes(user_id);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_email ON login_attempts(email, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_ip ON login_attempts(ip_address, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_account_lockouts_email ON account_lockouts(email);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user ON api_keys(user_id);
+
+CREATE INDEX IF NOT EXISTS idx_addresses_user ON addresses(user_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_addresses_default ON addresses(user_id) WHERE is_default;
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created ON idempotency_keys(created_at);
+CREATE INDEX IF NOT EXISTS idx_products_artisan ON products(artisan_id);
+
+CREATE INDEX IF NOT EXISTS idx_products_category ON products(category_id);
+
+CREATE INDEX IF NOT EXISTS idx_orders_user ON orders(user_id);
+
+CREATE INDEX IF NOT EXISTS idx_orders_artisan ON orders(artisan_id);
+
+CREATE INDEX IF NOT EXISTS idx_reviews_product ON reviews(product_id);
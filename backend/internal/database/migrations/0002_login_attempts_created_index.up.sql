@@ -0,0 +1,2 @@
+-- Lets the retention purge find old login attempts without a full scan.
+CREATE INDEX IF NOT EXISTS idx_login_attempts_created ON login_attempts(created_at);
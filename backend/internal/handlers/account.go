@@ -0,0 +1,497 @@
+// backend/internal/handlers/account.go
+package handlers
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/middleware"
+	"backend/internal/models"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// accountExport is everything we hold about a user, as handed out by ExportData.
+type accountExport struct {
+	ExportedAt time.Time            `json:"exported_at"`
+	Profile    models.User          `json:"profile"`
+	Artisan    *models.Artisan      `json:"artisan,omitempty"`
+	Addresses  []models.Address     `json:"addresses"`
+	Orders     []models.Order       `json:"orders"`
+	Reviews    []models.Review      `json:"reviews"`
+	Payments   []models.Payment     `json:"payments"`
+	VideoCalls []VideoCallRequest   `json:"video_calls"`
+	Sessions   []accountSessionInfo `json:"sessions"`
+}
+
+type accountSessionInfo struct {
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (h *AuthHandler) GetMe(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
+
+	user, err := h.loadUser(r.Context(), claims.UserID)
+	if err != nil {
+		middleware.RespondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, user)
+}
+
+// UpdateMe changes the caller's name and email. A new email has to be
+// verified again before checkout and other verified-only actions work, so
+// changing it signs out every session, whose access tokens still claim a
+// verified email, and returns a fresh token pair for this client.
+func (h *AuthHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
+
+	var req models.UpdateProfileRequest
+	if !middleware.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	user, err := h.loadUser(r.Context(), claims.UserID)
+	if err != nil {
+		middleware.RespondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			middleware.RespondError(w, http.StatusBadRequest, "Name cannot be empty")
+			return
+		}
+		user.Name = name
+	}
+
+	emailChanged := false
+	if req.Email != nil && !strings.EqualFold(*req.Email, user.Email) {
+		address, err := mail.ParseAddress(*req.Email)
+		if err != nil {
+			middleware.RespondError(w, http.StatusBadRequest, "A valid email is required")
+			return
+		}
+		if !h.reauthenticate(w, r, user, req.CurrentPassword) {
+			return
+		}
+		user.Email = address.Address
+		user.EmailVerified = false
+		emailChanged = true
+	}
+
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(r.Context(), `
+		UPDATE users SET name = $1, email = $2, email_verified = $3
+		WHERE id = $4
+	`, user.Name, user.Email, user.EmailVerified, user.ID)
+	if err != nil {
+		middleware.RespondDBError(w, r, err, "Failed to update profile")
+		return
+	}
+
+	if emailChanged {
+		_, err = tx.ExecContext(r.Context(), `
+			UPDATE sessions SET revoked_at = NOW()
+			WHERE user_id = $1 AND revoked_at IS NULL
+		`, user.ID)
+		if err != nil {
+			middleware.RespondError(w, http.StatusInternalServerError, "Failed to update profile")
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to update profile")
+		return
+	}
+
+	resp := models.UpdateProfileResponse{User: *user}
+	if emailChanged {
+		if err := h.sendVerificationEmail(r.Context(), user); err != nil {
+			middleware.Logger(r.Context()).Error("Failed to send verification email", "user_id", user.ID, "error", err)
+		}
+
+		auth, err := h.openSession(r, user)
+		if err != nil {
+			middleware.RespondError(w, http.StatusInternalServerError, "Failed to create session")
+			return
+		}
+		resp.Token, resp.RefreshToken, resp.ExpiresIn = auth.Token, auth.RefreshToken, auth.ExpiresIn
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, resp)
+}
+
+// ChangePassword requires the current password and signs out every other session.
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
+
+	var req models.ChangePasswordRequest
+	if !middleware.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	user, err := h.loadUser(r.Context(), claims.UserID)
+	if err != nil {
+		middleware.RespondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if !h.reauthenticate(w, r, user, req.CurrentPassword) {
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to hash password")
+		return
+	}
+
+	if _, err := h.db.ExecContext(r.Context(), "UPDATE users SET password_hash = $1 WHERE id = $2", string(hashedPassword), user.ID); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to update password")
+		return
+	}
+
+	if _, err := h.sessions.RevokeOthers(r.Context(), user.ID, claims.SessionID); err != nil {
+		middleware.Logger(r.Context()).Error("Failed to revoke other sessions", "user_id", user.ID, "error", err)
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, map[string]string{"message": "Password changed"})
+}
+
+// ExportData hands the user a copy of their data as JSON, or as a zip with
+// one file per section when called with ?format=zip.
+func (h *AuthHandler) ExportData(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
+
+	export, err := h.collectExport(r.Context(), claims.UserID)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to export account data")
+		return
+	}
+
+	filename := fmt.Sprintf("craftora-export-%d-%s", claims.UserID, export.ExportedAt.Format("20060102"))
+
+	if r.URL.Query().Get("format") != "zip" {
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.json"`)
+		middleware.RespondJSON(w, http.StatusOK, export)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.zip"`)
+	w.WriteHeader(http.StatusOK)
+
+	sections := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"artisan.json", export.Artisan},
+		{"addresses.json", export.Addresses},
+		{"orders.json", export.Orders},
+		{"reviews.json", export.Reviews},
+		{"payments.json", export.Payments},
+		{"video_calls.json", export.VideoCalls},
+		{"sessions.json", export.Sessions},
+	}
+
+	zw := zip.NewWriter(w)
+	for _, section := range sections {
+		f, err := zw.Create(section.name)
+		if err != nil {
+			middleware.Logger(r.Context()).Error("Failed to write export", "user_id", claims.UserID, "error", err)
+			return
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(section.data); err != nil {
+			middleware.Logger(r.Context()).Error("Failed to write export", "user_id", claims.UserID, "error", err)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		middleware.Logger(r.Context()).Error("Failed to write export", "user_id", claims.UserID, "error", err)
+	}
+}
+
+// DeleteAccount erases the user's personal data. Orders and reviews stay for
+// the artisans' records and the product ratings, but point at an anonymized
+// account instead of being deleted.
+func (h *AuthHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
+
+	var req models.DeleteAccountRequest
+	if !middleware.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	user, err := h.loadUser(r.Context(), claims.UserID)
+	if err != nil {
+		middleware.RespondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if user.Role != models.RoleBuyer && user.Role != models.RoleArtisan {
+		middleware.RespondError(w, http.StatusConflict, "Staff accounts must be moved back to buyer by an administrator before deletion")
+		return
+	}
+	if !h.reauthenticate(w, r, user, req.Password) {
+		return
+	}
+
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		`UPDATE users SET email = 'deleted-' || id || '@deleted.invalid', name = 'Deleted user',
+			password_hash = '!', role = 'buyer', email_verified = false, deleted_at = NOW()
+		WHERE id = $1`,
+		`UPDATE orders SET shipping_address = '[deleted]', address_id = NULL, shipping_snapshot = NULL WHERE user_id = $1`,
+		`DELETE FROM addresses WHERE user_id = $1`,
+		`UPDATE reviews SET media_urls = NULL WHERE user_id = $1`,
+		`UPDATE products SET is_approved = false, stock = 0
+		WHERE artisan_id IN (SELECT id FROM artisans WHERE user_id = $1)`,
+		`UPDATE artisans SET verification_docs = NULL WHERE user_id = $1`,
+		`UPDATE account_lockouts SET email = NULL, ip_address = NULL WHERE user_id = $1`,
+		`UPDATE sessions SET revoked_at = NOW(), user_agent = NULL, ip_address = NULL WHERE user_id = $1`,
+		`DELETE FROM user_totp WHERE user_id = $1`,
+		`DELETE FROM recovery_codes WHERE user_id = $1`,
+		`DELETE FROM login_challenges WHERE user_id = $1`,
+		`DELETE FROM password_resets WHERE user_id = $1`,
+		`DELETE FROM email_verifications WHERE user_id = $1`,
+		`DELETE FROM api_keys WHERE user_id = $1`,
+		`DELETE FROM idempotency_keys WHERE user_id = $1`,
+	}
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(r.Context(), stmt, user.ID); err != nil {
+			middleware.RespondError(w, http.StatusInternalServerError, "Failed to delete account")
+			return
+		}
+	}
+
+	if _, err := tx.ExecContext(r.Context(), "DELETE FROM login_attempts WHERE email = $1", strings.ToLower(user.Email)); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to delete account")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to delete account")
+		return
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, map[string]string{"message": "Account deleted"})
+}
+
+// reauthenticate checks the password of a signed-in user before a sensitive
+// change. Failures count towards the same lockout as failed logins.
+func (h *AuthHandler) reauthenticate(w http.ResponseWriter, r *http.Request, user *models.User, password string) bool {
+	ip := middleware.ClientIP(r)
+	decision, err := h.guard.Check(r.Context(), user.Email, ip)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Database error")
+		return false
+	}
+	if !decision.Allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(decision.RetryAfter.Seconds()))))
+		middleware.RespondError(w, http.StatusTooManyRequests, "Too many failed attempts. Please wait before retrying")
+		return false
+	}
+
+	if password == "" || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		if err := h.guard.RecordFailure(r.Context(), user.Email, ip); err != nil {
+			middleware.Logger(r.Context()).Error("Failed to record failed re-authentication", "user_id", user.ID, "error", err)
+		}
+		// 403 rather than 401 so clients don't treat it as an expired session
+		middleware.RespondError(w, http.StatusForbidden, "Current password is incorrect")
+		return false
+	}
+
+	return true
+}
+
+func (h *AuthHandler) loadUser(ctx context.Context, userID int) (*models.User, error) {
+	var user models.User
+	err := h.db.QueryRowContext(ctx, `
+		SELECT id, email, password_hash, name, role, email_verified, created_at
+		FROM users WHERE id = $1 AND deleted_at IS NULL
+	`, userID).Scan(&user.ID, &user.Email, &user.PasswordHash, &user.Name, &user.Role, &user.EmailVerified, &user.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (h *AuthHandler) collectExport(ctx context.Context, userID int) (*accountExport, error) {
+	user, err := h.loadUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &accountExport{
+		ExportedAt: time.Now().UTC(),
+		Profile:    *user,
+		Addresses:  []models.Address{},
+		Orders:     []models.Order{},
+		Reviews:    []models.Review{},
+		Payments:   []models.Payment{},
+		VideoCalls: []VideoCallRequest{},
+		Sessions:   []accountSessionInfo{},
+	}
+
+	var a models.Artisan
+	var bio, docs sql.NullString
+	err = h.db.QueryRowContext(ctx, `
+		SELECT id, user_id, business_name, craft_type, region, bio, verification_docs, is_verified, created_at
+		FROM artisans WHERE user_id = $1
+	`, userID).Scan(&a.ID, &a.UserID, &a.BusinessName, &a.CraftType, &a.Region, &bio, &docs, &a.IsVerified, &a.CreatedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if err == nil {
+		a.Bio, a.VerificationDocs = bio.String, docs.String
+		export.Artisan = &a
+	}
+
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT id, user_id, name, line1, line2, city, state, pin_code, country, phone, is_default, created_at, updated_at
+		FROM addresses WHERE user_id = $1 ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var ad models.Address
+		if err := rows.Scan(&ad.ID, &ad.UserID, &ad.Name, &ad.Line1, &ad.Line2, &ad.City, &ad.State,
+			&ad.PINCode, &ad.Country, &ad.Phone, &ad.IsDefault, &ad.CreatedAt, &ad.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		export.Addresses = append(export.Addresses, ad)
+	}
+	rows.Close()
+
+	rows, err = h.db.QueryContext(ctx, `
+		SELECT id, user_id, product_id, artisan_id, quantity, total_amount, status,
+			shipping_address, address_id, shipping_snapshot, estimated_eta, created_at, updated_at
+		FROM orders WHERE user_id = $1 ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var o models.Order
+		var eta sql.NullTime
+		var snapshot []byte
+		if err := rows.Scan(&o.ID, &o.UserID, &o.ProductID, &o.ArtisanID, &o.Quantity, &o.TotalAmount, &o.Status,
+			&o.ShippingAddress, &o.AddressID, &snapshot, &eta, &o.CreatedAt, &o.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		o.EstimatedETA = eta.Time
+		o.ShippingDetails = decodeShippingSnapshot(snapshot)
+		export.Orders = append(export.Orders, o)
+	}
+	rows.Close()
+
+	rows, err = h.db.QueryContext(ctx, `
+		SELECT id, user_id, product_id, COALESCE(order_id, 0), rating, COALESCE(comment, ''),
+			COALESCE(media_urls, ''), COALESCE(sentiment_score, 0), created_at
+		FROM reviews WHERE user_id = $1 ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var rv models.Review
+		if err := rows.Scan(&rv.ID, &rv.UserID, &rv.ProductID, &rv.OrderID, &rv.Rating, &rv.Comment,
+			&rv.MediaURLs, &rv.SentimentScore, &rv.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		export.Reviews = append(export.Reviews, rv)
+	}
+	rows.Close()
+
+	rows, err = h.db.QueryContext(ctx, `
+		SELECT p.id, p.order_id, p.amount, p.platform_fee, p.artisan_amount,
+			COALESCE(p.payment_method, ''), COALESCE(p.payment_status, ''), COALESCE(p.transaction_id, ''), p.created_at
+		FROM payments p
+		JOIN orders o ON p.order_id = o.id
+		WHERE o.user_id = $1 ORDER BY p.created_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var p models.Payment
+		if err := rows.Scan(&p.ID, &p.OrderID, &p.Amount, &p.PlatformFee, &p.ArtisanAmount,
+			&p.PaymentMethod, &p.PaymentStatus, &p.TransactionID, &p.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		export.Payments = append(export.Payments, p)
+	}
+	rows.Close()
+
+	rows, err = h.db.QueryContext(ctx, `
+		SELECT v.id, v.buyer_id, v.artisan_id, v.product_id, v.room_name, v.status, COALESCE(p.name, ''), v.created_at
+		FROM video_call_requests v
+		LEFT JOIN products p ON v.product_id = p.id
+		WHERE v.buyer_id = $1 ORDER BY v.created_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var v VideoCallRequest
+		if err := rows.Scan(&v.ID, &v.BuyerID, &v.ArtisanID, &v.ProductID, &v.RoomName, &v.Status, &v.ProductName, &v.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		v.BuyerName = user.Name
+		export.VideoCalls = append(export.VideoCalls, v)
+	}
+	rows.Close()
+
+	rows, err = h.db.QueryContext(ctx, `
+		SELECT COALESCE(user_agent, ''), COALESCE(ip_address, ''), last_used_at, created_at
+		FROM sessions WHERE user_id = $1 ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var s accountSessionInfo
+		if err := rows.Scan(&s.UserAgent, &s.IPAddress, &s.LastUsedAt, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		export.Sessions = append(export.Sessions, s)
+	}
+
+	return export, rows.Err()
+}
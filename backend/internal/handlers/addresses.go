@@ -0,0 +1,368 @@
+// backend/internal/handlers/addresses.go
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"backend/internal/middleware"
+	"backend/internal/models"
+)
+
+// maxAddressesPerUser keeps address books to a size the checkout UI can list.
+const maxAddressesPerUser = 20
+
+var (
+	indianPINPattern  = regexp.MustCompile(`^[1-9][0-9]{5}$`)
+	postalCodePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 -]{1,9}$`)
+	phonePattern      = regexp.MustCompile(`^\+?[0-9]{10,15}$`)
+
+	errAddressNotFound = errors.New("address not found")
+	errNoAddress       = errors.New("no shipping address")
+)
+
+type AddressHandler struct {
+	db *sql.DB
+}
+
+func NewAddressHandler(db *sql.DB) *AddressHandler {
+	return &AddressHandler{db: db}
+}
+
+func (h *AddressHandler) ListAddresses(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
+
+	rows, err := h.db.QueryContext(r.Context(), `
+		SELECT id, user_id, name, line1, line2, city, state, pin_code, country, phone, is_default, created_at, updated_at
+		FROM addresses WHERE user_id = $1
+		ORDER BY is_default DESC, created_at DESC
+	`, claims.UserID)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch addresses")
+		return
+	}
+	defer rows.Close()
+
+	addresses := []models.Address{}
+	for rows.Next() {
+		var a models.Address
+		if err := rows.Scan(&a.ID, &a.UserID, &a.Name, &a.Line1, &a.Line2, &a.City, &a.State,
+			&a.PINCode, &a.Country, &a.Phone, &a.IsDefault, &a.CreatedAt, &a.UpdatedAt); err != nil {
+			middleware.Logger(r.Context()).Error("Failed to scan address", "error", err)
+			continue
+		}
+		addresses = append(addresses, a)
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, addresses)
+}
+
+// CreateAddress adds an address. The first address a user saves becomes
+// their default.
+func (h *AddressHandler) CreateAddress(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
+
+	var req models.AddressRequest
+	if !middleware.DecodeJSON(w, r, &req) {
+		return
+	}
+	if msg := normalizeAddress(&req); msg != "" {
+		middleware.RespondError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.QueryRowContext(r.Context(), "SELECT COUNT(*) FROM addresses WHERE user_id = $1", claims.UserID).Scan(&count); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if count >= maxAddressesPerUser {
+		middleware.RespondError(w, http.StatusConflict, "Address book is full. Delete an address first")
+		return
+	}
+	if count == 0 {
+		req.IsDefault = true
+	}
+
+	if req.IsDefault {
+		if _, err := tx.ExecContext(r.Context(), "UPDATE addresses SET is_default = false WHERE user_id = $1", claims.UserID); err != nil {
+			middleware.RespondError(w, http.StatusInternalServerError, "Failed to save address")
+			return
+		}
+	}
+
+	a := addressFromRequest(claims.UserID, req)
+	err = tx.QueryRowContext(r.Context(), `
+		INSERT INTO addresses (user_id, name, line1, line2, city, state, pin_code, country, phone, is_default)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at, updated_at
+	`, a.UserID, a.Name, a.Line1, a.Line2, a.City, a.State, a.PINCode, a.Country, a.Phone, a.IsDefault).Scan(
+		&a.ID, &a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to save address")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to save address")
+		return
+	}
+
+	middleware.RespondJSON(w, http.StatusCreated, a)
+}
+
+// UpdateAddress replaces an address. Orders already placed keep their copy.
+func (h *AddressHandler) UpdateAddress(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
+
+	addressID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		middleware.RespondError(w, http.StatusBadRequest, "Invalid address ID")
+		return
+	}
+
+	var req models.AddressRequest
+	if !middleware.DecodeJSON(w, r, &req) {
+		return
+	}
+	if msg := normalizeAddress(&req); msg != "" {
+		middleware.RespondError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+
+	var wasDefault bool
+	err = tx.QueryRowContext(r.Context(), `
+		SELECT is_default FROM addresses WHERE id = $1 AND user_id = $2 FOR UPDATE
+	`, addressID, claims.UserID).Scan(&wasDefault)
+	if err == sql.ErrNoRows {
+		middleware.RespondError(w, http.StatusNotFound, "Address not found")
+		return
+	}
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	// The default can only move to another address, not be switched off
+	req.IsDefault = req.IsDefault || wasDefault
+	if req.IsDefault && !wasDefault {
+		if _, err := tx.ExecContext(r.Context(), "UPDATE addresses SET is_default = false WHERE user_id = $1", claims.UserID); err != nil {
+			middleware.RespondError(w, http.StatusInternalServerError, "Failed to save address")
+			return
+		}
+	}
+
+	a := addressFromRequest(claims.UserID, req)
+	a.ID = addressID
+	err = tx.QueryRowContext(r.Context(), `
+		UPDATE addresses SET name = $1, line1 = $2, line2 = $3, city = $4, state = $5,
+			pin_code = $6, country = $7, phone = $8, is_default = $9, updated_at = NOW()
+		WHERE id = $10
+		RETURNING created_at, updated_at
+	`, a.Name, a.Line1, a.Line2, a.City, a.State, a.PINCode, a.Country, a.Phone, a.IsDefault, a.ID).Scan(
+		&a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to save address")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to save address")
+		return
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, a)
+}
+
+// DeleteAddress removes an address. If it was the default, the most recently
+// added remaining address takes over.
+func (h *AddressHandler) DeleteAddress(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
+
+	addressID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		middleware.RespondError(w, http.StatusBadRequest, "Invalid address ID")
+		return
+	}
+
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+
+	var wasDefault bool
+	err = tx.QueryRowContext(r.Context(), `
+		DELETE FROM addresses WHERE id = $1 AND user_id = $2
+		RETURNING is_default
+	`, addressID, claims.UserID).Scan(&wasDefault)
+	if err == sql.ErrNoRows {
+		middleware.RespondError(w, http.StatusNotFound, "Address not found")
+		return
+	}
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to delete address")
+		return
+	}
+
+	if wasDefault {
+		_, err = tx.ExecContext(r.Context(), `
+			UPDATE addresses SET is_default = true
+			WHERE id = (SELECT id FROM addresses WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1)
+		`, claims.UserID)
+		if err != nil {
+			middleware.RespondError(w, http.StatusInternalServerError, "Failed to delete address")
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to delete address")
+		return
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, map[string]string{"message": "Address deleted"})
+}
+
+// queryRower is satisfied by both *sql.DB and *sql.Tx.
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// resolveShippingAddress picks the address for a new order: the saved address
+// named by addressID, otherwise the free-text address older clients send,
+// otherwise the user's default address. The returned snapshot is nil for
+// free-text addresses.
+func resolveShippingAddress(ctx context.Context, q queryRower, userID int, addressID *int, freeText string) (*models.Address, string, error) {
+	query := `
+		SELECT id, user_id, name, line1, line2, city, state, pin_code, country, phone, is_default, created_at, updated_at
+		FROM addresses WHERE user_id = $1`
+	args := []interface{}{userID}
+
+	switch {
+	case addressID != nil:
+		query += " AND id = $2"
+		args = append(args, *addressID)
+	case strings.TrimSpace(freeText) != "":
+		return nil, strings.TrimSpace(freeText), nil
+	default:
+		query += " AND is_default"
+	}
+
+	var a models.Address
+	err := q.QueryRowContext(ctx, query, args...).Scan(&a.ID, &a.UserID, &a.Name, &a.Line1, &a.Line2, &a.City, &a.State,
+		&a.PINCode, &a.Country, &a.Phone, &a.IsDefault, &a.CreatedAt, &a.UpdatedAt)
+	if err == sql.ErrNoRows {
+		if addressID != nil {
+			return nil, "", errAddressNotFound
+		}
+		return nil, "", errNoAddress
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &a, a.Format(), nil
+}
+
+// respondShippingAddressError reports a resolveShippingAddress failure and
+// returns false, or returns true when err is nil.
+func respondShippingAddressError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, errAddressNotFound):
+		middleware.RespondError(w, http.StatusBadRequest, "Address not found")
+	case errors.Is(err, errNoAddress):
+		middleware.RespondError(w, http.StatusBadRequest, "A shipping address is required")
+	default:
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to load shipping address")
+	}
+	return false
+}
+
+// shippingSnapshot encodes the address copy stored on an order. Free-text
+// addresses have no snapshot.
+func shippingSnapshot(a *models.Address) ([]byte, error) {
+	if a == nil {
+		return nil, nil
+	}
+	return json.Marshal(a)
+}
+
+// decodeShippingSnapshot reads the JSONB address copy stored on an order.
+func decodeShippingSnapshot(raw []byte) *models.Address {
+	if len(raw) == 0 {
+		return nil
+	}
+	var a models.Address
+	if err := json.Unmarshal(raw, &a); err != nil {
+		return nil
+	}
+	return &a
+}
+
+// normalizeAddress trims the request and returns a validation message, or ""
+// when the address is acceptable.
+func normalizeAddress(req *models.AddressRequest) string {
+	for _, field := range []*string{&req.Name, &req.Line1, &req.Line2, &req.City, &req.State, &req.PINCode, &req.Country} {
+		*field = strings.TrimSpace(*field)
+	}
+	req.Phone = strings.NewReplacer(" ", "", "-", "").Replace(req.Phone)
+
+	if req.Name == "" || req.Line1 == "" || req.City == "" || req.State == "" ||
+		req.PINCode == "" || req.Country == "" || req.Phone == "" {
+		return "Name, line1, city, state, pin_code, country and phone are required"
+	}
+
+	if strings.EqualFold(req.Country, "India") || strings.EqualFold(req.Country, "IN") {
+		req.Country = "India"
+		if !indianPINPattern.MatchString(req.PINCode) {
+			return "PIN code must be 6 digits"
+		}
+	} else if !postalCodePattern.MatchString(req.PINCode) {
+		return "Invalid postal code"
+	}
+
+	if !phonePattern.MatchString(req.Phone) {
+		return "Phone must be 10 to 15 digits, optionally starting with +"
+	}
+
+	return ""
+}
+
+func addressFromRequest(userID int, req models.AddressRequest) models.Address {
+	return models.Address{
+		UserID:    userID,
+		Name:      req.Name,
+		Line1:     req.Line1,
+		Line2:     req.Line2,
+		City:      req.City,
+		State:     req.State,
+		PINCode:   req.PINCode,
+		Country:   req.Country,
+		Phone:     req.Phone,
+		IsDefault: req.IsDefault,
+	}
+}
@@ -3,44 +3,88 @@ package handlers
 
 import (
 	"database/sql"
-	"encoding/json"
 	"net/http"
 	"strconv"
 
+	"backend/internal/apikey"
+	"backend/internal/config"
+	"backend/internal/loginguard"
+	"backend/internal/mailer"
+	"backend/internal/metrics"
 	"backend/internal/middleware"
 	"backend/internal/models"
+	"backend/internal/rbac"
+	"backend/internal/session"
+	"backend/internal/token"
 )
 
 type AdminHandler struct {
-	db *sql.DB
+	db          *sql.DB
+	cfg         *config.Config
+	sessions    *session.Store
+	tokens      *token.Service
+	mailer      mailer.Mailer
+	guard       *loginguard.Guard
+	permissions *rbac.Store
+	apiKeys     *apikey.Store
 }
 
-func NewAdminHandler(db *sql.DB) *AdminHandler {
-	return &AdminHandler{db: db}
+func NewAdminHandler(db *sql.DB, cfg *config.Config, sessions *session.Store, tokens *token.Service, mail mailer.Mailer, guard *loginguard.Guard, permissions *rbac.Store, apiKeys *apikey.Store) *AdminHandler {
+	return &AdminHandler{db: db, cfg: cfg, sessions: sessions, tokens: tokens, mailer: mail, guard: guard, permissions: permissions, apiKeys: apiKeys}
 }
 
 func (h *AdminHandler) GetPendingArtisans(w http.ResponseWriter, r *http.Request) {
-	rows, err := h.db.Query(`
-		SELECT id, user_id, business_name, craft_type, region, bio, verification_docs, created_at
-		FROM artisans WHERE is_verified = false
-		ORDER BY created_at DESC
-	`)
+	rows, err := h.db.QueryContext(r.Context(), pendingArtisansQuery)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch artisans")
+		return
+	}
+	defer rows.Close()
+
+	middleware.RespondJSON(w, http.StatusOK, scanPendingArtisans(r, rows))
+}
+
+// GetPendingArtisansPage is GetPendingArtisans for /api/v2, one page at a time.
+func (h *AdminHandler) GetPendingArtisansPage(w http.ResponseWriter, r *http.Request) {
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+
+	var total int
+	if err := h.db.QueryRowContext(r.Context(), "SELECT COUNT(*) FROM artisans WHERE is_verified = false").Scan(&total); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch artisans")
+		return
+	}
+
+	rows, err := h.db.QueryContext(r.Context(), pendingArtisansQuery+page.limitOffset(0), page.params()...)
 	if err != nil {
 		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch artisans")
 		return
 	}
 	defer rows.Close()
 
+	middleware.RespondJSON(w, http.StatusOK, newPage(scanPendingArtisans(r, rows), page, total))
+}
+
+const pendingArtisansQuery = `
+		SELECT id, user_id, business_name, craft_type, region, bio, verification_docs, created_at
+		FROM artisans WHERE is_verified = false
+		ORDER BY created_at DESC, id DESC
+	`
+
+func scanPendingArtisans(r *http.Request, rows *sql.Rows) []models.Artisan {
 	artisans := []models.Artisan{}
 	for rows.Next() {
 		var a models.Artisan
 		if err := rows.Scan(&a.ID, &a.UserID, &a.BusinessName, &a.CraftType, &a.Region,
-			&a.Bio, &a.VerificationDocs, &a.CreatedAt); err == nil {
-			artisans = append(artisans, a)
+			&a.Bio, &a.VerificationDocs, &a.CreatedAt); err != nil {
+			middleware.Logger(r.Context()).Error("Failed to scan artisan", "error", err)
+			continue
 		}
+		artisans = append(artisans, a)
 	}
-
-	middleware.RespondJSON(w, http.StatusOK, artisans)
+	return artisans
 }
 
 func (h *AdminHandler) VerifyArtisan(w http.ResponseWriter, r *http.Request) {
@@ -51,7 +95,7 @@ func (h *AdminHandler) VerifyArtisan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = h.db.Exec("UPDATE artisans SET is_verified = true WHERE id = $1", artisanID)
+	_, err = h.db.ExecContext(r.Context(), "UPDATE artisans SET is_verified = true WHERE id = $1", artisanID)
 	if err != nil {
 		middleware.RespondError(w, http.StatusInternalServerError, "Failed to verify artisan")
 		return
@@ -61,36 +105,66 @@ func (h *AdminHandler) VerifyArtisan(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *AdminHandler) GetPendingProducts(w http.ResponseWriter, r *http.Request) {
-	rows, err := h.db.Query(`
-		SELECT p.id, p.name, p.price, p.created_at, a.business_name
-		FROM products p
-		JOIN artisans a ON p.artisan_id = a.id
-		WHERE p.is_approved = false
-		ORDER BY p.created_at DESC
-	`)
+	rows, err := h.db.QueryContext(r.Context(), pendingProductsQuery)
 	if err != nil {
 		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch products")
 		return
 	}
 	defer rows.Close()
 
-	type PendingProduct struct {
-		ID          int     `json:"id"`
-		Name        string  `json:"name"`
-		Price       float64 `json:"price"`
-		CreatedAt   string  `json:"created_at"`
-		ArtisanName string  `json:"artisan_name"`
+	middleware.RespondJSON(w, http.StatusOK, scanPendingProducts(r, rows))
+}
+
+// GetPendingProductsPage is GetPendingProducts for /api/v2, one page at a time.
+func (h *AdminHandler) GetPendingProductsPage(w http.ResponseWriter, r *http.Request) {
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+
+	var total int
+	if err := h.db.QueryRowContext(r.Context(), "SELECT COUNT(*) FROM products WHERE is_approved = false").Scan(&total); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch products")
+		return
+	}
+
+	rows, err := h.db.QueryContext(r.Context(), pendingProductsQuery+page.limitOffset(0), page.params()...)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch products")
+		return
 	}
+	defer rows.Close()
+
+	middleware.RespondJSON(w, http.StatusOK, newPage(scanPendingProducts(r, rows), page, total))
+}
 
-	products := []PendingProduct{}
+const pendingProductsQuery = `
+		SELECT p.id, p.name, p.price, p.created_at, a.business_name
+		FROM products p
+		JOIN artisans a ON p.artisan_id = a.id
+		WHERE p.is_approved = false
+		ORDER BY p.created_at DESC, p.id DESC
+	`
+
+type pendingProduct struct {
+	ID          int     `json:"id"`
+	Name        string  `json:"name"`
+	Price       float64 `json:"price"`
+	CreatedAt   string  `json:"created_at"`
+	ArtisanName string  `json:"artisan_name"`
+}
+
+func scanPendingProducts(r *http.Request, rows *sql.Rows) []pendingProduct {
+	products := []pendingProduct{}
 	for rows.Next() {
-		var p PendingProduct
-		if err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.CreatedAt, &p.ArtisanName); err == nil {
-			products = append(products, p)
+		var p pendingProduct
+		if err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.CreatedAt, &p.ArtisanName); err != nil {
+			middleware.Logger(r.Context()).Error("Failed to scan product", "error", err)
+			continue
 		}
+		products = append(products, p)
 	}
-
-	middleware.RespondJSON(w, http.StatusOK, products)
+	return products
 }
 
 func (h *AdminHandler) ApproveProduct(w http.ResponseWriter, r *http.Request) {
@@ -101,30 +175,38 @@ func (h *AdminHandler) ApproveProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = h.db.Exec("UPDATE products SET is_approved = true WHERE id = $1", productID)
+	result, err := h.db.ExecContext(r.Context(), "UPDATE products SET is_approved = true WHERE id = $1 AND is_approved = false", productID)
 	if err != nil {
 		middleware.RespondError(w, http.StatusInternalServerError, "Failed to approve product")
 		return
 	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		metrics.ProductsApproved.Inc()
+	}
 
 	middleware.RespondJSON(w, http.StatusOK, map[string]string{"message": "Product approved"})
 }
 
 func (h *AdminHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
-	var category models.Category
-	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
-		middleware.RespondError(w, http.StatusBadRequest, "Invalid request body")
+	var req models.CategoryRequest
+	if !middleware.DecodeJSON(w, r, &req) {
 		return
 	}
+	category := models.Category{
+		Name:        req.Name,
+		Slug:        req.Slug,
+		Description: req.Description,
+		ImageURL:    req.ImageURL,
+	}
 
-	err := h.db.QueryRow(`
+	err := h.db.QueryRowContext(r.Context(), `
 		INSERT INTO categories (name, slug, description, image_url)
 		VALUES ($1, $2, $3, $4)
 		RETURNING id
 	`, category.Name, category.Slug, category.Description, category.ImageURL).Scan(&category.ID)
 
 	if err != nil {
-		middleware.RespondError(w, http.StatusInternalServerError, "Failed to create category")
+		middleware.RespondDBError(w, r, err, "Failed to create category")
 		return
 	}
 
@@ -134,12 +216,161 @@ func (h *AdminHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
 func (h *AdminHandler) GetAnalytics(w http.ResponseWriter, r *http.Request) {
 	var analytics models.Analytics
 
-	h.db.QueryRow("SELECT COUNT(*) FROM artisans").Scan(&analytics.TotalArtisans)
-	h.db.QueryRow("SELECT COUNT(*) FROM products WHERE is_approved = true").Scan(&analytics.TotalProducts)
-	h.db.QueryRow("SELECT COUNT(*) FROM orders").Scan(&analytics.TotalOrders)
-	h.db.QueryRow("SELECT COALESCE(SUM(total_amount), 0) FROM orders WHERE status = 'delivered'").Scan(&analytics.TotalRevenue)
-	h.db.QueryRow("SELECT COUNT(*) FROM artisans WHERE is_verified = false").Scan(&analytics.PendingArtisans)
-	h.db.QueryRow("SELECT COUNT(*) FROM products WHERE is_approved = false").Scan(&analytics.PendingProducts)
+	queries := []struct {
+		query string
+		dest  interface{}
+	}{
+		{"SELECT COUNT(*) FROM artisans", &analytics.TotalArtisans},
+		{"SELECT COUNT(*) FROM products WHERE is_approved = true", &analytics.TotalProducts},
+		{"SELECT COUNT(*) FROM orders", &analytics.TotalOrders},
+		{"SELECT COALESCE(SUM(total_amount), 0) FROM orders WHERE status = 'delivered'", &analytics.TotalRevenue},
+		{"SELECT COUNT(*) FROM artisans WHERE is_verified = false", &analytics.PendingArtisans},
+		{"SELECT COUNT(*) FROM products WHERE is_approved = false", &analytics.PendingProducts},
+	}
+	for _, q := range queries {
+		if err := h.db.QueryRowContext(r.Context(), q.query).Scan(q.dest); err != nil {
+			middleware.Logger(r.Context()).Error("Analytics query failed", "query", q.query, "error", err)
+		}
+	}
 
 	middleware.RespondJSON(w, http.StatusOK, analytics)
 }
+
+// ListOrders gives support staff a view of the latest 200 orders, newest
+// first. It can be narrowed with ?status= and ?user_id=.
+func (h *AdminHandler) ListOrders(w http.ResponseWriter, r *http.Request) {
+	where, params, ok := adminOrdersFilter(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := h.db.QueryContext(r.Context(), adminOrdersQuery+where+adminOrdersOrder+" LIMIT 200", params...)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch orders")
+		return
+	}
+	defer rows.Close()
+
+	middleware.RespondJSON(w, http.StatusOK, scanAdminOrders(r, rows))
+}
+
+// ListOrdersPage is ListOrders for /api/v2: every matching order, one page
+// at a time, rather than only the latest 200.
+func (h *AdminHandler) ListOrdersPage(w http.ResponseWriter, r *http.Request) {
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+	where, params, ok := adminOrdersFilter(w, r)
+	if !ok {
+		return
+	}
+
+	var total int
+	if err := h.db.QueryRowContext(r.Context(), "SELECT COUNT(*) FROM orders o WHERE 1=1"+where, params...).Scan(&total); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch orders")
+		return
+	}
+
+	query := adminOrdersQuery + where + adminOrdersOrder + page.limitOffset(len(params))
+	rows, err := h.db.QueryContext(r.Context(), query, append(params, page.params()...)...)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch orders")
+		return
+	}
+	defer rows.Close()
+
+	middleware.RespondJSON(w, http.StatusOK, newPage(scanAdminOrders(r, rows), page, total))
+}
+
+const (
+	adminOrdersQuery = `
+		SELECT o.id, o.user_id, o.product_id, o.artisan_id, o.quantity, o.total_amount,
+			   o.status, o.shipping_address, o.estimated_eta, o.created_at, o.updated_at,
+			   p.name, a.business_name, u.email
+		FROM orders o
+		JOIN products p ON o.product_id = p.id
+		JOIN artisans a ON o.artisan_id = a.id
+		JOIN users u ON o.user_id = u.id
+		WHERE 1=1
+	`
+	adminOrdersOrder = " ORDER BY o.created_at DESC, o.id DESC"
+)
+
+// adminOrdersFilter turns ?status= and ?user_id= into conditions on orders o.
+// On a bad user ID it writes the error and returns false.
+func adminOrdersFilter(w http.ResponseWriter, r *http.Request) (string, []interface{}, bool) {
+	where := ""
+	params := []interface{}{}
+
+	if status := r.URL.Query().Get("status"); status != "" {
+		params = append(params, status)
+		where += " AND o.status = $" + strconv.Itoa(len(params))
+	}
+
+	if userID := r.URL.Query().Get("user_id"); userID != "" {
+		id, err := strconv.Atoi(userID)
+		if err != nil {
+			middleware.RespondError(w, http.StatusBadRequest, "Invalid user ID")
+			return "", nil, false
+		}
+		params = append(params, id)
+		where += " AND o.user_id = $" + strconv.Itoa(len(params))
+	}
+
+	return where, params, true
+}
+
+type adminOrder struct {
+	models.Order
+	ProductName string `json:"product_name"`
+	ArtisanName string `json:"artisan_name"`
+	BuyerEmail  string `json:"buyer_email"`
+}
+
+func scanAdminOrders(r *http.Request, rows *sql.Rows) []adminOrder {
+	orders := []adminOrder{}
+	for rows.Next() {
+		var o adminOrder
+		err := rows.Scan(
+			&o.ID, &o.UserID, &o.ProductID, &o.ArtisanID, &o.Quantity, &o.TotalAmount,
+			&o.Status, &o.ShippingAddress, &o.EstimatedETA, &o.CreatedAt, &o.UpdatedAt,
+			&o.ProductName, &o.ArtisanName, &o.BuyerEmail,
+		)
+		if err != nil {
+			middleware.Logger(r.Context()).Error("Failed to scan order", "error", err)
+			continue
+		}
+		orders = append(orders, o)
+	}
+	return orders
+}
+
+// RevokeUserSessions signs a user out everywhere, e.g. when an account is
+// compromised or banned. Their API keys are revoked too, since they don't
+// depend on a session and would otherwise keep working.
+func (h *AdminHandler) RevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	userID, err := strconv.Atoi(idStr)
+	if err != nil {
+		middleware.RespondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	revoked, err := h.sessions.RevokeAll(r.Context(), userID)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to revoke sessions")
+		return
+	}
+	revokedKeys, err := h.apiKeys.RevokeAll(r.Context(), userID)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to revoke API keys")
+		return
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"message":          "Sessions revoked",
+		"revoked_sessions": revoked,
+		"revoked_api_keys": revokedKeys,
+	})
+}
@@ -2,26 +2,27 @@
 package handlers
 
 import (
+	"backend/internal/config"
 	"backend/internal/middleware"
 	"backend/internal/models"
 	"database/sql"
-	"encoding/json"
+	"math"
 	"net/http"
 	"strconv"
 )
 
 type AIHandler struct {
-	db *sql.DB
+	db  *sql.DB
+	cfg *config.Config
 }
 
-func NewAIHandler(db *sql.DB) *AIHandler {
-	return &AIHandler{db: db}
+func NewAIHandler(db *sql.DB, cfg *config.Config) *AIHandler {
+	return &AIHandler{db: db, cfg: cfg}
 }
 
 func (h *AIHandler) GenerateProductStory(w http.ResponseWriter, r *http.Request) {
 	var req models.ProductStoryRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		middleware.RespondError(w, http.StatusBadRequest, "Invalid request body")
+	if !middleware.DecodeJSON(w, r, &req) {
 		return
 	}
 
@@ -48,7 +49,7 @@ func (h *AIHandler) GetConfidenceScore(w http.ResponseWriter, r *http.Request) {
 	var completionRate, rating float64
 	var reviewCount int
 
-	err = h.db.QueryRow(`
+	err = h.db.QueryRowContext(r.Context(), `
 		SELECT a.is_verified, a.completion_rate, p.rating, p.review_count
 		FROM products p
 		JOIN artisans a ON p.artisan_id = a.id
@@ -96,7 +97,7 @@ func (h *AIHandler) GetDeliveryETA(w http.ResponseWriter, r *http.Request) {
 
 	var estimatedETA string
 	var craftingTime int
-	err = h.db.QueryRow(`
+	err = h.db.QueryRowContext(r.Context(), `
 		SELECT o.estimated_eta, p.crafting_time
 		FROM orders o
 		JOIN products p ON o.product_id = p.id
@@ -109,7 +110,7 @@ func (h *AIHandler) GetDeliveryETA(w http.ResponseWriter, r *http.Request) {
 	}
 
 	craftingDays := craftingTime / 24
-	shippingDays := 3
+	shippingDays := int(math.Ceil(h.cfg.Commerce.ShippingBuffer.Hours() / 24))
 
 	middleware.RespondJSON(w, http.StatusOK, map[string]interface{}{
 		"estimated_delivery": estimatedETA,
@@ -0,0 +1,108 @@
+// backend/internal/handlers/apikeys.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/apikey"
+	"backend/internal/middleware"
+	"backend/internal/models"
+)
+
+// maxAPIKeysPerUser keeps a leaked account from minting keys without bound.
+const maxAPIKeysPerUser = 20
+
+type APIKeyHandler struct {
+	keys *apikey.Store
+}
+
+func NewAPIKeyHandler(keys *apikey.Store) *APIKeyHandler {
+	return &APIKeyHandler{keys: keys}
+}
+
+func (h *APIKeyHandler) CreateKey(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
+
+	var req models.CreateAPIKeyRequest
+	if !middleware.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+
+	existing, err := h.keys.List(r.Context(), claims.UserID)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch API keys")
+		return
+	}
+	active := 0
+	for _, k := range existing {
+		if k.RevokedAt == nil {
+			active++
+		}
+	}
+	if active >= maxAPIKeysPerUser {
+		middleware.RespondError(w, http.StatusConflict, "API key limit reached. Revoke an unused key first")
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		t := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	key, plaintext, err := h.keys.Create(r.Context(), claims.UserID, req.Name, req.Scopes, expiresAt)
+	if errors.Is(err, apikey.ErrUnknownScope) {
+		middleware.RespondError(w, http.StatusBadRequest, "Unknown scope. Valid scopes: "+strings.Join(apikey.Scopes, ", "))
+		return
+	}
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to create API key")
+		return
+	}
+
+	// The plaintext key is only ever shown here
+	middleware.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+		"key":     plaintext,
+		"api_key": key,
+	})
+}
+
+func (h *APIKeyHandler) ListKeys(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
+
+	keys, err := h.keys.List(r.Context(), claims.UserID)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch API keys")
+		return
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, keys)
+}
+
+func (h *APIKeyHandler) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
+
+	keyID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		middleware.RespondError(w, http.StatusBadRequest, "Invalid API key ID")
+		return
+	}
+
+	err = h.keys.Revoke(r.Context(), claims.UserID, keyID)
+	if errors.Is(err, apikey.ErrNotFound) {
+		middleware.RespondError(w, http.StatusNotFound, "API key not found")
+		return
+	}
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to revoke API key")
+		return
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, map[string]string{"message": "API key revoked"})
+}
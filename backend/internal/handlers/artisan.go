@@ -3,7 +3,6 @@ package handlers
 
 import (
 	"database/sql"
-	"encoding/json"
 	"net/http"
 	"strconv"
 
@@ -20,33 +19,36 @@ func NewArtisanHandler(db *sql.DB) *ArtisanHandler {
 }
 
 func (h *ArtisanHandler) OnboardArtisan(w http.ResponseWriter, r *http.Request) {
-	println("Error here")
 	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
 
-	var artisan models.Artisan
-	println("Error here")
-	if err := json.NewDecoder(r.Body).Decode(&artisan); err != nil {
-		middleware.RespondError(w, http.StatusBadRequest, "Invalid request body")
+	var req models.ArtisanProfileRequest
+	if !middleware.DecodeJSON(w, r, &req) {
 		return
 	}
-	println("Error here")
-	artisan.UserID = claims.UserID
-	artisan.IsVerified = false
-	println("Error here")
-	err := h.db.QueryRow(`
+	artisan := models.Artisan{
+		UserID:           claims.UserID,
+		BusinessName:     req.BusinessName,
+		CraftType:        req.CraftType,
+		Region:           req.Region,
+		Bio:              req.Bio,
+		VerificationDocs: req.VerificationDocs,
+	}
+
+	err := h.db.QueryRowContext(r.Context(), `
 		INSERT INTO artisans (user_id, business_name, craft_type, region, bio, verification_docs)
 		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id, created_at
 	`, artisan.UserID, artisan.BusinessName, artisan.CraftType, artisan.Region,
 		artisan.Bio, artisan.VerificationDocs).Scan(&artisan.ID, &artisan.CreatedAt)
 	if err != nil {
-		middleware.RespondError(w, http.StatusInternalServerError, "Failed to create artisan profile")
+		middleware.RespondDBError(w, r, err, "Failed to create artisan profile")
 		return
 	}
 
-	// Update user role
-	_, err = h.db.Exec("UPDATE users SET role = 'artisan' WHERE id = $1", claims.UserID)
+	// Promote buyers only, so onboarding never changes an admin's role
+	_, err = h.db.ExecContext(r.Context(), "UPDATE users SET role = 'artisan' WHERE id = $1 AND role = 'buyer'", claims.UserID)
 	if err != nil {
+		middleware.Logger(r.Context()).Error("Failed to promote user to artisan", "error", err)
 		middleware.RespondError(w, http.StatusInternalServerError, "Failed to update user role")
 		return
 	}
@@ -57,19 +59,18 @@ func (h *ArtisanHandler) OnboardArtisan(w http.ResponseWriter, r *http.Request)
 func (h *ArtisanHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
 
-	var artisan models.Artisan
-	if err := json.NewDecoder(r.Body).Decode(&artisan); err != nil {
-		middleware.RespondError(w, http.StatusBadRequest, "Invalid request body")
+	var req models.UpdateArtisanProfileRequest
+	if !middleware.DecodeJSON(w, r, &req) {
 		return
 	}
 
-	_, err := h.db.Exec(`
+	_, err := h.db.ExecContext(r.Context(), `
 		UPDATE artisans SET business_name = $1, bio = $2, region = $3
 		WHERE user_id = $4
-	`, artisan.BusinessName, artisan.Bio, artisan.Region, claims.UserID)
+	`, req.BusinessName, req.Bio, req.Region, claims.UserID)
 
 	if err != nil {
-		middleware.RespondError(w, http.StatusInternalServerError, "Failed to update profile")
+		middleware.RespondDBError(w, r, err, "Failed to update profile")
 		return
 	}
 
@@ -85,7 +86,7 @@ func (h *ArtisanHandler) GetArtisanProfile(w http.ResponseWriter, r *http.Reques
 	}
 
 	var artisan models.Artisan
-	err = h.db.QueryRow(`
+	err = h.db.QueryRowContext(r.Context(), `
 		SELECT id, user_id, business_name, craft_type, region, bio, is_verified,
 			   rating, total_orders, completion_rate, created_at
 		FROM artisans WHERE id = $1
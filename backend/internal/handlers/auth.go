@@ -3,42 +3,45 @@ package handlers
 
 import (
 	"database/sql"
-	"encoding/json"
+	"errors"
+	"math"
 	"net/http"
-	"os"
+	"strconv"
 	"time"
 
+	"backend/internal/apikey"
+	"backend/internal/config"
+	"backend/internal/loginguard"
+	"backend/internal/mailer"
 	"backend/internal/middleware"
 	"backend/internal/models"
+	"backend/internal/session"
+	"backend/internal/token"
 
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthHandler struct {
-	db *sql.DB
+	db       *sql.DB
+	cfg      *config.Config
+	sessions *session.Store
+	tokens   *token.Service
+	mailer   mailer.Mailer
+	guard    *loginguard.Guard
+	apiKeys  *apikey.Store
 }
 
-func NewAuthHandler(db *sql.DB) *AuthHandler {
-	return &AuthHandler{db: db}
+func NewAuthHandler(db *sql.DB, cfg *config.Config, sessions *session.Store, tokens *token.Service, mail mailer.Mailer, guard *loginguard.Guard, apiKeys *apikey.Store) *AuthHandler {
+	return &AuthHandler{db: db, cfg: cfg, sessions: sessions, tokens: tokens, mailer: mail, guard: guard, apiKeys: apiKeys}
 }
 
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var req models.RegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		middleware.RespondError(w, http.StatusBadRequest, "Invalid request body")
+	if !middleware.DecodeJSON(w, r, &req) {
 		return
 	}
 
-	if req.Email == "" || req.Password == "" || req.Name == "" {
-		middleware.RespondError(w, http.StatusBadRequest, "All fields are required")
-		return
-	}
-
-	if req.Role == "" {
-		req.Role = models.RoleBuyer
-	}
-
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
 		middleware.RespondError(w, http.StatusInternalServerError, "Failed to hash password")
@@ -46,55 +49,60 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var userID int
-	err = h.db.QueryRow(`
+	err = h.db.QueryRowContext(r.Context(), `
 		INSERT INTO users (email, password_hash, name, role)
 		VALUES ($1, $2, $3, $4)
 		RETURNING id
-	`, req.Email, string(hashedPassword), req.Name, req.Role).Scan(&userID)
+	`, req.Email, string(hashedPassword), req.Name, models.RoleBuyer).Scan(&userID)
 
 	if err != nil {
-		middleware.RespondError(w, http.StatusConflict, "Email already exists")
+		middleware.RespondDBError(w, r, err, "Failed to create account")
 		return
 	}
 
 	var user models.User
-	err = h.db.QueryRow(`
-		SELECT id, email, name, role, created_at
+	err = h.db.QueryRowContext(r.Context(), `
+		SELECT id, email, name, role, email_verified, created_at
 		FROM users WHERE id = $1
-	`, userID).Scan(&user.ID, &user.Email, &user.Name, &user.Role, &user.CreatedAt)
+	`, userID).Scan(&user.ID, &user.Email, &user.Name, &user.Role, &user.EmailVerified, &user.CreatedAt)
 
 	if err != nil {
 		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch user")
 		return
 	}
 
-	token, err := h.generateToken(&user)
-	if err != nil {
-		middleware.RespondError(w, http.StatusInternalServerError, "Failed to generate token")
-		return
+	if err := h.sendVerificationEmail(r.Context(), &user); err != nil {
+		middleware.Logger(r.Context()).Error("Failed to send verification email", "user_id", user.ID, "error", err)
 	}
 
-	middleware.RespondJSON(w, http.StatusCreated, models.AuthResponse{
-		Token: token,
-		User:  user,
-	})
+	h.completeLogin(w, r, http.StatusCreated, &user)
 }
 
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req models.LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		middleware.RespondError(w, http.StatusBadRequest, "Invalid request body")
+	if !middleware.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	ip := middleware.ClientIP(r)
+	decision, err := h.guard.Check(r.Context(), req.Email, ip)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if !decision.Allowed {
+		respondLoginThrottled(w, decision)
 		return
 	}
 
 	var user models.User
-	err := h.db.QueryRow(`
-		SELECT id, email, password_hash, name, role, created_at
+	err = h.db.QueryRowContext(r.Context(), `
+		SELECT id, email, password_hash, name, role, email_verified, created_at
 		FROM users WHERE email = $1
-	`, req.Email).Scan(&user.ID, &user.Email, &user.PasswordHash, &user.Name, &user.Role, &user.CreatedAt)
+	`, req.Email).Scan(&user.ID, &user.Email, &user.PasswordHash, &user.Name, &user.Role, &user.EmailVerified, &user.CreatedAt)
 
 	if err == sql.ErrNoRows {
-		middleware.RespondError(w, http.StatusUnauthorized, "Invalid credentials")
+		h.loginFailed(w, r, req.Email, ip)
 		return
 	}
 	if err != nil {
@@ -103,38 +111,147 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		middleware.RespondError(w, http.StatusUnauthorized, "Invalid credentials")
+		h.loginFailed(w, r, req.Email, ip)
+		return
+	}
+
+	h.completeLogin(w, r, http.StatusOK, &user)
+}
+
+func respondLoginThrottled(w http.ResponseWriter, decision loginguard.Decision) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(decision.RetryAfter.Seconds()))))
+	if decision.Locked {
+		middleware.RespondError(w, http.StatusTooManyRequests, "Too many failed login attempts. Account temporarily locked")
+	} else {
+		middleware.RespondError(w, http.StatusTooManyRequests, "Too many failed login attempts. Please wait before retrying")
+	}
+}
+
+// loginSucceeded resets the account's failure count. It only runs once every
+// factor has been checked, so a known password alone cannot clear a lockout.
+func (h *AuthHandler) loginSucceeded(r *http.Request, user *models.User) {
+	if err := h.guard.RecordSuccess(r.Context(), user.Email, middleware.ClientIP(r)); err != nil {
+		middleware.Logger(r.Context()).Error("Failed to record login", "user_id", user.ID, "error", err)
+	}
+}
+
+// loginFailed counts the failure towards backoff and lockout. Unknown emails are
+// tracked too so the response doesn't reveal which accounts exist.
+func (h *AuthHandler) loginFailed(w http.ResponseWriter, r *http.Request, email, ip string) {
+	if err := h.guard.RecordFailure(r.Context(), email, ip); err != nil {
+		middleware.Logger(r.Context()).Error("Failed to record failed login", "ip", ip, "error", err)
+	}
+	middleware.RespondError(w, http.StatusUnauthorized, "Invalid credentials")
+}
+
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshRequest
+	if !middleware.DecodeJSON(w, r, &req) {
 		return
 	}
 
-	token, err := h.generateToken(&user)
+	sess, refreshToken, err := h.sessions.Rotate(r.Context(), req.RefreshToken, r.UserAgent(), middleware.ClientIP(r))
+	if errors.Is(err, session.ErrInvalidRefreshToken) || errors.Is(err, session.ErrRefreshTokenReused) {
+		middleware.RespondError(w, http.StatusUnauthorized, "Invalid refresh token")
+		return
+	}
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to refresh session")
+		return
+	}
+
+	// Reload the user so role and verification changes take effect on the next access token
+	var user models.User
+	err = h.db.QueryRowContext(r.Context(), `
+		SELECT id, email, name, role, email_verified, created_at
+		FROM users WHERE id = $1
+	`, sess.UserID).Scan(&user.ID, &user.Email, &user.Name, &user.Role, &user.EmailVerified, &user.CreatedAt)
+
+	if err != nil {
+		middleware.RespondError(w, http.StatusUnauthorized, "Invalid refresh token")
+		return
+	}
+
+	resp, err := h.authResponse(&user, sess.ID, refreshToken)
 	if err != nil {
 		middleware.RespondError(w, http.StatusInternalServerError, "Failed to generate token")
 		return
 	}
 
-	middleware.RespondJSON(w, http.StatusOK, models.AuthResponse{
-		Token: token,
-		User:  user,
-	})
+	middleware.RespondJSON(w, http.StatusOK, resp)
+}
+
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
+
+	var req models.LogoutRequest
+	if !middleware.DecodeOptionalJSON(w, r, &req) {
+		return
+	}
+
+	var err error
+	if req.AllSessions {
+		_, err = h.sessions.RevokeAll(r.Context(), claims.UserID)
+	} else {
+		err = h.sessions.Revoke(r.Context(), claims.SessionID)
+	}
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to log out")
+		return
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, map[string]string{"message": "Logged out"})
 }
 
-func (h *AuthHandler) generateToken(user *models.User) (string, error) {
+// startSession opens a new session for the user and responds with its token pair.
+func (h *AuthHandler) startSession(w http.ResponseWriter, r *http.Request, status int, user *models.User) {
+	resp, err := h.openSession(r, user)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to create session")
+		return
+	}
+
+	middleware.RespondJSON(w, status, resp)
+}
+
+func (h *AuthHandler) openSession(r *http.Request, user *models.User) (*models.AuthResponse, error) {
+	sess, refreshToken, err := h.sessions.Create(r.Context(), user.ID, r.UserAgent(), middleware.ClientIP(r))
+	if err != nil {
+		return nil, err
+	}
+
+	return h.authResponse(user, sess.ID, refreshToken)
+}
+
+func (h *AuthHandler) authResponse(user *models.User, sessionID int, refreshToken string) (*models.AuthResponse, error) {
+	token, err := h.generateToken(user, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AuthResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(h.cfg.Auth.AccessTokenTTL.Seconds()),
+		User:         *user,
+	}, nil
+}
+
+func (h *AuthHandler) generateToken(user *models.User, sessionID int) (string, error) {
 	claims := middleware.Claims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Role:   user.Role,
+		UserID:        user.ID,
+		Email:         user.Email,
+		Role:          user.Role,
+		EmailVerified: user.EmailVerified,
+		SessionID:     sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour * 7)),
+			Issuer:    token.Issuer,
+			Subject:   strconv.Itoa(user.ID),
+			Audience:  jwt.ClaimStrings{token.AccessAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(h.cfg.Auth.AccessTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "your-secret-key-change-in-production"
-	}
-
-	return token.SignedString([]byte(secret))
+	return h.tokens.Sign(claims)
 }
@@ -0,0 +1,60 @@
+// backend/internal/handlers/health.go
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"backend/internal/middleware"
+)
+
+const readinessTimeout = 2 * time.Second
+
+type HealthHandler struct {
+	db       *sql.DB
+	draining atomic.Bool
+}
+
+func NewHealthHandler(db *sql.DB) *HealthHandler {
+	return &HealthHandler{db: db}
+}
+
+// Drain marks the instance as shutting down so /readyz starts failing and the
+// orchestrator stops routing new traffic here while in-flight requests finish.
+func (h *HealthHandler) Drain() {
+	h.draining.Store(true)
+}
+
+// Healthz reports that the process is up. It deliberately checks nothing
+// else, so a database outage doesn't get the pod restarted.
+func (h *HealthHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	middleware.RespondJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Readyz reports whether the instance can serve traffic: it isn't shutting
+// down and the database answers a ping.
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	if h.draining.Load() {
+		middleware.RespondJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "draining"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+	if err := h.db.PingContext(ctx); err != nil {
+		middleware.Logger(r.Context()).Warn("Readiness check failed", "error", err)
+		middleware.RespondJSON(w, http.StatusServiceUnavailable, map[string]string{
+			"status":   "unavailable",
+			"database": "unreachable",
+		})
+		return
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, map[string]string{"status": "ok", "database": "ok"})
+}
@@ -0,0 +1,84 @@
+// backend/internal/handlers/health_test.go
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// pingDriver opens connections that only answer Ping, failing while down is set.
+type pingDriver struct{ down *atomic.Bool }
+
+type pingConn struct{ down *atomic.Bool }
+
+func (d pingDriver) Open(name string) (driver.Conn, error) { return pingConn(d), nil }
+
+func (c pingConn) Ping(ctx context.Context) error {
+	if c.down.Load() {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+func (c pingConn) Prepare(query string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c pingConn) Close() error                              { return nil }
+func (c pingConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+var databaseDown atomic.Bool
+
+func init() {
+	sql.Register("healthtest", pingDriver{down: &databaseDown})
+}
+
+func probe(t *testing.T, handler http.HandlerFunc) (int, map[string]string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Header().Get("Cache-Control") != "no-store" {
+		t.Error("health responses must not be cached")
+	}
+	var body map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body is not JSON: %s", rec.Body)
+	}
+	return rec.Code, body
+}
+
+func TestHealthDrain(t *testing.T) {
+	db, err := sql.Open("healthtest", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	h := NewHealthHandler(db)
+	t.Cleanup(func() { databaseDown.Store(false) })
+
+	if code, body := probe(t, h.Readyz); code != http.StatusOK || body["database"] != "ok" {
+		t.Errorf("ready instance: /readyz = %d %v, want 200", code, body)
+	}
+
+	databaseDown.Store(true)
+	if code, body := probe(t, h.Readyz); code != http.StatusServiceUnavailable || body["database"] != "unreachable" {
+		t.Errorf("database down: /readyz = %d %v, want 503 unreachable", code, body)
+	}
+	if code, _ := probe(t, h.Healthz); code != http.StatusOK {
+		t.Errorf("database down: /healthz = %d, want 200 so the pod isn't restarted", code)
+	}
+	databaseDown.Store(false)
+
+	h.Drain()
+	if code, body := probe(t, h.Readyz); code != http.StatusServiceUnavailable || body["status"] != "draining" {
+		t.Errorf("draining: /readyz = %d %v, want 503 draining", code, body)
+	}
+	if code, _ := probe(t, h.Healthz); code != http.StatusOK {
+		t.Errorf("draining: /healthz = %d, want 200 while in-flight requests finish", code)
+	}
+}
@@ -0,0 +1,281 @@
+// backend/internal/handlers/invitation.go
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"time"
+
+	"backend/internal/mailer"
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/token"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	invitationTTL      = 72 * time.Hour
+	invitationAudience = "craftora-admin-invitation"
+)
+
+type invitationClaims struct {
+	Email string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// Invitations are signed by the token service with their own audience, so an
+// invitation can never be replayed as an access token.
+func signInvitation(tokens *token.Service, id int, email string, expiresAt time.Time) (string, error) {
+	claims := invitationClaims{
+		Email: email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        strconv.Itoa(id),
+			Issuer:    token.Issuer,
+			Audience:  jwt.ClaimStrings{invitationAudience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	return tokens.Sign(claims)
+}
+
+func parseInvitation(tokens *token.Service, tokenString string) (*invitationClaims, error) {
+	claims := &invitationClaims{}
+	if err := tokens.Parse(tokenString, claims, jwt.WithAudience(invitationAudience)); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// CreateInvitation lets an existing admin invite someone to become an admin.
+// The signed link is only delivered by email.
+func (h *AdminHandler) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
+
+	var req models.CreateInvitationRequest
+	if !middleware.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	address, err := mail.ParseAddress(req.Email)
+	if err != nil {
+		middleware.RespondError(w, http.StatusBadRequest, "A valid email is required")
+		return
+	}
+
+	var isAdmin bool
+	err = h.db.QueryRowContext(r.Context(), `
+		SELECT EXISTS (SELECT 1 FROM users WHERE email = $1 AND role = 'admin')
+	`, address.Address).Scan(&isAdmin)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if isAdmin {
+		middleware.RespondError(w, http.StatusConflict, "User is already an admin")
+		return
+	}
+
+	invitation := models.AdminInvitation{
+		Email:     address.Address,
+		InvitedBy: claims.UserID,
+		ExpiresAt: time.Now().Add(invitationTTL),
+	}
+	err = h.db.QueryRowContext(r.Context(), `
+		INSERT INTO admin_invitations (email, invited_by, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`, invitation.Email, invitation.InvitedBy, invitation.ExpiresAt).Scan(&invitation.ID, &invitation.CreatedAt)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to create invitation")
+		return
+	}
+
+	inviteToken, err := signInvitation(h.tokens, invitation.ID, invitation.Email, invitation.ExpiresAt)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to sign invitation")
+		return
+	}
+
+	link := h.cfg.BaseURL + "/accept-invitation?token=" + url.QueryEscape(inviteToken)
+	err = h.mailer.Send(r.Context(), mailer.Message{
+		To:      invitation.Email,
+		Subject: "You've been invited to administer Craftora",
+		Body: fmt.Sprintf("Hello,\n\n%s has invited you to become a Craftora administrator. "+
+			"Accept the invitation within 72 hours using the link below:\n\n%s\n", claims.Email, link),
+	})
+	if err != nil {
+		middleware.Logger(r.Context()).Error("Failed to send admin invitation", "invitation_id", invitation.ID, "error", err)
+		middleware.RespondError(w, http.StatusBadGateway, "Invitation created but the email could not be sent")
+		return
+	}
+
+	middleware.RespondJSON(w, http.StatusCreated, invitation)
+}
+
+func (h *AdminHandler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.db.QueryContext(r.Context(), `
+		SELECT id, email, COALESCE(invited_by, 0), expires_at, accepted_at, revoked_at, created_at
+		FROM admin_invitations
+		ORDER BY created_at DESC
+	`)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch invitations")
+		return
+	}
+	defer rows.Close()
+
+	invitations := []models.AdminInvitation{}
+	for rows.Next() {
+		var inv models.AdminInvitation
+		if err := rows.Scan(&inv.ID, &inv.Email, &inv.InvitedBy, &inv.ExpiresAt,
+			&inv.AcceptedAt, &inv.RevokedAt, &inv.CreatedAt); err != nil {
+			middleware.Logger(r.Context()).Error("Failed to scan invitation", "error", err)
+			continue
+		}
+		invitations = append(invitations, inv)
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, invitations)
+}
+
+func (h *AdminHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	invitationID, err := strconv.Atoi(idStr)
+	if err != nil {
+		middleware.RespondError(w, http.StatusBadRequest, "Invalid invitation ID")
+		return
+	}
+
+	res, err := h.db.ExecContext(r.Context(), `
+		UPDATE admin_invitations SET revoked_at = NOW()
+		WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+	`, invitationID)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to revoke invitation")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		middleware.RespondError(w, http.StatusNotFound, "Pending invitation not found")
+		return
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, map[string]string{"message": "Invitation revoked"})
+}
+
+// AcceptInvitation redeems an invitation. Invitees without an account choose a
+// name and password; existing users confirm their current password and are promoted.
+func (h *AuthHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	var req models.AcceptInvitationRequest
+	if !middleware.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	invite, err := parseInvitation(h.tokens, req.Token)
+	if err != nil {
+		middleware.RespondError(w, http.StatusBadRequest, "Invalid or expired invitation")
+		return
+	}
+	invitationID, err := strconv.Atoi(invite.ID)
+	if err != nil {
+		middleware.RespondError(w, http.StatusBadRequest, "Invalid or expired invitation")
+		return
+	}
+
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+
+	var email string
+	var expiresAt time.Time
+	var acceptedAt, revokedAt sql.NullTime
+	err = tx.QueryRowContext(r.Context(), `
+		SELECT email, expires_at, accepted_at, revoked_at FROM admin_invitations
+		WHERE id = $1
+		FOR UPDATE
+	`, invitationID).Scan(&email, &expiresAt, &acceptedAt, &revokedAt)
+
+	if err != nil || email != invite.Email || acceptedAt.Valid || revokedAt.Valid || time.Now().After(expiresAt) {
+		middleware.RespondError(w, http.StatusBadRequest, "Invalid or expired invitation")
+		return
+	}
+
+	var userID int
+	var passwordHash string
+	err = tx.QueryRowContext(r.Context(), "SELECT id, password_hash FROM users WHERE email = $1", email).Scan(&userID, &passwordHash)
+
+	switch {
+	case err == sql.ErrNoRows:
+		if req.Name == "" || len(req.Password) < minPasswordLength {
+			middleware.RespondError(w, http.StatusBadRequest,
+				fmt.Sprintf("Name and a password of at least %d characters are required", minPasswordLength))
+			return
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			middleware.RespondError(w, http.StatusInternalServerError, "Failed to hash password")
+			return
+		}
+
+		err = tx.QueryRowContext(r.Context(), `
+			INSERT INTO users (email, password_hash, name, role, email_verified)
+			VALUES ($1, $2, $3, $4, true)
+			RETURNING id
+		`, email, string(hashedPassword), req.Name, models.RoleAdmin).Scan(&userID)
+		if err != nil {
+			middleware.RespondDBError(w, r, err, "Failed to create admin")
+			return
+		}
+
+	case err != nil:
+		middleware.RespondError(w, http.StatusInternalServerError, "Database error")
+		return
+
+	default:
+		if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(req.Password)); err != nil {
+			middleware.RespondError(w, http.StatusUnauthorized, "Invalid credentials")
+			return
+		}
+
+		_, err = tx.ExecContext(r.Context(), `
+			UPDATE users SET role = $1, email_verified = true WHERE id = $2
+		`, models.RoleAdmin, userID)
+		if err != nil {
+			middleware.RespondError(w, http.StatusInternalServerError, "Failed to promote user")
+			return
+		}
+	}
+
+	if _, err := tx.ExecContext(r.Context(), "UPDATE admin_invitations SET accepted_at = NOW() WHERE id = $1", invitationID); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to accept invitation")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to accept invitation")
+		return
+	}
+
+	var user models.User
+	err = h.db.QueryRowContext(r.Context(), `
+		SELECT id, email, name, role, email_verified, created_at
+		FROM users WHERE id = $1
+	`, userID).Scan(&user.ID, &user.Email, &user.Name, &user.Role, &user.EmailVerified, &user.CreatedAt)
+
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+
+	h.completeLogin(w, r, http.StatusOK, &user)
+}
@@ -0,0 +1,23 @@
+// backend/internal/handlers/keys.go
+package handlers
+
+import (
+	"net/http"
+
+	"backend/internal/middleware"
+	"backend/internal/token"
+)
+
+type KeysHandler struct {
+	tokens *token.Service
+}
+
+func NewKeysHandler(tokens *token.Service) *KeysHandler {
+	return &KeysHandler{tokens: tokens}
+}
+
+// JWKS publishes the public keys other services use to verify Craftora tokens.
+func (h *KeysHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	middleware.RespondJSON(w, http.StatusOK, h.tokens.JWKS())
+}
@@ -0,0 +1,75 @@
+// backend/internal/handlers/lockout.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"backend/internal/middleware"
+	"backend/internal/models"
+)
+
+// ListLockouts reports login lockouts, newest first. Pass ?active=true to only
+// show lockouts that are still in force.
+func (h *AdminHandler) ListLockouts(w http.ResponseWriter, r *http.Request) {
+	query := `
+		SELECT id, user_id, email, ip_address, scope, failed_attempts, locked_until, unlocked_at, unlocked_by, created_at
+		FROM account_lockouts
+	`
+	if r.URL.Query().Get("active") == "true" {
+		query += " WHERE unlocked_at IS NULL AND locked_until > NOW()"
+	}
+	query += " ORDER BY created_at DESC LIMIT 500"
+
+	rows, err := h.db.QueryContext(r.Context(), query)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch lockouts")
+		return
+	}
+	defer rows.Close()
+
+	lockouts := []models.AccountLockout{}
+	for rows.Next() {
+		var l models.AccountLockout
+		if err := rows.Scan(&l.ID, &l.UserID, &l.Email, &l.IPAddress, &l.Scope, &l.FailedAttempts,
+			&l.LockedUntil, &l.UnlockedAt, &l.UnlockedBy, &l.CreatedAt); err != nil {
+			middleware.Logger(r.Context()).Error("Failed to scan lockout", "error", err)
+			continue
+		}
+		lockouts = append(lockouts, l)
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, lockouts)
+}
+
+// UnlockUser lifts an account lockout early and resets the failed-attempt count.
+func (h *AdminHandler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
+
+	userID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		middleware.RespondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var exists bool
+	if err := h.db.QueryRowContext(r.Context(), `SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, userID).Scan(&exists); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if !exists {
+		middleware.RespondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	unlocked, err := h.guard.UnlockUser(r.Context(), userID, claims.UserID)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to unlock user")
+		return
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"message":  "User unlocked",
+		"unlocked": unlocked,
+	})
+}
@@ -3,36 +3,42 @@ package handlers
 
 import (
 	"database/sql"
-	"encoding/json"
 	"net/http"
 	"strconv"
 	"time"
 
+	"backend/internal/config"
+	"backend/internal/metrics"
 	"backend/internal/middleware"
 	"backend/internal/models"
 )
 
 type OrderHandler struct {
-	db *sql.DB
+	db  *sql.DB
+	cfg *config.Config
 }
 
-func NewOrderHandler(db *sql.DB) *OrderHandler {
-	return &OrderHandler{db: db}
+func NewOrderHandler(db *sql.DB, cfg *config.Config) *OrderHandler {
+	return &OrderHandler{db: db, cfg: cfg}
 }
 
 func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
 
-	var order models.Order
-	if err := json.NewDecoder(r.Body).Decode(&order); err != nil {
-		middleware.RespondError(w, http.StatusBadRequest, "Invalid request body")
+	var req models.CreateOrderRequest
+	if !middleware.DecodeJSON(w, r, &req) {
 		return
 	}
+	order := models.Order{
+		ProductID: req.ProductID,
+		Quantity:  req.Quantity,
+		AddressID: req.AddressID,
+	}
 
 	// Get product details
 	var price float64
 	var artisanID, craftingTime int
-	err := h.db.QueryRow(`
+	err := h.db.QueryRowContext(r.Context(), `
 		SELECT price, artisan_id, crafting_time FROM products
 		WHERE id = $1 AND is_approved = true AND stock >= $2
 	`, order.ProductID, order.Quantity).Scan(&price, &artisanID, &craftingTime)
@@ -42,40 +48,57 @@ func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	address, shippingAddress, err := resolveShippingAddress(r.Context(), h.db, claims.UserID, req.AddressID, req.ShippingAddress)
+	if !respondShippingAddressError(w, err) {
+		return
+	}
+	snapshot, err := shippingSnapshot(address)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to save shipping address")
+		return
+	}
+
 	order.UserID = claims.UserID
 	order.ArtisanID = artisanID
+	order.ShippingAddress = shippingAddress
+	order.ShippingDetails = address
 	order.TotalAmount = price * float64(order.Quantity)
 	order.Status = models.OrderPending
 
 	// Calculate ETA (crafting time + 3 days shipping)
-	order.EstimatedETA = time.Now().Add(time.Duration(craftingTime)*time.Hour + 72*time.Hour)
+	order.EstimatedETA = time.Now().Add(time.Duration(craftingTime)*time.Hour + h.cfg.Commerce.ShippingBuffer)
 
 	// Insert order
-	err = h.db.QueryRow(`
-		INSERT INTO orders (user_id, product_id, artisan_id, quantity, total_amount, status, shipping_address, estimated_eta)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	err = h.db.QueryRowContext(r.Context(), `
+		INSERT INTO orders (user_id, product_id, artisan_id, quantity, total_amount, status, shipping_address,
+			address_id, shipping_snapshot, estimated_eta)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id, created_at, updated_at
 	`, order.UserID, order.ProductID, order.ArtisanID, order.Quantity, order.TotalAmount,
-		order.Status, order.ShippingAddress, order.EstimatedETA,
+		order.Status, order.ShippingAddress, order.AddressID, snapshot, order.EstimatedETA,
 	).Scan(&order.ID, &order.CreatedAt, &order.UpdatedAt)
 
 	if err != nil {
-		middleware.RespondError(w, http.StatusInternalServerError, "Failed to create order")
+		middleware.RespondDBError(w, r, err, "Failed to create order")
 		return
 	}
 
 	// Update product stock
-	_, err = h.db.Exec("UPDATE products SET stock = stock - $1 WHERE id = $2", order.Quantity, order.ProductID)
+	_, err = h.db.ExecContext(r.Context(), "UPDATE products SET stock = stock - $1 WHERE id = $2", order.Quantity, order.ProductID)
 	if err != nil {
 		middleware.RespondError(w, http.StatusInternalServerError, "Failed to update stock")
 		return
 	}
 
 	// Add initial progress
-	_, err = h.db.Exec(`
+	_, err = h.db.ExecContext(r.Context(), `
 		INSERT INTO order_progress (order_id, stage, description)
 		VALUES ($1, $2, $3)
 	`, order.ID, "Order Placed", "Your order has been received and is awaiting confirmation")
+	if err != nil {
+		middleware.Logger(r.Context()).Error("Failed to add initial order progress", "order_id", order.ID, "error", err)
+	}
+	metrics.OrdersCreated.WithLabelValues("order").Inc()
 
 	middleware.RespondJSON(w, http.StatusCreated, order)
 }
@@ -83,7 +106,42 @@ func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 func (h *OrderHandler) GetUserOrders(w http.ResponseWriter, r *http.Request) {
 	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
 
-	rows, err := h.db.Query(`
+	rows, err := h.db.QueryContext(r.Context(), userOrdersQuery, claims.UserID)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch orders")
+		return
+	}
+	defer rows.Close()
+
+	middleware.RespondJSON(w, http.StatusOK, scanBuyerOrders(r, rows))
+}
+
+// GetUserOrdersPage is GetUserOrders for /api/v2, one page at a time.
+func (h *OrderHandler) GetUserOrdersPage(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
+
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+
+	var total int
+	if err := h.db.QueryRowContext(r.Context(), "SELECT COUNT(*) FROM orders WHERE user_id = $1", claims.UserID).Scan(&total); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch orders")
+		return
+	}
+
+	rows, err := h.db.QueryContext(r.Context(), userOrdersQuery+page.limitOffset(1), append([]interface{}{claims.UserID}, page.params()...)...)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch orders")
+		return
+	}
+	defer rows.Close()
+
+	middleware.RespondJSON(w, http.StatusOK, newPage(scanBuyerOrders(r, rows), page, total))
+}
+
+const userOrdersQuery = `
 		SELECT o.id, o.user_id, o.product_id, o.artisan_id, o.quantity, o.total_amount,
 			   o.status, o.shipping_address, o.estimated_eta, o.created_at, o.updated_at,
 			   p.name, p.image_urls, p.price,
@@ -92,38 +150,25 @@ func (h *OrderHandler) GetUserOrders(w http.ResponseWriter, r *http.Request) {
 		JOIN products p ON o.product_id = p.id
 		JOIN artisans a ON o.artisan_id = a.id
 		WHERE o.user_id = $1
-		ORDER BY o.created_at DESC
-	`, claims.UserID)
+		ORDER BY o.created_at DESC, o.id DESC
+	`
 
-	if err != nil {
-		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch orders")
-		return
-	}
-	defer rows.Close()
-
-	type OrderWithDetails struct {
-		models.Order
-		ProductName  string  `json:"product_name"`
-		ProductImage string  `json:"product_image"`
-		ProductPrice float64 `json:"product_price"`
-		ArtisanName  string  `json:"artisan_name"`
-	}
-
-	orders := []OrderWithDetails{}
+func scanBuyerOrders(r *http.Request, rows *sql.Rows) []models.BuyerOrder {
+	orders := []models.BuyerOrder{}
 	for rows.Next() {
-		var o OrderWithDetails
+		var o models.BuyerOrder
 		err := rows.Scan(
 			&o.ID, &o.UserID, &o.ProductID, &o.ArtisanID, &o.Quantity, &o.TotalAmount,
 			&o.Status, &o.ShippingAddress, &o.EstimatedETA, &o.CreatedAt, &o.UpdatedAt,
 			&o.ProductName, &o.ProductImage, &o.ProductPrice, &o.ArtisanName,
 		)
 		if err != nil {
+			middleware.Logger(r.Context()).Error("Failed to scan order", "error", err)
 			continue
 		}
 		orders = append(orders, o)
 	}
-
-	middleware.RespondJSON(w, http.StatusOK, orders)
+	return orders
 }
 
 func (h *OrderHandler) GetOrderDetails(w http.ResponseWriter, r *http.Request) {
@@ -144,9 +189,10 @@ func (h *OrderHandler) GetOrderDetails(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var order OrderDetails
-	err = h.db.QueryRow(`
+	var snapshot []byte
+	err = h.db.QueryRowContext(r.Context(), `
 		SELECT o.id, o.user_id, o.product_id, o.artisan_id, o.quantity, o.total_amount,
-			   o.status, o.shipping_address, o.estimated_eta, o.created_at, o.updated_at,
+			   o.status, o.shipping_address, o.address_id, o.shipping_snapshot, o.estimated_eta, o.created_at, o.updated_at,
 			   p.name, p.image_urls, a.business_name
 		FROM orders o
 		JOIN products p ON o.product_id = p.id
@@ -154,7 +200,7 @@ func (h *OrderHandler) GetOrderDetails(w http.ResponseWriter, r *http.Request) {
 		WHERE o.id = $1 AND o.user_id = $2
 	`, orderID, claims.UserID).Scan(
 		&order.ID, &order.UserID, &order.ProductID, &order.ArtisanID, &order.Quantity,
-		&order.TotalAmount, &order.Status, &order.ShippingAddress, &order.EstimatedETA,
+		&order.TotalAmount, &order.Status, &order.ShippingAddress, &order.AddressID, &snapshot, &order.EstimatedETA,
 		&order.CreatedAt, &order.UpdatedAt, &order.ProductName, &order.ProductImage, &order.ArtisanName,
 	)
 
@@ -162,19 +208,24 @@ func (h *OrderHandler) GetOrderDetails(w http.ResponseWriter, r *http.Request) {
 		middleware.RespondError(w, http.StatusNotFound, "Order not found")
 		return
 	}
+	order.ShippingDetails = decodeShippingSnapshot(snapshot)
 
 	// Get progress
-	rows, err := h.db.Query(`
+	rows, err := h.db.QueryContext(r.Context(), `
 		SELECT id, order_id, stage, description, image_url, created_at
 		FROM order_progress WHERE order_id = $1 ORDER BY created_at ASC
 	`, orderID)
-	if err == nil {
+	if err != nil {
+		middleware.Logger(r.Context()).Error("Failed to fetch order progress", "order_id", orderID, "error", err)
+	} else {
 		defer rows.Close()
 		for rows.Next() {
 			var p models.OrderProgress
-			if err := rows.Scan(&p.ID, &p.OrderID, &p.Stage, &p.Description, &p.ImageURL, &p.CreatedAt); err == nil {
-				order.Progress = append(order.Progress, p)
+			if err := rows.Scan(&p.ID, &p.OrderID, &p.Stage, &p.Description, &p.ImageURL, &p.CreatedAt); err != nil {
+				middleware.Logger(r.Context()).Error("Failed to scan order progress", "error", err)
+				continue
 			}
+			order.Progress = append(order.Progress, p)
 		}
 	}
 
@@ -185,50 +236,89 @@ func (h *OrderHandler) GetArtisanOrders(w http.ResponseWriter, r *http.Request)
 	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
 
 	var artisanID int
-	err := h.db.QueryRow("SELECT id FROM artisans WHERE user_id = $1", claims.UserID).Scan(&artisanID)
+	err := h.db.QueryRowContext(r.Context(), "SELECT id FROM artisans WHERE user_id = $1", claims.UserID).Scan(&artisanID)
 	if err != nil {
 		middleware.RespondError(w, http.StatusBadRequest, "Artisan profile not found")
 		return
 	}
 
-	rows, err := h.db.Query(`
-		SELECT o.id, o.user_id, o.product_id, o.artisan_id, o.quantity, o.total_amount,
-			   o.status, o.shipping_address, o.estimated_eta, o.created_at, o.updated_at,
-			   p.name, u.name as buyer_name
-		FROM orders o
-		JOIN products p ON o.product_id = p.id
-		JOIN users u ON o.user_id = u.id
-		WHERE o.artisan_id = $1
-		ORDER BY o.created_at DESC
-	`, artisanID)
-
+	rows, err := h.db.QueryContext(r.Context(), artisanOrdersQuery, artisanID)
 	if err != nil {
 		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch orders")
 		return
 	}
 	defer rows.Close()
 
-	type ArtisanOrderView struct {
-		models.Order
-		ProductName string `json:"product_name"`
-		BuyerName   string `json:"buyer_name"`
+	middleware.RespondJSON(w, http.StatusOK, scanArtisanOrders(r, rows))
+}
+
+// GetArtisanOrdersPage is GetArtisanOrders for /api/v2, one page at a time.
+func (h *OrderHandler) GetArtisanOrdersPage(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
+
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
 	}
 
-	orders := []ArtisanOrderView{}
+	var artisanID int
+	err := h.db.QueryRowContext(r.Context(), "SELECT id FROM artisans WHERE user_id = $1", claims.UserID).Scan(&artisanID)
+	if err != nil {
+		middleware.RespondError(w, http.StatusBadRequest, "Artisan profile not found")
+		return
+	}
+
+	var total int
+	if err := h.db.QueryRowContext(r.Context(), "SELECT COUNT(*) FROM orders WHERE artisan_id = $1", artisanID).Scan(&total); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch orders")
+		return
+	}
+
+	rows, err := h.db.QueryContext(r.Context(), artisanOrdersQuery+page.limitOffset(1), append([]interface{}{artisanID}, page.params()...)...)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch orders")
+		return
+	}
+	defer rows.Close()
+
+	middleware.RespondJSON(w, http.StatusOK, newPage(scanArtisanOrders(r, rows), page, total))
+}
+
+const artisanOrdersQuery = `
+		SELECT o.id, o.user_id, o.product_id, o.artisan_id, o.quantity, o.total_amount,
+			   o.status, o.shipping_address, o.shipping_snapshot, o.estimated_eta, o.created_at, o.updated_at,
+			   p.name, u.name as buyer_name
+		FROM orders o
+		JOIN products p ON o.product_id = p.id
+		JOIN users u ON o.user_id = u.id
+		WHERE o.artisan_id = $1
+		ORDER BY o.created_at DESC, o.id DESC
+	`
+
+type artisanOrderView struct {
+	models.Order
+	ProductName string `json:"product_name"`
+	BuyerName   string `json:"buyer_name"`
+}
+
+func scanArtisanOrders(r *http.Request, rows *sql.Rows) []artisanOrderView {
+	orders := []artisanOrderView{}
 	for rows.Next() {
-		var o ArtisanOrderView
+		var o artisanOrderView
+		var snapshot []byte
 		err := rows.Scan(
 			&o.ID, &o.UserID, &o.ProductID, &o.ArtisanID, &o.Quantity, &o.TotalAmount,
-			&o.Status, &o.ShippingAddress, &o.EstimatedETA, &o.CreatedAt, &o.UpdatedAt,
+			&o.Status, &o.ShippingAddress, &snapshot, &o.EstimatedETA, &o.CreatedAt, &o.UpdatedAt,
 			&o.ProductName, &o.BuyerName,
 		)
 		if err != nil {
+			middleware.Logger(r.Context()).Error("Failed to scan order", "error", err)
 			continue
 		}
+		o.ShippingDetails = decodeShippingSnapshot(snapshot)
 		orders = append(orders, o)
 	}
-
-	middleware.RespondJSON(w, http.StatusOK, orders)
+	return orders
 }
 
 func (h *OrderHandler) UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
@@ -240,23 +330,20 @@ func (h *OrderHandler) UpdateOrderStatus(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	var req struct {
-		Status string `json:"status"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		middleware.RespondError(w, http.StatusBadRequest, "Invalid request body")
+	var req models.UpdateOrderStatusRequest
+	if !middleware.DecodeJSON(w, r, &req) {
 		return
 	}
 
 	// Verify artisan owns this order
 	var artisanID int
-	err = h.db.QueryRow("SELECT id FROM artisans WHERE user_id = $1", claims.UserID).Scan(&artisanID)
+	err = h.db.QueryRowContext(r.Context(), "SELECT id FROM artisans WHERE user_id = $1", claims.UserID).Scan(&artisanID)
 	if err != nil {
 		middleware.RespondError(w, http.StatusForbidden, "Not authorized")
 		return
 	}
 
-	_, err = h.db.Exec(`
+	_, err = h.db.ExecContext(r.Context(), `
 		UPDATE orders SET status = $1, updated_at = NOW()
 		WHERE id = $2 AND artisan_id = $3
 	`, req.Status, orderID, artisanID)
@@ -278,28 +365,33 @@ func (h *OrderHandler) AddProgressUpdate(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	var progress models.OrderProgress
-	if err := json.NewDecoder(r.Body).Decode(&progress); err != nil {
-		middleware.RespondError(w, http.StatusBadRequest, "Invalid request body")
+	var req models.OrderProgressRequest
+	if !middleware.DecodeJSON(w, r, &req) {
 		return
 	}
 
 	// Verify artisan owns this order
 	var artisanID int
-	err = h.db.QueryRow("SELECT id FROM artisans WHERE user_id = $1", claims.UserID).Scan(&artisanID)
+	err = h.db.QueryRowContext(r.Context(), "SELECT id FROM artisans WHERE user_id = $1", claims.UserID).Scan(&artisanID)
 	if err != nil {
 		middleware.RespondError(w, http.StatusForbidden, "Not authorized")
 		return
 	}
 
 	var orderArtisanID int
-	err = h.db.QueryRow("SELECT artisan_id FROM orders WHERE id = $1", orderID).Scan(&orderArtisanID)
+	err = h.db.QueryRowContext(r.Context(), "SELECT artisan_id FROM orders WHERE id = $1", orderID).Scan(&orderArtisanID)
 	if err != nil || orderArtisanID != artisanID {
 		middleware.RespondError(w, http.StatusForbidden, "Not authorized")
 		return
 	}
 
-	err = h.db.QueryRow(`
+	progress := models.OrderProgress{
+		OrderID:     orderID,
+		Stage:       req.Stage,
+		Description: req.Description,
+		ImageURL:    req.ImageURL,
+	}
+	err = h.db.QueryRowContext(r.Context(), `
 		INSERT INTO order_progress (order_id, stage, description, image_url)
 		VALUES ($1, $2, $3, $4)
 		RETURNING id, created_at
@@ -0,0 +1,70 @@
+// backend/internal/handlers/pagination.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"backend/internal/middleware"
+	"backend/internal/models"
+)
+
+const (
+	defaultPerPage = 20
+	maxPerPage     = 100
+)
+
+// pageRequest is the page a v2 list endpoint was asked for.
+type pageRequest struct {
+	page    int
+	perPage int
+}
+
+func (p pageRequest) limitOffset(paramCount int) string {
+	return " LIMIT $" + strconv.Itoa(paramCount+1) + " OFFSET $" + strconv.Itoa(paramCount+2)
+}
+
+func (p pageRequest) params() []interface{} {
+	return []interface{}{p.perPage, (p.page - 1) * p.perPage}
+}
+
+// parsePage reads ?page= (from 1) and ?per_page= (1 to maxPerPage). On bad
+// values it writes a validation error and returns false.
+func parsePage(w http.ResponseWriter, r *http.Request) (pageRequest, bool) {
+	p := pageRequest{page: 1, perPage: defaultPerPage}
+	var fields []middleware.FieldError
+
+	if v := r.URL.Query().Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			fields = append(fields, middleware.FieldError{Field: "page", Code: middleware.FieldOutOfRange, Message: "page must be a whole number of at least 1"})
+		}
+		p.page = n
+	}
+	if v := r.URL.Query().Get("per_page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPerPage {
+			fields = append(fields, middleware.FieldError{Field: "per_page", Code: middleware.FieldOutOfRange,
+				Message: "per_page must be between 1 and " + strconv.Itoa(maxPerPage)})
+		}
+		p.perPage = n
+	}
+
+	if len(fields) > 0 {
+		middleware.RespondValidationError(w, fields)
+		return p, false
+	}
+	return p, true
+}
+
+func newPage[T any](items []T, p pageRequest, total int) models.Page[T] {
+	return models.Page[T]{
+		Data: items,
+		Pagination: models.Pagination{
+			Page:       p.page,
+			PerPage:    p.perPage,
+			Total:      total,
+			TotalPages: (total + p.perPage - 1) / p.perPage,
+		},
+	}
+}
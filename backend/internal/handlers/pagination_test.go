@@ -0,0 +1,159 @@
+// backend/internal/handlers/pagination_test.go
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"backend/internal/middleware"
+)
+
+func TestParsePage(t *testing.T) {
+	tests := []struct {
+		query     string
+		want      pageRequest
+		badFields []string
+	}{
+		{"", pageRequest{page: 1, perPage: defaultPerPage}, nil},
+		{"?page=3&per_page=50", pageRequest{page: 3, perPage: 50}, nil},
+		{"?page=1&per_page=1", pageRequest{page: 1, perPage: 1}, nil},
+		{"?per_page=100", pageRequest{page: 1, perPage: maxPerPage}, nil},
+		{"?page=0", pageRequest{}, []string{"page"}},
+		{"?page=-2", pageRequest{}, []string{"page"}},
+		{"?page=two", pageRequest{}, []string{"page"}},
+		{"?per_page=0", pageRequest{}, []string{"per_page"}},
+		{"?per_page=101", pageRequest{}, []string{"per_page"}},
+		{"?per_page=ten", pageRequest{}, []string{"per_page"}},
+		{"?page=0&per_page=500", pageRequest{}, []string{"page", "per_page"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			got, ok := parsePage(rec, httptest.NewRequest(http.MethodGet, "/api/v2/orders"+tt.query, nil))
+
+			if tt.badFields == nil {
+				if !ok || got != tt.want {
+					t.Fatalf("parsePage = %+v, %v; want %+v, true", got, ok, tt.want)
+				}
+				if rec.Body.Len() > 0 {
+					t.Errorf("wrote a response for a valid page: %s", rec.Body)
+				}
+				return
+			}
+
+			if ok {
+				t.Fatalf("parsePage accepted %q", tt.query)
+			}
+			if rec.Code != http.StatusUnprocessableEntity {
+				t.Errorf("status %d, want 422", rec.Code)
+			}
+			var body struct {
+				Errors []middleware.FieldError `json:"errors"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("body is not JSON: %s", rec.Body)
+			}
+			var fields []string
+			for _, e := range body.Errors {
+				if e.Code != middleware.FieldOutOfRange {
+					t.Errorf("%s: code %q, want %q", e.Field, e.Code, middleware.FieldOutOfRange)
+				}
+				fields = append(fields, e.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.badFields) {
+				t.Errorf("errors on %v, want %v", fields, tt.badFields)
+			}
+		})
+	}
+}
+
+func TestPageLimitOffset(t *testing.T) {
+	tests := []struct {
+		page       pageRequest
+		paramCount int
+		wantSQL    string
+		wantParams []interface{}
+	}{
+		{pageRequest{page: 1, perPage: 20}, 0, " LIMIT $1 OFFSET $2", []interface{}{20, 0}},
+		{pageRequest{page: 3, perPage: 20}, 1, " LIMIT $2 OFFSET $3", []interface{}{20, 40}},
+		{pageRequest{page: 2, perPage: 7}, 2, " LIMIT $3 OFFSET $4", []interface{}{7, 7}},
+	}
+
+	for _, tt := range tests {
+		if got := tt.page.limitOffset(tt.paramCount); got != tt.wantSQL {
+			t.Errorf("%+v.limitOffset(%d) = %q, want %q", tt.page, tt.paramCount, got, tt.wantSQL)
+		}
+		if got := tt.page.params(); !reflect.DeepEqual(got, tt.wantParams) {
+			t.Errorf("%+v.params() = %v, want %v", tt.page, got, tt.wantParams)
+		}
+	}
+}
+
+func TestNewPageTotalPages(t *testing.T) {
+	tests := []struct {
+		total, perPage, want int
+	}{
+		{0, 20, 0},
+		{1, 20, 1},
+		{20, 20, 1},
+		{21, 20, 2},
+		{100, 1, 100},
+	}
+
+	for _, tt := range tests {
+		p := newPage([]int{}, pageRequest{page: 1, perPage: tt.perPage}, tt.total)
+		if p.Pagination.TotalPages != tt.want {
+			t.Errorf("total %d, per page %d: TotalPages = %d, want %d", tt.total, tt.perPage, p.Pagination.TotalPages, tt.want)
+		}
+		if p.Pagination.Total != tt.total || p.Pagination.PerPage != tt.perPage || p.Pagination.Page != 1 {
+			t.Errorf("Pagination = %+v, want the request and total echoed back", p.Pagination)
+		}
+	}
+}
+
+// The v2 handlers reject a bad page before they touch the database.
+func TestPagedHandlersValidateThePage(t *testing.T) {
+	admin := NewAdminHandler(nil, nil, nil, nil, nil, nil, nil, nil)
+	handlers := map[string]http.HandlerFunc{
+		"pending artisans": admin.GetPendingArtisansPage,
+		"pending products": admin.GetPendingProductsPage,
+		"admin orders":     admin.ListOrdersPage,
+	}
+
+	for name, handler := range handlers {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, "/api/v2/admin/x?per_page=1000", nil))
+		if rec.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: status %d, want 422", name, rec.Code)
+		}
+	}
+}
+
+func TestAdminOrdersFilter(t *testing.T) {
+	tests := []struct {
+		query      string
+		wantWhere  string
+		wantParams []interface{}
+	}{
+		{"", "", []interface{}{}},
+		{"?status=shipped", " AND o.status = $1", []interface{}{"shipped"}},
+		{"?user_id=12", " AND o.user_id = $1", []interface{}{12}},
+		{"?status=shipped&user_id=12", " AND o.status = $1 AND o.user_id = $2", []interface{}{"shipped", 12}},
+	}
+
+	for _, tt := range tests {
+		where, params, ok := adminOrdersFilter(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/admin/orders"+tt.query, nil))
+		if !ok || where != tt.wantWhere || !reflect.DeepEqual(params, tt.wantParams) {
+			t.Errorf("%q: got %q %v %v, want %q %v", tt.query, where, params, ok, tt.wantWhere, tt.wantParams)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	if _, _, ok := adminOrdersFilter(rec, httptest.NewRequest(http.MethodGet, "/api/admin/orders?user_id=me", nil)); ok || rec.Code != http.StatusBadRequest {
+		t.Errorf("user_id=me: ok = %v, status %d; want a 400", ok, rec.Code)
+	}
+}
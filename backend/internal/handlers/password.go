@@ -0,0 +1,151 @@
+// backend/internal/handlers/password.go
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"backend/internal/mailer"
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/securetoken"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	passwordResetTTL  = time.Hour
+	minPasswordLength = 8
+)
+
+// ForgotPassword emails a single-use reset link. It always answers the same way
+// so the endpoint cannot be used to discover which emails are registered.
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.ForgotPasswordRequest
+	if !middleware.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	response := map[string]string{"message": "If that email is registered, a reset link has been sent"}
+
+	var userID int
+	var name string
+	err := h.db.QueryRowContext(r.Context(), "SELECT id, name FROM users WHERE email = $1", req.Email).Scan(&userID, &name)
+	if err == sql.ErrNoRows {
+		middleware.RespondJSON(w, http.StatusOK, response)
+		return
+	}
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	token, hash, err := securetoken.New()
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to generate reset token")
+		return
+	}
+
+	// Only the most recent link stays valid
+	_, err = h.db.ExecContext(r.Context(), `
+		UPDATE password_resets SET used_at = NOW()
+		WHERE user_id = $1 AND used_at IS NULL
+	`, userID)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to create reset token")
+		return
+	}
+
+	_, err = h.db.ExecContext(r.Context(), `
+		INSERT INTO password_resets (user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)
+	`, userID, hash, time.Now().Add(passwordResetTTL))
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to create reset token")
+		return
+	}
+
+	link := h.cfg.BaseURL + "/reset-password?token=" + url.QueryEscape(token)
+	err = h.mailer.Send(r.Context(), mailer.Message{
+		To:      req.Email,
+		Subject: "Reset your Craftora password",
+		Body: fmt.Sprintf("Hi %s,\n\nWe received a request to reset your Craftora password. "+
+			"Use the link below within the next hour to choose a new one:\n\n%s\n\n"+
+			"If you didn't ask for this, you can ignore this email.\n", name, link),
+	})
+	if err != nil {
+		middleware.Logger(r.Context()).Error("Failed to send password reset email", "user_id", userID, "error", err)
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, response)
+}
+
+// ResetPassword consumes a reset token, sets the new password and signs the
+// user out of every existing session.
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.ResetPasswordRequest
+	if !middleware.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to hash password")
+		return
+	}
+
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+
+	var resetID, userID int
+	err = tx.QueryRowContext(r.Context(), `
+		SELECT id, user_id FROM password_resets
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		FOR UPDATE
+	`, securetoken.Hash(req.Token)).Scan(&resetID, &userID)
+
+	if err == sql.ErrNoRows {
+		middleware.RespondError(w, http.StatusBadRequest, "Invalid or expired reset token")
+		return
+	}
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	// Following the emailed link also proves ownership of the address
+	_, err = tx.ExecContext(r.Context(), `
+		UPDATE users SET password_hash = $1, email_verified = true WHERE id = $2
+	`, string(hashedPassword), userID)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to update password")
+		return
+	}
+
+	if _, err := tx.ExecContext(r.Context(), "UPDATE password_resets SET used_at = NOW() WHERE id = $1", resetID); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to update password")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to update password")
+		return
+	}
+
+	if _, err := h.sessions.RevokeAll(r.Context(), userID); err != nil {
+		middleware.Logger(r.Context()).Error("Failed to revoke sessions after password reset", "user_id", userID, "error", err)
+	}
+	// Whoever forced the reset may have minted keys while they had the account.
+	if _, err := h.apiKeys.RevokeAll(r.Context(), userID); err != nil {
+		middleware.Logger(r.Context()).Error("Failed to revoke API keys after password reset", "user_id", userID, "error", err)
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, map[string]string{"message": "Password has been reset"})
+}
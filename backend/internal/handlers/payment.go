@@ -3,40 +3,45 @@ package handlers
 
 import (
 	"database/sql"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"time"
 
+	"backend/internal/config"
+	"backend/internal/metrics"
 	"backend/internal/middleware"
+	"backend/internal/models"
 )
 
 type PaymentHandler struct {
-	db *sql.DB
+	db  *sql.DB
+	cfg *config.Config
 }
 
-func NewPaymentHandler(db *sql.DB) *PaymentHandler {
-	return &PaymentHandler{db: db}
+func NewPaymentHandler(db *sql.DB, cfg *config.Config) *PaymentHandler {
+	return &PaymentHandler{db: db, cfg: cfg}
 }
 
 // CreateOrderWithPayment handles the complete payment flow with transaction
 func (h *OrderHandler) CreateOrderWithPayment(w http.ResponseWriter, r *http.Request) {
 	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
 
-	var req struct {
-		ProductID       int    `json:"product_id"`
-		Quantity        int    `json:"quantity"`
-		ShippingAddress string `json:"shipping_address"`
-		PaymentMethod   string `json:"payment_method"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		middleware.RespondError(w, http.StatusBadRequest, "Invalid request body")
+	var req models.CheckoutRequest
+	if !middleware.DecodeJSON(w, r, &req) {
 		return
 	}
 
+	// Any return before the commit below is a failed checkout.
+	completed := false
+	failure := "internal"
+	defer func() {
+		if !completed {
+			metrics.PaymentFailures.WithLabelValues(failure).Inc()
+		}
+	}()
+
 	// Start transaction
-	tx, err := h.db.Begin()
+	tx, err := h.db.BeginTx(r.Context(), nil)
 	if err != nil {
 		middleware.RespondError(w, http.StatusInternalServerError, "Failed to start transaction")
 		return
@@ -47,46 +52,59 @@ func (h *OrderHandler) CreateOrderWithPayment(w http.ResponseWriter, r *http.Req
 	var price float64
 	var artisanID, craftingTime, stock int
 	var productName string
-	err = tx.QueryRow(`
+	err = tx.QueryRowContext(r.Context(), `
 		SELECT price, artisan_id, crafting_time, stock, name FROM products
 		WHERE id = $1 AND is_approved = true
 		FOR UPDATE
 	`, req.ProductID).Scan(&price, &artisanID, &craftingTime, &stock, &productName)
 
 	if err != nil {
+		failure = "product_unavailable"
 		middleware.RespondError(w, http.StatusBadRequest, "Product not available")
 		return
 	}
 
 	// Check stock
 	if stock < req.Quantity {
+		failure = "insufficient_stock"
 		middleware.RespondError(w, http.StatusBadRequest, "Insufficient stock")
 		return
 	}
 
+	address, shippingAddress, err := resolveShippingAddress(r.Context(), tx, claims.UserID, req.AddressID, req.ShippingAddress)
+	if !respondShippingAddressError(w, err) {
+		failure = "shipping_address"
+		return
+	}
+	snapshot, err := shippingSnapshot(address)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to save shipping address")
+		return
+	}
+
 	totalAmount := price * float64(req.Quantity)
-	platformFee := totalAmount * 0.1
+	platformFee := h.cfg.Commerce.PlatformFee(totalAmount)
 	artisanAmount := totalAmount - platformFee
 
 	// Create order
 	var orderID int
-	estimatedETA := time.Now().Add(time.Duration(craftingTime)*time.Hour + 72*time.Hour)
+	estimatedETA := time.Now().Add(time.Duration(craftingTime)*time.Hour + h.cfg.Commerce.ShippingBuffer)
 
-	err = tx.QueryRow(`
+	err = tx.QueryRowContext(r.Context(), `
 		INSERT INTO orders (user_id, product_id, artisan_id, quantity, total_amount, 
-			status, shipping_address, estimated_eta)
-		VALUES ($1, $2, $3, $4, $5, 'confirmed', $6, $7)
+			status, shipping_address, address_id, shipping_snapshot, estimated_eta)
+		VALUES ($1, $2, $3, $4, $5, 'confirmed', $6, $7, $8, $9)
 		RETURNING id
 	`, claims.UserID, req.ProductID, artisanID, req.Quantity, totalAmount,
-		req.ShippingAddress, estimatedETA).Scan(&orderID)
+		shippingAddress, req.AddressID, snapshot, estimatedETA).Scan(&orderID)
 
 	if err != nil {
-		middleware.RespondError(w, http.StatusInternalServerError, "Failed to create order")
+		middleware.RespondDBError(w, r, err, "Failed to create order")
 		return
 	}
 
 	// Update product stock
-	_, err = tx.Exec(`
+	_, err = tx.ExecContext(r.Context(), `
 		UPDATE products SET stock = stock - $1 
 		WHERE id = $2
 	`, req.Quantity, req.ProductID)
@@ -97,7 +115,7 @@ func (h *OrderHandler) CreateOrderWithPayment(w http.ResponseWriter, r *http.Req
 	}
 
 	// Record payment transaction
-	_, err = tx.Exec(`
+	_, err = tx.ExecContext(r.Context(), `
 		INSERT INTO payments (order_id, amount, platform_fee, artisan_amount, 
 			payment_method, payment_status, transaction_id)
 		VALUES ($1, $2, $3, $4, $5, 'completed', $6)
@@ -110,7 +128,7 @@ func (h *OrderHandler) CreateOrderWithPayment(w http.ResponseWriter, r *http.Req
 	}
 
 	// Add initial progress
-	_, err = tx.Exec(`
+	_, err = tx.ExecContext(r.Context(), `
 		INSERT INTO order_progress (order_id, stage, description)
 		VALUES ($1, 'Order Confirmed', 'Payment received. Order is being prepared.')
 	`, orderID)
@@ -121,7 +139,7 @@ func (h *OrderHandler) CreateOrderWithPayment(w http.ResponseWriter, r *http.Req
 	}
 
 	// Update artisan stats
-	_, err = tx.Exec(`
+	_, err = tx.ExecContext(r.Context(), `
 		UPDATE artisans SET 
 			total_orders = total_orders + 1
 		WHERE id = $1
@@ -132,15 +150,18 @@ func (h *OrderHandler) CreateOrderWithPayment(w http.ResponseWriter, r *http.Req
 		middleware.RespondError(w, http.StatusInternalServerError, "Failed to complete order")
 		return
 	}
+	completed = true
+	metrics.OrdersCreated.WithLabelValues("checkout").Inc()
 
 	// Success response
 	middleware.RespondJSON(w, http.StatusCreated, map[string]interface{}{
-		"order_id":       orderID,
-		"total_amount":   totalAmount,
-		"artisan_amount": artisanAmount,
-		"platform_fee":   platformFee,
-		"message":        "Order placed successfully!",
-		"estimated_eta":  estimatedETA,
+		"order_id":         orderID,
+		"total_amount":     totalAmount,
+		"artisan_amount":   artisanAmount,
+		"platform_fee":     platformFee,
+		"message":          "Order placed successfully!",
+		"estimated_eta":    estimatedETA,
+		"shipping_address": shippingAddress,
 	})
 }
 
@@ -149,7 +170,7 @@ func (h *PaymentHandler) GetArtisanEarnings(w http.ResponseWriter, r *http.Reque
 	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
 
 	var artisanID int
-	err := h.db.QueryRow("SELECT id FROM artisans WHERE user_id = $1", claims.UserID).Scan(&artisanID)
+	err := h.db.QueryRowContext(r.Context(), "SELECT id FROM artisans WHERE user_id = $1", claims.UserID).Scan(&artisanID)
 	if err != nil {
 		middleware.RespondError(w, http.StatusBadRequest, "Artisan profile not found")
 		return
@@ -159,7 +180,7 @@ func (h *PaymentHandler) GetArtisanEarnings(w http.ResponseWriter, r *http.Reque
 	var totalOrders, pendingOrders, completedOrders int
 
 	// Total earnings from all orders
-	h.db.QueryRow(`
+	h.db.QueryRowContext(r.Context(), `
 		SELECT COALESCE(SUM(p.artisan_amount), 0), COUNT(*)
 		FROM payments p
 		JOIN orders o ON p.order_id = o.id
@@ -167,7 +188,7 @@ func (h *PaymentHandler) GetArtisanEarnings(w http.ResponseWriter, r *http.Reque
 	`, artisanID).Scan(&totalEarnings, &totalOrders)
 
 	// Pending orders
-	h.db.QueryRow(`
+	h.db.QueryRowContext(r.Context(), `
 		SELECT COALESCE(SUM(p.artisan_amount), 0), COUNT(*)
 		FROM payments p
 		JOIN orders o ON p.order_id = o.id
@@ -175,7 +196,7 @@ func (h *PaymentHandler) GetArtisanEarnings(w http.ResponseWriter, r *http.Reque
 	`, artisanID).Scan(&pendingAmount, &pendingOrders)
 
 	// Completed orders
-	h.db.QueryRow(`
+	h.db.QueryRowContext(r.Context(), `
 		SELECT COALESCE(SUM(p.artisan_amount), 0), COUNT(*)
 		FROM payments p
 		JOIN orders o ON p.order_id = o.id
@@ -189,6 +210,6 @@ func (h *PaymentHandler) GetArtisanEarnings(w http.ResponseWriter, r *http.Reque
 		"total_orders":      totalOrders,
 		"completed_orders":  completedOrders,
 		"pending_orders":    pendingOrders,
-		"platform_fee_rate": 0.1,
+		"platform_fee_rate": h.cfg.Commerce.PlatformFeeRate,
 	})
 }
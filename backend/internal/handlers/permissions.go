@@ -0,0 +1,126 @@
+// backend/internal/handlers/permissions.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/rbac"
+)
+
+// ListPermissions returns every known permission and what each role is granted.
+func (h *AdminHandler) ListPermissions(w http.ResponseWriter, r *http.Request) {
+	grants, err := h.permissions.RolePermissions(r.Context())
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch permissions")
+		return
+	}
+
+	roles := []models.RolePermissions{}
+	for _, role := range models.Roles {
+		roles = append(roles, models.RolePermissions{Role: role, Permissions: grants[role]})
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"permissions": rbac.Permissions,
+		"roles":       roles,
+	})
+}
+
+// UpdateRolePermissions replaces the permissions granted to a role. The admin
+// role always keeps every permission so nobody can lock themselves out.
+func (h *AdminHandler) UpdateRolePermissions(w http.ResponseWriter, r *http.Request) {
+	role := models.UserRole(r.PathValue("role"))
+	if !role.Valid() {
+		middleware.RespondError(w, http.StatusBadRequest, "Unknown role")
+		return
+	}
+	if role == models.RoleAdmin {
+		middleware.RespondError(w, http.StatusBadRequest, "The admin role always has every permission")
+		return
+	}
+
+	var req models.UpdateRolePermissionsRequest
+	if !middleware.DecodeJSON(w, r, &req) {
+		return
+	}
+	for _, p := range req.Permissions {
+		if !rbac.Known(p) {
+			middleware.RespondError(w, http.StatusBadRequest, "Unknown permission: "+p)
+			return
+		}
+	}
+
+	if err := h.permissions.SetRolePermissions(r.Context(), role, req.Permissions); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to update permissions")
+		return
+	}
+
+	grants, err := h.permissions.RolePermissions(r.Context())
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch permissions")
+		return
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, models.RolePermissions{Role: role, Permissions: grants[role]})
+}
+
+// UpdateUserRole moves a user to a staff role or back to buyer. Artisans are
+// managed through onboarding and verification instead, and admins are only
+// made through invitations, which expire and leave an audit trail.
+func (h *AdminHandler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
+
+	userID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		middleware.RespondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+	if userID == claims.UserID {
+		middleware.RespondError(w, http.StatusBadRequest, "You cannot change your own role")
+		return
+	}
+
+	var req models.UpdateUserRoleRequest
+	if !middleware.DecodeJSON(w, r, &req) {
+		return
+	}
+	if req.Role == models.RoleAdmin {
+		middleware.RespondError(w, http.StatusForbidden, "Admins can only be added by invitation (POST /api/admin/invitations)")
+		return
+	}
+	if !req.Role.Valid() || req.Role == models.RoleArtisan {
+		middleware.RespondError(w, http.StatusBadRequest, "Role must be buyer, support or moderator")
+		return
+	}
+
+	var current models.UserRole
+	err = h.db.QueryRowContext(r.Context(), `SELECT role FROM users WHERE id = $1`, userID).Scan(&current)
+	if err != nil {
+		middleware.RespondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if current == models.RoleArtisan {
+		middleware.RespondError(w, http.StatusConflict, "Artisan accounts cannot be moved to another role")
+		return
+	}
+
+	_, err = h.db.ExecContext(r.Context(), `UPDATE users SET role = $1 WHERE id = $2`, req.Role, userID)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to update role")
+		return
+	}
+
+	// The role travels in access tokens, so sign the user out to apply it now
+	if _, err := h.sessions.RevokeAll(r.Context(), userID); err != nil {
+		middleware.Logger(r.Context()).Error("Failed to revoke sessions after role change", "user_id", userID, "error", err)
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Role updated",
+		"user_id": userID,
+		"role":    req.Role,
+	})
+}
@@ -0,0 +1,48 @@
+// backend/internal/handlers/permissions_test.go
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend/internal/middleware"
+	"backend/internal/models"
+)
+
+// The refusals below all happen before the database is touched.
+func TestUpdateUserRoleRefuses(t *testing.T) {
+	h := NewAdminHandler(nil, nil, nil, nil, nil, nil, nil, nil)
+
+	tests := []struct {
+		name   string
+		target string
+		body   string
+		want   int
+		reason string
+	}{
+		{"granting admin", "42", `{"role":"admin"}`, http.StatusForbidden, "invitation"},
+		{"granting artisan", "42", `{"role":"artisan"}`, http.StatusBadRequest, "buyer, support or moderator"},
+		{"unknown role", "42", `{"role":"owner"}`, http.StatusBadRequest, "buyer, support or moderator"},
+		{"own role", "1", `{"role":"buyer"}`, http.StatusBadRequest, "your own role"},
+		{"invalid user ID", "me", `{"role":"buyer"}`, http.StatusBadRequest, "Invalid user ID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/api/admin/users/"+tt.target+"/role", strings.NewReader(tt.body))
+			r.SetPathValue("id", tt.target)
+			r = r.WithContext(context.WithValue(r.Context(), middleware.UserContextKey,
+				&middleware.Claims{UserID: 1, Role: models.RoleAdmin}))
+			rec := httptest.NewRecorder()
+
+			h.UpdateUserRole(rec, r)
+
+			if rec.Code != tt.want || !strings.Contains(rec.Body.String(), tt.reason) {
+				t.Errorf("status %d %s, want %d mentioning %q", rec.Code, rec.Body, tt.want, tt.reason)
+			}
+		})
+	}
+}
@@ -3,31 +3,73 @@ package handlers
 
 import (
 	"database/sql"
-	"encoding/json"
 	"net/http"
 	"strconv"
 
+	"backend/internal/config"
 	"backend/internal/middleware"
 	"backend/internal/models"
 )
 
 type ProductHandler struct {
-	db *sql.DB
+	db  *sql.DB
+	cfg *config.Config
 }
 
-func NewProductHandler(db *sql.DB) *ProductHandler {
-	return &ProductHandler{db: db}
+func NewProductHandler(db *sql.DB, cfg *config.Config) *ProductHandler {
+	return &ProductHandler{db: db, cfg: cfg}
 }
 
 func (h *ProductHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
-	query := `
+	from, params := productFilters(r)
+	rows, err := h.db.QueryContext(r.Context(), productColumns+from+productOrder(r), params...)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch products")
+		return
+	}
+	defer rows.Close()
+
+	middleware.RespondJSON(w, http.StatusOK, scanProducts(r, rows))
+}
+
+// ListProductsPage is ListProducts for /api/v2, one page at a time.
+func (h *ProductHandler) ListProductsPage(w http.ResponseWriter, r *http.Request) {
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+
+	from, params := productFilters(r)
+	var total int
+	if err := h.db.QueryRowContext(r.Context(), "SELECT COUNT(*)"+from, params...).Scan(&total); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch products")
+		return
+	}
+
+	query := productColumns + from + productOrder(r) + page.limitOffset(len(params))
+	rows, err := h.db.QueryContext(r.Context(), query, append(params, page.params()...)...)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch products")
+		return
+	}
+	defer rows.Close()
+
+	middleware.RespondJSON(w, http.StatusOK, newPage(scanProducts(r, rows), page, total))
+}
+
+const productColumns = `
 		SELECT p.id, p.artisan_id, p.category_id, p.name, p.description, p.ai_story,
 			   p.price, p.material_cost, p.labor_cost, p.platform_fee, p.materials,
 			   p.crafting_time, p.image_urls, p.stock, p.is_approved, p.rating,
 			   p.review_count, p.confidence_score, p.sustainability_score,
 			   p.created_at, p.updated_at,
 			   a.business_name, a.craft_type, a.region, a.is_verified,
-			   c.name as category_name
+			   c.name as category_name`
+
+// productFilters builds the FROM and WHERE clauses for the catalog search
+// from the query string.
+func productFilters(r *http.Request) (string, []interface{}) {
+	query := `
 		FROM products p
 		LEFT JOIN artisans a ON p.artisan_id = a.id
 		LEFT JOIN categories c ON p.category_id = c.id
@@ -74,28 +116,25 @@ func (h *ProductHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
 		params = append(params, maxPrice)
 	}
 
-	// Add sorting
-	sortBy := r.URL.Query().Get("sort")
-	switch sortBy {
+	return query, params
+}
+
+func productOrder(r *http.Request) string {
+	switch r.URL.Query().Get("sort") {
 	case "price_asc":
-		query += " ORDER BY p.price ASC"
+		return " ORDER BY p.price ASC, p.id"
 	case "price_desc":
-		query += " ORDER BY p.price DESC"
+		return " ORDER BY p.price DESC, p.id"
 	case "rating":
-		query += " ORDER BY p.rating DESC"
+		return " ORDER BY p.rating DESC, p.id"
 	case "newest":
-		query += " ORDER BY p.created_at DESC"
+		return " ORDER BY p.created_at DESC, p.id"
 	default:
-		query += " ORDER BY p.confidence_score DESC, p.rating DESC"
+		return " ORDER BY p.confidence_score DESC, p.rating DESC, p.id"
 	}
+}
 
-	rows, err := h.db.Query(query, params...)
-	if err != nil {
-		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch products")
-		return
-	}
-	defer rows.Close()
-
+func scanProducts(r *http.Request, rows *sql.Rows) []models.ProductWithDetails {
 	products := []models.ProductWithDetails{}
 	for rows.Next() {
 		var p models.ProductWithDetails
@@ -109,12 +148,12 @@ func (h *ProductHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
 			&p.CategoryName,
 		)
 		if err != nil {
+			middleware.Logger(r.Context()).Error("Failed to scan product", "error", err)
 			continue
 		}
 		products = append(products, p)
 	}
-
-	middleware.RespondJSON(w, http.StatusOK, products)
+	return products
 }
 
 func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
@@ -126,7 +165,7 @@ func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var p models.ProductWithDetails
-	err = h.db.QueryRow(`
+	err = h.db.QueryRowContext(r.Context(), `
 		SELECT p.id, p.artisan_id, p.category_id, p.name, p.description, p.ai_story,
 			   p.price, p.material_cost, p.labor_cost, p.platform_fee, p.materials,
 			   p.crafting_time, p.image_urls, p.stock, p.is_approved, p.rating,
@@ -168,22 +207,35 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 
 	// Get artisan ID
 	var artisanID int
-	err := h.db.QueryRow("SELECT id FROM artisans WHERE user_id = $1", claims.UserID).Scan(&artisanID)
+	err := h.db.QueryRowContext(r.Context(), "SELECT id FROM artisans WHERE user_id = $1", claims.UserID).Scan(&artisanID)
 	if err != nil {
 		middleware.RespondError(w, http.StatusBadRequest, "Artisan profile not found")
 		return
 	}
 
-	var product models.Product
-	if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
-		middleware.RespondError(w, http.StatusBadRequest, "Invalid request body")
+	var req models.ProductRequest
+	if !middleware.DecodeJSON(w, r, &req) {
 		return
 	}
 
-	product.ArtisanID = artisanID
-	product.IsApproved = false // Requires admin approval
+	product := models.Product{
+		ArtisanID:    artisanID,
+		CategoryID:   req.CategoryID,
+		Name:         req.Name,
+		Description:  req.Description,
+		AIStory:      req.AIStory,
+		Price:        req.Price,
+		MaterialCost: req.MaterialCost,
+		LaborCost:    req.LaborCost,
+		PlatformFee:  h.cfg.Commerce.PlatformFee(req.Price),
+		Materials:    req.Materials,
+		CraftingTime: req.CraftingTime,
+		ImageURLs:    req.ImageURLs,
+		Stock:        req.Stock,
+		IsApproved:   false, // Requires admin approval
+	}
 
-	err = h.db.QueryRow(`
+	err = h.db.QueryRowContext(r.Context(), `
 		INSERT INTO products (artisan_id, category_id, name, description, ai_story, price,
 			material_cost, labor_cost, platform_fee, materials, crafting_time, image_urls, stock)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
@@ -194,7 +246,7 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	).Scan(&product.ID, &product.CreatedAt, &product.UpdatedAt)
 
 	if err != nil {
-		middleware.RespondError(w, http.StatusInternalServerError, "Failed to create product")
+		middleware.RespondDBError(w, r, err, "Failed to create product")
 		return
 	}
 
@@ -212,7 +264,7 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 
 	// Verify ownership
 	var artisanID int
-	err = h.db.QueryRow(`
+	err = h.db.QueryRowContext(r.Context(), `
 		SELECT a.id FROM artisans a
 		JOIN products p ON p.artisan_id = a.id
 		WHERE p.id = $1 AND a.user_id = $2
@@ -223,21 +275,20 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var product models.Product
-	if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
-		middleware.RespondError(w, http.StatusBadRequest, "Invalid request body")
+	var req models.UpdateProductRequest
+	if !middleware.DecodeJSON(w, r, &req) {
 		return
 	}
 
-	_, err = h.db.Exec(`
-		UPDATE products SET name = $1, description = $2, price = $3, stock = $4,
-			materials = $5, crafting_time = $6, updated_at = NOW()
-		WHERE id = $7
-	`, product.Name, product.Description, product.Price, product.Stock,
-		product.Materials, product.CraftingTime, productID)
+	_, err = h.db.ExecContext(r.Context(), `
+		UPDATE products SET name = $1, description = $2, price = $3, platform_fee = $4,
+			stock = $5, materials = $6, crafting_time = $7, updated_at = NOW()
+		WHERE id = $8
+	`, req.Name, req.Description, req.Price, h.cfg.Commerce.PlatformFee(req.Price), req.Stock,
+		req.Materials, req.CraftingTime, productID)
 
 	if err != nil {
-		middleware.RespondError(w, http.StatusInternalServerError, "Failed to update product")
+		middleware.RespondDBError(w, r, err, "Failed to update product")
 		return
 	}
 
@@ -245,7 +296,7 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ProductHandler) ListCategories(w http.ResponseWriter, r *http.Request) {
-	rows, err := h.db.Query("SELECT id, name, slug, description, image_url FROM categories")
+	rows, err := h.db.QueryContext(r.Context(), "SELECT id, name, slug, description, image_url FROM categories ORDER BY id")
 	if err != nil {
 		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch categories")
 		return
@@ -256,6 +307,7 @@ func (h *ProductHandler) ListCategories(w http.ResponseWriter, r *http.Request)
 	for rows.Next() {
 		var c models.Category
 		if err := rows.Scan(&c.ID, &c.Name, &c.Slug, &c.Description, &c.ImageURL); err != nil {
+			middleware.Logger(r.Context()).Error("Failed to scan category", "error", err)
 			continue
 		}
 		categories = append(categories, c)
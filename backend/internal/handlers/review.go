@@ -3,8 +3,6 @@ package handlers
 
 import (
 	"database/sql"
-	"encoding/json"
-	"fmt"
 	"net/http"
 	"strconv"
 
@@ -23,36 +21,44 @@ func NewReviewHandler(db *sql.DB) *ReviewHandler {
 func (h *ReviewHandler) CreateReview(w http.ResponseWriter, r *http.Request) {
 	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
 
-	var review models.Review
-	if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
-		middleware.RespondError(w, http.StatusBadRequest, "Invalid request body")
+	var req models.CreateReviewRequest
+	if !middleware.DecodeJSON(w, r, &req) {
 		return
 	}
 
-	review.UserID = claims.UserID
+	review := models.Review{
+		UserID:    claims.UserID,
+		ProductID: req.ProductID,
+		OrderID:   req.OrderID,
+		Rating:    req.Rating,
+		Comment:   req.Comment,
+		MediaURLs: req.MediaURLs,
+	}
 
 	// Simple sentiment score calculation
 	review.SentimentScore = float64(review.Rating) * 20.0
 
-	err := h.db.QueryRow(`
+	err := h.db.QueryRowContext(r.Context(), `
 		INSERT INTO reviews (user_id, product_id, order_id, rating, comment, media_urls, sentiment_score)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6, $7)
 		RETURNING id, created_at
 	`, review.UserID, review.ProductID, review.OrderID, review.Rating,
 		review.Comment, review.MediaURLs, review.SentimentScore).Scan(&review.ID, &review.CreatedAt)
-	fmt.Println("DB error:", err.Error())
 	if err != nil {
-		middleware.RespondError(w, http.StatusInternalServerError, "Failed to create review")
+		middleware.RespondDBError(w, r, err, "Failed to create review")
 		return
 	}
 
 	// Update product rating
-	h.db.Exec(`
+	_, err = h.db.ExecContext(r.Context(), `
 		UPDATE products SET 
 			rating = (SELECT AVG(rating) FROM reviews WHERE product_id = $1),
 			review_count = (SELECT COUNT(*) FROM reviews WHERE product_id = $1)
 		WHERE id = $1
 	`, review.ProductID)
+	if err != nil {
+		middleware.Logger(r.Context()).Error("Failed to update product rating", "product_id", review.ProductID, "error", err)
+	}
 
 	middleware.RespondJSON(w, http.StatusCreated, review)
 }
@@ -65,29 +71,63 @@ func (h *ReviewHandler) GetProductReviews(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	rows, err := h.db.Query(`
-		SELECT r.id, r.user_id, r.product_id, r.order_id, r.rating, r.comment,
-			   r.media_urls, r.sentiment_score, r.created_at, u.name
-		FROM reviews r
-		JOIN users u ON r.user_id = u.id
-		WHERE r.product_id = $1
-		ORDER BY r.created_at DESC
-	`, productID)
+	rows, err := h.db.QueryContext(r.Context(), reviewsQuery, productID)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch reviews")
+		return
+	}
+	defer rows.Close()
+
+	middleware.RespondJSON(w, http.StatusOK, scanReviews(r, rows))
+}
 
+// GetProductReviewsPage is GetProductReviews for /api/v2, one page at a time.
+func (h *ReviewHandler) GetProductReviewsPage(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		middleware.RespondError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+
+	var total int
+	if err := h.db.QueryRowContext(r.Context(), "SELECT COUNT(*) FROM reviews WHERE product_id = $1", productID).Scan(&total); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch reviews")
+		return
+	}
+
+	rows, err := h.db.QueryContext(r.Context(), reviewsQuery+page.limitOffset(1), append([]interface{}{productID}, page.params()...)...)
 	if err != nil {
 		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch reviews")
 		return
 	}
 	defer rows.Close()
 
+	middleware.RespondJSON(w, http.StatusOK, newPage(scanReviews(r, rows), page, total))
+}
+
+const reviewsQuery = `
+		SELECT r.id, r.user_id, r.product_id, COALESCE(r.order_id, 0), r.rating, r.comment,
+			   r.media_urls, r.sentiment_score, r.created_at, u.name
+		FROM reviews r
+		JOIN users u ON r.user_id = u.id
+		WHERE r.product_id = $1
+		ORDER BY r.created_at DESC, r.id DESC
+	`
+
+func scanReviews(r *http.Request, rows *sql.Rows) []models.ReviewWithUser {
 	reviews := []models.ReviewWithUser{}
 	for rows.Next() {
-		var r models.ReviewWithUser
-		if err := rows.Scan(&r.ID, &r.UserID, &r.ProductID, &r.OrderID, &r.Rating,
-			&r.Comment, &r.MediaURLs, &r.SentimentScore, &r.CreatedAt, &r.UserName); err == nil {
-			reviews = append(reviews, r)
+		var rv models.ReviewWithUser
+		if err := rows.Scan(&rv.ID, &rv.UserID, &rv.ProductID, &rv.OrderID, &rv.Rating,
+			&rv.Comment, &rv.MediaURLs, &rv.SentimentScore, &rv.CreatedAt, &rv.UserName); err != nil {
+			middleware.Logger(r.Context()).Error("Failed to scan review", "error", err)
+			continue
 		}
+		reviews = append(reviews, rv)
 	}
-
-	middleware.RespondJSON(w, http.StatusOK, reviews)
+	return reviews
 }
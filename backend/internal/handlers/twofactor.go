@@ -0,0 +1,569 @@
+// backend/internal/handlers/twofactor.go
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"net/http"
+	"strings"
+	"time"
+
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/securetoken"
+	"backend/internal/totp"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	twoFactorIssuer      = "Craftora"
+	loginChallengeTTL    = 5 * time.Minute
+	maxChallengeAttempts = 5
+	recoveryCodeCount    = 10
+)
+
+// completeLogin runs after the password has been checked. It issues tokens
+// directly, or answers with a challenge when the account has 2FA enabled or
+// its role requires it.
+func (h *AuthHandler) completeLogin(w http.ResponseWriter, r *http.Request, status int, user *models.User) {
+	var enabled, required bool
+	err := h.db.QueryRowContext(r.Context(), `
+		SELECT
+			COALESCE((SELECT enabled FROM user_totp WHERE user_id = $1), false),
+			COALESCE((SELECT require_two_factor FROM role_policies WHERE role = $2), false)
+	`, user.ID, user.Role).Scan(&enabled, &required)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	if !enabled && !required {
+		h.loginSucceeded(r, user)
+		h.startSession(w, r, status, user)
+		return
+	}
+
+	challenge := models.TwoFactorChallenge{
+		TwoFactorRequired: true,
+		ExpiresIn:         int(loginChallengeTTL.Seconds()),
+	}
+
+	if !enabled {
+		secret, err := h.startTwoFactorEnrollment(r.Context(), user.ID)
+		if err != nil {
+			middleware.RespondError(w, http.StatusInternalServerError, "Failed to start two-factor enrollment")
+			return
+		}
+		challenge.EnrollmentRequired = true
+		challenge.Secret = secret
+		challenge.ProvisioningURI = totp.ProvisioningURI(secret, twoFactorIssuer, user.Email)
+	}
+
+	token, hash, err := securetoken.New()
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to create login challenge")
+		return
+	}
+
+	_, err = h.db.ExecContext(r.Context(), `
+		INSERT INTO login_challenges (user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)
+	`, user.ID, hash, time.Now().Add(loginChallengeTTL))
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to create login challenge")
+		return
+	}
+
+	challenge.ChallengeToken = token
+	middleware.RespondJSON(w, status, challenge)
+}
+
+// VerifyTwoFactorLogin is the second step of Login: it exchanges a challenge
+// token and a TOTP or recovery code for a session. Completing a challenge
+// that required enrollment enables 2FA and returns fresh recovery codes.
+// Wrong codes count towards the account's login backoff and lockout, so a
+// known password does not allow unlimited guesses across new challenges.
+func (h *AuthHandler) VerifyTwoFactorLogin(w http.ResponseWriter, r *http.Request) {
+	var req models.TwoFactorLoginRequest
+	if !middleware.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.Code == "" && req.RecoveryCode == "" {
+		middleware.RespondError(w, http.StatusBadRequest, "A code or recovery code is required")
+		return
+	}
+
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+
+	var challengeID, userID, attempts int
+	var email string
+	err = tx.QueryRowContext(r.Context(), `
+		SELECT c.id, c.user_id, c.attempts, u.email
+		FROM login_challenges c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.token_hash = $1 AND c.used_at IS NULL AND c.expires_at > NOW()
+		FOR UPDATE OF c
+	`, securetoken.Hash(req.ChallengeToken)).Scan(&challengeID, &userID, &attempts, &email)
+
+	if err == sql.ErrNoRows || attempts >= maxChallengeAttempts {
+		middleware.RespondError(w, http.StatusUnauthorized, "Invalid or expired challenge")
+		return
+	}
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	ip := middleware.ClientIP(r)
+	decision, err := h.guard.Check(r.Context(), email, ip)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if !decision.Allowed {
+		respondLoginThrottled(w, decision)
+		return
+	}
+
+	valid, enabled, err := verifySecondFactor(r.Context(), tx, userID, req.Code, req.RecoveryCode)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to verify code")
+		return
+	}
+
+	if !valid {
+		if _, err := tx.ExecContext(r.Context(), "UPDATE login_challenges SET attempts = attempts + 1 WHERE id = $1", challengeID); err == nil {
+			tx.Commit()
+		}
+		if err := h.guard.RecordFailure(r.Context(), email, ip); err != nil {
+			middleware.Logger(r.Context()).Error("Failed to record failed second factor", "user_id", userID, "error", err)
+		}
+		middleware.RespondError(w, http.StatusUnauthorized, "Invalid verification code")
+		return
+	}
+
+	if _, err := tx.ExecContext(r.Context(), "UPDATE login_challenges SET used_at = NOW() WHERE id = $1", challengeID); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to complete login")
+		return
+	}
+
+	var recoveryCodes []string
+	if !enabled {
+		if _, err := tx.ExecContext(r.Context(), "UPDATE user_totp SET enabled = true, enabled_at = NOW() WHERE user_id = $1", userID); err != nil {
+			middleware.RespondError(w, http.StatusInternalServerError, "Failed to enable two-factor authentication")
+			return
+		}
+		if recoveryCodes, err = replaceRecoveryCodes(r.Context(), tx, userID); err != nil {
+			middleware.RespondError(w, http.StatusInternalServerError, "Failed to generate recovery codes")
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to complete login")
+		return
+	}
+
+	var user models.User
+	err = h.db.QueryRowContext(r.Context(), `
+		SELECT id, email, name, role, email_verified, created_at
+		FROM users WHERE id = $1
+	`, userID).Scan(&user.ID, &user.Email, &user.Name, &user.Role, &user.EmailVerified, &user.CreatedAt)
+
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+
+	h.loginSucceeded(r, &user)
+
+	resp, err := h.openSession(r, &user)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to create session")
+		return
+	}
+	resp.RecoveryCodes = recoveryCodes
+
+	middleware.RespondJSON(w, http.StatusOK, resp)
+}
+
+// SetupTwoFactor starts voluntary enrollment. The secret only becomes active
+// once EnableTwoFactor confirms a code generated from it.
+func (h *AuthHandler) SetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
+
+	var enabled bool
+	err := h.db.QueryRowContext(r.Context(), `
+		SELECT COALESCE((SELECT enabled FROM user_totp WHERE user_id = $1), false)
+	`, claims.UserID).Scan(&enabled)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if enabled {
+		middleware.RespondError(w, http.StatusConflict, "Two-factor authentication is already enabled")
+		return
+	}
+
+	secret, err := h.startTwoFactorEnrollment(r.Context(), claims.UserID)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to start two-factor enrollment")
+		return
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, models.TwoFactorSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(secret, twoFactorIssuer, claims.Email),
+	})
+}
+
+func (h *AuthHandler) EnableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
+
+	var req models.TwoFactorCodeRequest
+	if !middleware.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+
+	valid, enabled, err := verifySecondFactor(r.Context(), tx, claims.UserID, req.Code, "")
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to verify code")
+		return
+	}
+	if enabled {
+		middleware.RespondError(w, http.StatusConflict, "Two-factor authentication is already enabled")
+		return
+	}
+	if !valid {
+		middleware.RespondError(w, http.StatusBadRequest, "Invalid verification code")
+		return
+	}
+
+	if _, err := tx.ExecContext(r.Context(), "UPDATE user_totp SET enabled = true, enabled_at = NOW() WHERE user_id = $1", claims.UserID); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to enable two-factor authentication")
+		return
+	}
+
+	codes, err := replaceRecoveryCodes(r.Context(), tx, claims.UserID)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to generate recovery codes")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to enable two-factor authentication")
+		return
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h *AuthHandler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
+
+	var req models.DisableTwoFactorRequest
+	if !middleware.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	var passwordHash string
+	var required bool
+	err := h.db.QueryRowContext(r.Context(), `
+		SELECT u.password_hash, COALESCE(rp.require_two_factor, false)
+		FROM users u
+		LEFT JOIN role_policies rp ON rp.role = u.role
+		WHERE u.id = $1
+	`, claims.UserID).Scan(&passwordHash, &required)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	if required {
+		middleware.RespondError(w, http.StatusForbidden, "Two-factor authentication is required for your role")
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(req.Password)); err != nil {
+		middleware.RespondError(w, http.StatusUnauthorized, "Invalid credentials")
+		return
+	}
+
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+
+	valid, enabled, err := verifySecondFactor(r.Context(), tx, claims.UserID, req.Code, req.RecoveryCode)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to verify code")
+		return
+	}
+	if !enabled {
+		middleware.RespondError(w, http.StatusBadRequest, "Two-factor authentication is not enabled")
+		return
+	}
+	if !valid {
+		middleware.RespondError(w, http.StatusUnauthorized, "Invalid verification code")
+		return
+	}
+
+	if _, err := tx.ExecContext(r.Context(), "DELETE FROM user_totp WHERE user_id = $1", claims.UserID); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to disable two-factor authentication")
+		return
+	}
+	if _, err := tx.ExecContext(r.Context(), "DELETE FROM recovery_codes WHERE user_id = $1", claims.UserID); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to disable two-factor authentication")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to disable two-factor authentication")
+		return
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, map[string]string{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes replaces every recovery code after confirming a TOTP code.
+func (h *AuthHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
+
+	var req models.TwoFactorCodeRequest
+	if !middleware.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+
+	valid, enabled, err := verifySecondFactor(r.Context(), tx, claims.UserID, req.Code, "")
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to verify code")
+		return
+	}
+	if !enabled || !valid {
+		middleware.RespondError(w, http.StatusUnauthorized, "Invalid verification code")
+		return
+	}
+
+	codes, err := replaceRecoveryCodes(r.Context(), tx, claims.UserID)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to generate recovery codes")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to generate recovery codes")
+		return
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h *AdminHandler) ListRolePolicies(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.db.QueryContext(r.Context(), "SELECT role, require_two_factor, updated_at FROM role_policies")
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch role policies")
+		return
+	}
+	defer rows.Close()
+
+	stored := map[models.UserRole]models.RolePolicy{}
+	for rows.Next() {
+		var p models.RolePolicy
+		if err := rows.Scan(&p.Role, &p.RequireTwoFactor, &p.UpdatedAt); err != nil {
+			middleware.Logger(r.Context()).Error("Failed to scan role policy", "error", err)
+			continue
+		}
+		stored[p.Role] = p
+	}
+
+	policies := []models.RolePolicy{}
+	for _, role := range models.Roles {
+		p, ok := stored[role]
+		if !ok {
+			p = models.RolePolicy{Role: role}
+		}
+		policies = append(policies, p)
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, policies)
+}
+
+// UpdateRolePolicy toggles mandatory 2FA for a role. Turning it on signs out
+// members of the role who have not enrolled yet, so their next login goes
+// through enrollment.
+func (h *AdminHandler) UpdateRolePolicy(w http.ResponseWriter, r *http.Request) {
+	role := models.UserRole(r.PathValue("role"))
+	if !role.Valid() {
+		middleware.RespondError(w, http.StatusBadRequest, "Unknown role")
+		return
+	}
+
+	var req models.UpdateRolePolicyRequest
+	if !middleware.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	policy := models.RolePolicy{Role: role, RequireTwoFactor: req.RequireTwoFactor}
+	err := h.db.QueryRowContext(r.Context(), `
+		INSERT INTO role_policies (role, require_two_factor, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (role) DO UPDATE SET require_two_factor = EXCLUDED.require_two_factor, updated_at = NOW()
+		RETURNING updated_at
+	`, policy.Role, policy.RequireTwoFactor).Scan(&policy.UpdatedAt)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to update role policy")
+		return
+	}
+
+	if policy.RequireTwoFactor {
+		_, err = h.db.ExecContext(r.Context(), `
+			UPDATE sessions SET revoked_at = NOW()
+			WHERE revoked_at IS NULL AND user_id IN (
+				SELECT u.id FROM users u
+				LEFT JOIN user_totp t ON t.user_id = u.id AND t.enabled
+				WHERE u.role = $1 AND t.user_id IS NULL
+			)
+		`, policy.Role)
+		if err != nil {
+			middleware.Logger(r.Context()).Error("Failed to revoke sessions without 2FA", "role", policy.Role, "error", err)
+		}
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, policy)
+}
+
+// startTwoFactorEnrollment stores a new, not yet enabled secret for the user.
+func (h *AuthHandler) startTwoFactorEnrollment(ctx context.Context, userID int) (string, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = h.db.ExecContext(ctx, `
+		INSERT INTO user_totp (user_id, secret, enabled)
+		VALUES ($1, $2, false)
+		ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, last_used_step = 0, created_at = NOW()
+		WHERE user_totp.enabled = false
+	`, userID, secret)
+	if err != nil {
+		return "", err
+	}
+
+	return secret, nil
+}
+
+// verifySecondFactor checks a TOTP code or, once 2FA is enabled, a recovery
+// code. TOTP codes are accepted once per time step and recovery codes are
+// consumed. It also reports whether 2FA was already enabled.
+func verifySecondFactor(ctx context.Context, tx *sql.Tx, userID int, code, recoveryCode string) (valid bool, enabled bool, err error) {
+	var secret string
+	var lastStep int64
+	err = tx.QueryRowContext(ctx, `
+		SELECT secret, enabled, last_used_step FROM user_totp
+		WHERE user_id = $1
+		FOR UPDATE
+	`, userID).Scan(&secret, &enabled, &lastStep)
+	if err == sql.ErrNoRows {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+
+	if code != "" {
+		step, ok := totp.Verify(secret, code, time.Now(), lastStep)
+		if !ok {
+			return false, enabled, nil
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE user_totp SET last_used_step = $1 WHERE user_id = $2", step, userID)
+		return err == nil, enabled, err
+	}
+
+	if recoveryCode != "" && enabled {
+		res, err := tx.ExecContext(ctx, `
+			UPDATE recovery_codes SET used_at = NOW()
+			WHERE id = (
+				SELECT id FROM recovery_codes
+				WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+				LIMIT 1
+			)
+		`, userID, hashRecoveryCode(recoveryCode))
+		if err != nil {
+			return false, enabled, err
+		}
+		n, _ := res.RowsAffected()
+		return n > 0, enabled, nil
+	}
+
+	return false, enabled, nil
+}
+
+// replaceRecoveryCodes discards the user's recovery codes and returns a new set.
+// Only hashes are stored, so the codes can be shown exactly once.
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userID int) ([]string, error) {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id = $1", userID); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO recovery_codes (user_id, code_hash)
+			VALUES ($1, $2)
+		`, userID, hashRecoveryCode(code))
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+
+	return codes, nil
+}
+
+// newRecoveryCode returns 50 random bits as ten base32 characters, written
+// xxxxx-xxxxx for readability.
+func newRecoveryCode() (string, error) {
+	buf := make([]byte, 7)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	raw := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf))[:10]
+	return raw[:5] + "-" + raw[5:], nil
+}
+
+// hashRecoveryCode ignores case, dashes and spaces so codes can be typed as
+// the user likes.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return securetoken.Hash(normalized)
+}
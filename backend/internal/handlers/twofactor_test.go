@@ -0,0 +1,39 @@
+// backend/internal/handlers/twofactor_test.go
+package handlers
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestNewRecoveryCodeFormat(t *testing.T) {
+	format := regexp.MustCompile(`^[a-z2-7]{5}-[a-z2-7]{5}$`)
+	seen := map[string]bool{}
+
+	for i := 0; i < 200; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !format.MatchString(code) {
+			t.Fatalf("recovery code %q is not xxxxx-xxxxx base32", code)
+		}
+		if seen[code] {
+			t.Fatalf("recovery code %q generated twice", code)
+		}
+		seen[code] = true
+	}
+}
+
+func TestHashRecoveryCodeNormalizes(t *testing.T) {
+	want := hashRecoveryCode("abcde-fghij")
+
+	for _, typed := range []string{"abcdefghij", "ABCDE-FGHIJ", "abcde fghij", " abcde-fghij"} {
+		if got := hashRecoveryCode(typed); got != want {
+			t.Errorf("hashRecoveryCode(%q) differs from the stored form", typed)
+		}
+	}
+	if hashRecoveryCode("abcde-fghik") == want {
+		t.Error("different recovery codes hash the same")
+	}
+}
@@ -0,0 +1,126 @@
+// backend/internal/handlers/verification.go
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"backend/internal/mailer"
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/securetoken"
+)
+
+const emailVerificationTTL = 48 * time.Hour
+
+// VerifyEmail consumes a verification token. Clients should refresh their
+// session afterwards so the new access token carries the verified flag.
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req models.VerifyEmailRequest
+	if !middleware.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+
+	var verificationID, userID int
+	err = tx.QueryRowContext(r.Context(), `
+		SELECT id, user_id FROM email_verifications
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		FOR UPDATE
+	`, securetoken.Hash(req.Token)).Scan(&verificationID, &userID)
+
+	if err == sql.ErrNoRows {
+		middleware.RespondError(w, http.StatusBadRequest, "Invalid or expired verification token")
+		return
+	}
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	if _, err := tx.ExecContext(r.Context(), "UPDATE users SET email_verified = true WHERE id = $1", userID); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to verify email")
+		return
+	}
+
+	if _, err := tx.ExecContext(r.Context(), "UPDATE email_verifications SET used_at = NOW() WHERE id = $1", verificationID); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to verify email")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to verify email")
+		return
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, map[string]string{"message": "Email verified"})
+}
+
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
+
+	var user models.User
+	err := h.db.QueryRowContext(r.Context(), `
+		SELECT id, email, name, email_verified FROM users WHERE id = $1
+	`, claims.UserID).Scan(&user.ID, &user.Email, &user.Name, &user.EmailVerified)
+
+	if err != nil {
+		middleware.RespondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	if user.EmailVerified {
+		middleware.RespondError(w, http.StatusBadRequest, "Email is already verified")
+		return
+	}
+
+	if err := h.sendVerificationEmail(r.Context(), &user); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to send verification email")
+		return
+	}
+
+	middleware.RespondJSON(w, http.StatusOK, map[string]string{"message": "Verification email sent"})
+}
+
+// sendVerificationEmail issues a fresh verification token, invalidating any
+// earlier ones, and mails the link to the user.
+func (h *AuthHandler) sendVerificationEmail(ctx context.Context, user *models.User) error {
+	token, hash, err := securetoken.New()
+	if err != nil {
+		return err
+	}
+
+	_, err = h.db.ExecContext(ctx, `
+		UPDATE email_verifications SET used_at = NOW()
+		WHERE user_id = $1 AND used_at IS NULL
+	`, user.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.db.ExecContext(ctx, `
+		INSERT INTO email_verifications (user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)
+	`, user.ID, hash, time.Now().Add(emailVerificationTTL))
+	if err != nil {
+		return err
+	}
+
+	link := h.cfg.BaseURL + "/verify-email?token=" + url.QueryEscape(token)
+	return h.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your Craftora email address",
+		Body: fmt.Sprintf("Hi %s,\n\nWelcome to Craftora! Please confirm your email address "+
+			"by opening the link below within the next 48 hours:\n\n%s\n", user.Name, link),
+	})
+}
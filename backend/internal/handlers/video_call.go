@@ -3,12 +3,13 @@ package handlers
 
 import (
 	"database/sql"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"time"
 
+	"backend/internal/metrics"
 	"backend/internal/middleware"
+	"backend/internal/models"
 )
 
 type VideoCallHandler struct {
@@ -35,29 +36,25 @@ type VideoCallRequest struct {
 func (h *VideoCallHandler) RequestCall(w http.ResponseWriter, r *http.Request) {
 	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
 
-	var req struct {
-		ProductID int `json:"product_id"`
-		ArtisanID int `json:"artisan_id"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		middleware.RespondError(w, http.StatusBadRequest, "Invalid request")
+	var req models.VideoCallRequest
+	if !middleware.DecodeJSON(w, r, &req) {
 		return
 	}
 
 	roomName := fmt.Sprintf("Artisan-Call-%d-%d-%d", req.ProductID, req.ArtisanID, time.Now().Unix())
 
 	var callID int
-	err := h.db.QueryRow(`
+	err := h.db.QueryRowContext(r.Context(), `
 		INSERT INTO video_call_requests (buyer_id, artisan_id, product_id, room_name, status)
 		VALUES ($1, $2, $3, $4, 'pending')
 		RETURNING id
 	`, claims.UserID, req.ArtisanID, req.ProductID, roomName).Scan(&callID)
 
 	if err != nil {
-		middleware.RespondError(w, http.StatusInternalServerError, "Failed to create call request")
+		middleware.RespondDBError(w, r, err, "Failed to create call request")
 		return
 	}
+	metrics.VideoCallsRequested.Inc()
 
 	middleware.RespondJSON(w, http.StatusCreated, map[string]interface{}{
 		"id":        callID,
@@ -71,37 +68,77 @@ func (h *VideoCallHandler) GetPendingCalls(w http.ResponseWriter, r *http.Reques
 	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
 
 	var artisanID int
-	err := h.db.QueryRow("SELECT id FROM artisans WHERE user_id = $1", claims.UserID).Scan(&artisanID)
+	err := h.db.QueryRowContext(r.Context(), "SELECT id FROM artisans WHERE user_id = $1", claims.UserID).Scan(&artisanID)
 	if err != nil {
 		middleware.RespondError(w, http.StatusNotFound, "Artisan profile not found")
 		return
 	}
 
-	rows, err := h.db.Query(`
-		SELECT v.id, v.buyer_id, v.artisan_id, v.product_id, v.room_name, v.status, 
-		       u.name as buyer_name, p.name as product_name, v.created_at
-		FROM video_call_requests v
-		JOIN users u ON v.buyer_id = u.id
-		JOIN products p ON v.product_id = p.id
-		WHERE v.artisan_id = $1 AND v.status = 'pending'
-		ORDER BY v.created_at DESC
-	`, artisanID)
+	rows, err := h.db.QueryContext(r.Context(), pendingCallsQuery, artisanID)
+	if err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch requests")
+		return
+	}
+	defer rows.Close()
+
+	middleware.RespondJSON(w, http.StatusOK, scanPendingCalls(r, rows))
+}
+
+// GetPendingCallsPage is GetPendingCalls for /api/v2, one page at a time.
+func (h *VideoCallHandler) GetPendingCallsPage(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
+
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
 
+	var artisanID int
+	err := h.db.QueryRowContext(r.Context(), "SELECT id FROM artisans WHERE user_id = $1", claims.UserID).Scan(&artisanID)
+	if err != nil {
+		middleware.RespondError(w, http.StatusNotFound, "Artisan profile not found")
+		return
+	}
+
+	var total int
+	if err := h.db.QueryRowContext(r.Context(),
+		"SELECT COUNT(*) FROM video_call_requests WHERE artisan_id = $1 AND status = 'pending'", artisanID).Scan(&total); err != nil {
+		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch requests")
+		return
+	}
+
+	rows, err := h.db.QueryContext(r.Context(), pendingCallsQuery+page.limitOffset(1), append([]interface{}{artisanID}, page.params()...)...)
 	if err != nil {
 		middleware.RespondError(w, http.StatusInternalServerError, "Failed to fetch requests")
 		return
 	}
 	defer rows.Close()
 
+	middleware.RespondJSON(w, http.StatusOK, newPage(scanPendingCalls(r, rows), page, total))
+}
+
+const pendingCallsQuery = `
+		SELECT v.id, v.buyer_id, v.artisan_id, v.product_id, v.room_name, v.status,
+		       u.name as buyer_name, p.name as product_name, v.created_at
+		FROM video_call_requests v
+		JOIN users u ON v.buyer_id = u.id
+		JOIN products p ON v.product_id = p.id
+		WHERE v.artisan_id = $1 AND v.status = 'pending'
+		ORDER BY v.created_at DESC, v.id DESC
+	`
+
+func scanPendingCalls(r *http.Request, rows *sql.Rows) []VideoCallRequest {
 	requests := []VideoCallRequest{}
 	for rows.Next() {
 		var req VideoCallRequest
-		rows.Scan(&req.ID, &req.BuyerID, &req.ArtisanID, &req.ProductID, &req.RoomName,
-			&req.Status, &req.BuyerName, &req.ProductName, &req.CreatedAt)
+		if err := rows.Scan(&req.ID, &req.BuyerID, &req.ArtisanID, &req.ProductID, &req.RoomName,
+			&req.Status, &req.BuyerName, &req.ProductName, &req.CreatedAt); err != nil {
+			middleware.Logger(r.Context()).Error("Failed to scan video call request", "error", err)
+			continue
+		}
 		requests = append(requests, req)
 	}
-
-	middleware.RespondJSON(w, http.StatusOK, requests)
+	return requests
 }
 
 // Artisan accepts call
@@ -109,7 +146,7 @@ func (h *VideoCallHandler) AcceptCall(w http.ResponseWriter, r *http.Request) {
 	claims := r.Context().Value(middleware.UserContextKey).(*middleware.Claims)
 	callID := r.PathValue("id")
 
-	_, err := h.db.Exec(`
+	_, err := h.db.ExecContext(r.Context(), `
 		UPDATE video_call_requests 
 		SET status = 'accepted'
 		WHERE id = $1 AND artisan_id IN (SELECT id FROM artisans WHERE user_id = $2)
@@ -128,7 +165,7 @@ func (h *VideoCallHandler) GetCallStatus(w http.ResponseWriter, r *http.Request)
 	callID := r.PathValue("id")
 
 	var status, roomName string
-	err := h.db.QueryRow(`
+	err := h.db.QueryRowContext(r.Context(), `
 		SELECT status, room_name FROM video_call_requests WHERE id = $1
 	`, callID).Scan(&status, &roomName)
 
@@ -0,0 +1,134 @@
+// backend/internal/idempotency/idempotency.go
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// Header is the request header clients put their idempotency key in.
+const Header = "Idempotency-Key"
+
+const (
+	// Retention is how long a completed response is replayed for.
+	Retention = 24 * time.Hour
+	// staleAfter is when an unfinished request is assumed to have died with
+	// its server, freeing the key for a retry.
+	staleAfter = 5 * time.Minute
+)
+
+var (
+	ErrFingerprintMismatch = errors.New("idempotency key reused with a different request")
+	ErrInProgress          = errors.New("a request with this idempotency key is still in progress")
+)
+
+// Response is a stored result that is replayed for duplicate requests.
+type Response struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+// Store records which idempotency keys have been used and what they returned.
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Fingerprint identifies a request by method, path and body, so a key
+// replayed against different input is caught.
+func Fingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Begin claims key for the user. It returns the record ID when the caller
+// should go ahead and run the request, or the stored response when the
+// request has already completed. ErrFingerprintMismatch and ErrInProgress
+// report the other outcomes.
+func (s *Store) Begin(ctx context.Context, userID int, key, fingerprint string) (int, *Response, error) {
+	_, err := s.db.ExecContext(ctx, `
+		DELETE FROM idempotency_keys
+		WHERE user_id = $1 AND idempotency_key = $2
+		AND (created_at < $3 OR (completed_at IS NULL AND created_at < $4))
+	`, userID, key, time.Now().Add(-Retention), time.Now().Add(-staleAfter))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var id int
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO idempotency_keys (user_id, idempotency_key, fingerprint)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, idempotency_key) DO NOTHING
+		RETURNING id
+	`, userID, key, fingerprint).Scan(&id)
+	if err == nil {
+		return id, nil, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, nil, err
+	}
+
+	var storedFingerprint string
+	var status sql.NullInt64
+	var contentType sql.NullString
+	var body []byte
+	err = s.db.QueryRowContext(ctx, `
+		SELECT fingerprint, status_code, content_type, response_body
+		FROM idempotency_keys
+		WHERE user_id = $1 AND idempotency_key = $2
+	`, userID, key).Scan(&storedFingerprint, &status, &contentType, &body)
+	if err == sql.ErrNoRows {
+		// Deleted between our insert and select; let the client retry.
+		return 0, nil, ErrInProgress
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if storedFingerprint != fingerprint {
+		return 0, nil, ErrFingerprintMismatch
+	}
+	if !status.Valid {
+		return 0, nil, ErrInProgress
+	}
+	return 0, &Response{StatusCode: int(status.Int64), ContentType: contentType.String, Body: body}, nil
+}
+
+// Complete stores the response for a claimed key.
+func (s *Store) Complete(ctx context.Context, id int, resp Response) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE idempotency_keys
+		SET status_code = $1, content_type = $2, response_body = $3, completed_at = NOW()
+		WHERE id = $4
+	`, resp.StatusCode, resp.ContentType, resp.Body, id)
+	return err
+}
+
+// Release frees a claimed key without storing a response, so the client
+// can retry after a server error.
+func (s *Store) Release(ctx context.Context, id int) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE id = $1", id)
+	return err
+}
+
+// Purge deletes keys older than Retention, responses included. Begin only
+// clears expired rows for the key it is claiming, and clients send a fresh
+// key per request, so this is what keeps the table from growing forever.
+func (s *Store) Purge(ctx context.Context) (int64, error) {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE created_at < $1", time.Now().Add(-Retention))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
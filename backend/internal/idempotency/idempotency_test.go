@@ -0,0 +1,149 @@
+// backend/internal/idempotency/idempotency_test.go
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"backend/internal/database/dbtest"
+)
+
+func TestFingerprint(t *testing.T) {
+	base := Fingerprint("POST", "/api/orders", []byte(`{"product_id":1}`))
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantSameAs bool
+	}{
+		{"identical request", "POST", "/api/orders", `{"product_id":1}`, true},
+		{"different body", "POST", "/api/orders", `{"product_id":2}`, false},
+		{"different path", "POST", "/api/reviews", `{"product_id":1}`, false},
+		{"different method", "PUT", "/api/orders", `{"product_id":1}`, false},
+		{"path and body boundary moved", "POST", "/api/orders\n{", `"product_id":1}`, false},
+		{"empty body", "POST", "/api/orders", ``, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Fingerprint(tt.method, tt.path, []byte(tt.body))
+			if (got == base) != tt.wantSameAs {
+				t.Errorf("Fingerprint(%s %s %q) == base: %v, want %v", tt.method, tt.path, tt.body, got == base, tt.wantSameAs)
+			}
+		})
+	}
+}
+
+func TestStore(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	store := NewStore(db)
+	userID, _ := dbtest.CreateUser(t, db)
+	otherUserID, _ := dbtest.CreateUser(t, db)
+	fp := Fingerprint("POST", "/api/orders", []byte(`{}`))
+
+	id, stored, err := store.Begin(ctx, userID, "key-1", fp)
+	if err != nil || id == 0 || stored != nil {
+		t.Fatalf("first Begin = %d, %v, %v; want a new claim", id, stored, err)
+	}
+
+	if _, _, err := store.Begin(ctx, userID, "key-1", fp); !errors.Is(err, ErrInProgress) {
+		t.Errorf("Begin while in progress: %v, want ErrInProgress", err)
+	}
+	if _, _, err := store.Begin(ctx, userID, "key-1", Fingerprint("POST", "/api/orders", []byte(`{"x":1}`))); !errors.Is(err, ErrFingerprintMismatch) {
+		t.Errorf("Begin with another request: %v, want ErrFingerprintMismatch", err)
+	}
+	if otherID, _, err := store.Begin(ctx, otherUserID, "key-1", fp); err != nil || otherID == 0 {
+		t.Errorf("another user's Begin with the same key = %d, %v; keys must be per user", otherID, err)
+	}
+
+	want := Response{StatusCode: 201, ContentType: "application/json", Body: []byte(`{"id":7}`)}
+	if err := store.Complete(ctx, id, want); err != nil {
+		t.Fatal(err)
+	}
+	_, stored, err = store.Begin(ctx, userID, "key-1", fp)
+	if err != nil || stored == nil {
+		t.Fatalf("Begin after Complete = %v, %v; want the stored response", stored, err)
+	}
+	if stored.StatusCode != want.StatusCode || stored.ContentType != want.ContentType || string(stored.Body) != string(want.Body) {
+		t.Errorf("stored response = %+v, want %+v", *stored, want)
+	}
+}
+
+func TestStoreReleaseAndStaleClaims(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	store := NewStore(db)
+	userID, _ := dbtest.CreateUser(t, db)
+	fp := Fingerprint("POST", "/api/orders", nil)
+
+	id, _, err := store.Begin(ctx, userID, "released", fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Release(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if retry, _, err := store.Begin(ctx, userID, "released", fp); err != nil || retry == 0 {
+		t.Errorf("Begin after Release = %d, %v; want a new claim", retry, err)
+	}
+
+	// A claim whose server died mid-request frees up after staleAfter.
+	id, _, err = store.Begin(ctx, userID, "stale", fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("UPDATE idempotency_keys SET created_at = $1 WHERE id = $2", time.Now().Add(-staleAfter-time.Minute), id); err != nil {
+		t.Fatal(err)
+	}
+	if retry, _, err := store.Begin(ctx, userID, "stale", fp); err != nil || retry == 0 {
+		t.Errorf("Begin on a stale claim = %d, %v; want a new claim", retry, err)
+	}
+}
+
+func TestStorePurge(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	store := NewStore(db)
+	userID, _ := dbtest.CreateUser(t, db)
+	fp := Fingerprint("POST", "/api/orders", nil)
+
+	expired, _, err := store.Begin(ctx, userID, "expired", fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Complete(ctx, expired, Response{StatusCode: 201, Body: []byte("{}")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("UPDATE idempotency_keys SET created_at = $1 WHERE id = $2", time.Now().Add(-Retention-time.Minute), expired); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.Begin(ctx, userID, "recent", fp); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := store.Purge(ctx)
+	if err != nil || removed != 1 {
+		t.Fatalf("Purge = %d, %v; want 1 row removed", removed, err)
+	}
+	var left []string
+	rows, err := db.Query("SELECT idempotency_key FROM idempotency_keys WHERE user_id = $1", userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			t.Fatal(err)
+		}
+		left = append(left, key)
+	}
+	if len(left) != 1 || left[0] != "recent" {
+		t.Errorf("keys left after Purge = %v, want [recent]", left)
+	}
+}
@@ -0,0 +1,216 @@
+// backend/internal/loginguard/loginguard.go
+package loginguard
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	// BackoffThreshold is the number of consecutive failures after which each
+	// further attempt on the account has to wait, doubling every time.
+	BackoffThreshold = 3
+	BaseBackoff      = time.Second
+	MaxBackoff       = 15 * time.Minute
+
+	// AccountLockoutThreshold failures lock the account; every further
+	// multiple locks it again for twice as long.
+	AccountLockoutThreshold = 10
+	AccountLockoutDuration  = 15 * time.Minute
+
+	// IPLockoutThreshold failures from one address within IPWindow, across
+	// any accounts, lock that address out.
+	IPLockoutThreshold = 30
+	IPWindow           = 15 * time.Minute
+	IPLockoutDuration  = 30 * time.Minute
+
+	// Failures older than this no longer count towards backoff or lockout.
+	failureWindow = 24 * time.Hour
+)
+
+const (
+	ScopeAccount = "account"
+	ScopeIP      = "ip"
+)
+
+// Decision tells the caller whether a login attempt may proceed.
+type Decision struct {
+	Allowed    bool
+	Locked     bool
+	RetryAfter time.Duration
+}
+
+// Guard tracks failed logins per account and per client IP in the database,
+// so limits hold across restarts and multiple instances.
+type Guard struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *Guard {
+	return &Guard{db: db}
+}
+
+// Check runs before the password is verified.
+func (g *Guard) Check(ctx context.Context, email, ip string) (Decision, error) {
+	email = normalize(email)
+
+	var lockedUntil sql.NullTime
+	err := g.db.QueryRowContext(ctx, `
+		SELECT MAX(locked_until) FROM account_lockouts
+		WHERE unlocked_at IS NULL AND locked_until > NOW()
+			AND ((scope = $1 AND email = $2) OR (scope = $3 AND ip_address = $4))
+	`, ScopeAccount, email, ScopeIP, ip).Scan(&lockedUntil)
+	if err != nil {
+		return Decision{}, err
+	}
+	if lockedUntil.Valid {
+		return Decision{Locked: true, RetryAfter: time.Until(lockedUntil.Time)}, nil
+	}
+
+	failures, lastFailure, err := g.accountFailures(ctx, email)
+	if err != nil {
+		return Decision{}, err
+	}
+	if failures >= BackoffThreshold {
+		if wait := time.Until(lastFailure.Add(Backoff(failures))); wait > 0 {
+			return Decision{RetryAfter: wait}, nil
+		}
+	}
+
+	return Decision{Allowed: true}, nil
+}
+
+// RecordFailure stores a failed attempt and locks the account or IP when a
+// threshold is crossed.
+func (g *Guard) RecordFailure(ctx context.Context, email, ip string) error {
+	email = normalize(email)
+
+	_, err := g.db.ExecContext(ctx, `
+		INSERT INTO login_attempts (email, ip_address, success)
+		VALUES ($1, $2, false)
+	`, email, ip)
+	if err != nil {
+		return err
+	}
+
+	failures, _, err := g.accountFailures(ctx, email)
+	if err != nil {
+		return err
+	}
+	if duration := AccountLockout(failures); duration > 0 {
+		if err := g.lock(ctx, ScopeAccount, email, "", failures, duration); err != nil {
+			return err
+		}
+	}
+
+	var ipFailures int
+	err = g.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM login_attempts
+		WHERE ip_address = $1 AND success = false AND created_at > $2
+	`, ip, time.Now().Add(-IPWindow)).Scan(&ipFailures)
+	if err != nil {
+		return err
+	}
+	if ipFailures >= IPLockoutThreshold {
+		return g.lock(ctx, ScopeIP, "", ip, ipFailures, IPLockoutDuration)
+	}
+
+	return nil
+}
+
+// RecordSuccess stores a successful attempt, which resets the account's failure count.
+func (g *Guard) RecordSuccess(ctx context.Context, email, ip string) error {
+	_, err := g.db.ExecContext(ctx, `
+		INSERT INTO login_attempts (email, ip_address, success)
+		VALUES ($1, $2, true)
+	`, normalize(email), ip)
+	return err
+}
+
+// Purge deletes attempts older than the failure window. Nothing reads them
+// after that, and they hold the email and IP address of everyone who tried
+// to sign in.
+func (g *Guard) Purge(ctx context.Context) (int64, error) {
+	res, err := g.db.ExecContext(ctx, "DELETE FROM login_attempts WHERE created_at < $1", time.Now().Add(-failureWindow))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// UnlockUser lifts the user's active account lockouts and resets their failure count.
+func (g *Guard) UnlockUser(ctx context.Context, userID, adminID int) (int64, error) {
+	res, err := g.db.ExecContext(ctx, `
+		UPDATE account_lockouts SET unlocked_at = NOW(), unlocked_by = $2
+		WHERE scope = $3 AND unlocked_at IS NULL
+			AND email = (SELECT LOWER(email) FROM users WHERE id = $1)
+	`, userID, adminID, ScopeAccount)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Backoff returns how long to wait after the given number of consecutive failures.
+func Backoff(failures int) time.Duration {
+	if failures < BackoffThreshold {
+		return 0
+	}
+	exp := float64(failures - BackoffThreshold)
+	wait := time.Duration(float64(BaseBackoff) * math.Pow(2, exp))
+	if wait <= 0 || wait > MaxBackoff {
+		return MaxBackoff
+	}
+	return wait
+}
+
+// AccountLockout returns how long the account is locked when a failure
+// brings it to the given count, or zero when that failure doesn't lock it.
+func AccountLockout(failures int) time.Duration {
+	if failures < AccountLockoutThreshold || failures%AccountLockoutThreshold != 0 {
+		return 0
+	}
+	round := failures / AccountLockoutThreshold
+	// Stop doubling well before the duration could overflow.
+	if round > 16 {
+		round = 16
+	}
+	return AccountLockoutDuration * time.Duration(1<<(round-1))
+}
+
+// accountFailures counts failures since the last successful login or admin
+// unlock, whichever is more recent.
+func (g *Guard) accountFailures(ctx context.Context, email string) (int, time.Time, error) {
+	var count int
+	var last sql.NullTime
+	err := g.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), MAX(created_at) FROM login_attempts
+		WHERE email = $1 AND success = false
+			AND created_at > GREATEST(
+				$2,
+				COALESCE((SELECT MAX(created_at) FROM login_attempts WHERE email = $1 AND success = true), $2),
+				COALESCE((SELECT MAX(unlocked_at) FROM account_lockouts WHERE email = $1 AND scope = $3), $2)
+			)
+	`, email, time.Now().Add(-failureWindow), ScopeAccount).Scan(&count, &last)
+	return count, last.Time, err
+}
+
+func (g *Guard) lock(ctx context.Context, scope, email, ip string, failures int, duration time.Duration) error {
+	_, err := g.db.ExecContext(ctx, `
+		INSERT INTO account_lockouts (user_id, email, ip_address, scope, failed_attempts, locked_until)
+		SELECT (SELECT id FROM users WHERE LOWER(email) = NULLIF($1, '')), NULLIF($1, ''), NULLIF($2, ''), $3, $4, $5
+		WHERE NOT EXISTS (
+			SELECT 1 FROM account_lockouts
+			WHERE scope = $3 AND unlocked_at IS NULL AND locked_until > NOW()
+				AND (email = NULLIF($1, '') OR ip_address = NULLIF($2, ''))
+		)
+	`, email, ip, scope, failures, time.Now().Add(duration))
+	return err
+}
+
+func normalize(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
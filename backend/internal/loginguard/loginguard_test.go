@@ -0,0 +1,108 @@
+// backend/internal/loginguard/loginguard_test.go
+package loginguard
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/database/dbtest"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{BackoffThreshold - 1, 0},
+		{BackoffThreshold, BaseBackoff},
+		{BackoffThreshold + 1, 2 * BaseBackoff},
+		{BackoffThreshold + 4, 16 * BaseBackoff},
+		{BackoffThreshold + 9, 512 * BaseBackoff},
+		{BackoffThreshold + 10, MaxBackoff},
+		{BackoffThreshold + 100, MaxBackoff},
+		{1 << 20, MaxBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := Backoff(tt.failures); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestBackoffNeverDecreases(t *testing.T) {
+	prev := time.Duration(0)
+	for failures := 0; failures < 200; failures++ {
+		got := Backoff(failures)
+		if got < prev || got > MaxBackoff {
+			t.Fatalf("Backoff(%d) = %v after %v", failures, got, prev)
+		}
+		prev = got
+	}
+}
+
+func TestAccountLockout(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, 0},
+		{AccountLockoutThreshold - 1, 0},
+		{AccountLockoutThreshold, AccountLockoutDuration},
+		{AccountLockoutThreshold + 1, 0},
+		{2*AccountLockoutThreshold - 1, 0},
+		{2 * AccountLockoutThreshold, 2 * AccountLockoutDuration},
+		{3 * AccountLockoutThreshold, 4 * AccountLockoutDuration},
+		{4 * AccountLockoutThreshold, 8 * AccountLockoutDuration},
+		{1000 * AccountLockoutThreshold, AccountLockoutDuration << 15},
+	}
+
+	for _, tt := range tests {
+		if got := AccountLockout(tt.failures); got != tt.want {
+			t.Errorf("AccountLockout(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+// Backoff must start before the first lockout, or an attacker gets the
+// whole lockout threshold at full speed.
+func TestThresholdsAreOrdered(t *testing.T) {
+	if BackoffThreshold >= AccountLockoutThreshold {
+		t.Errorf("BackoffThreshold %d is not below AccountLockoutThreshold %d", BackoffThreshold, AccountLockoutThreshold)
+	}
+	if Backoff(AccountLockoutThreshold-1) >= AccountLockoutDuration {
+		t.Errorf("backoff before the lockout (%v) already exceeds the lockout (%v)", Backoff(AccountLockoutThreshold-1), AccountLockoutDuration)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	if got := normalize("  Ana@Example.COM "); got != "ana@example.com" {
+		t.Errorf("normalize = %q", got)
+	}
+}
+
+func TestPurgeKeepsTheFailureWindow(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	g := New(db)
+
+	for _, email := range []string{"old@example.com", "recent@example.com"} {
+		if err := g.RecordFailure(ctx, email, "10.0.0.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.Exec("UPDATE login_attempts SET created_at = $1 WHERE email = 'old@example.com'", time.Now().Add(-failureWindow-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := g.Purge(ctx)
+	if err != nil || removed != 1 {
+		t.Fatalf("Purge = %d, %v; want 1 row removed", removed, err)
+	}
+	failures, _, err := g.accountFailures(ctx, "recent@example.com")
+	if err != nil || failures != 1 {
+		t.Errorf("failures inside the window after Purge = %d, %v; want 1", failures, err)
+	}
+}
@@ -0,0 +1,198 @@
+// backend/internal/middleware/cache.go
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// minCompressSize is the smallest body worth compressing; below it the
+// encoding overhead outweighs the savings.
+const minCompressSize = 1024
+
+var (
+	gzipWriters   = sync.Pool{New: func() interface{} { return gzip.NewWriter(io.Discard) }}
+	brotliWriters = sync.Pool{New: func() interface{} { return brotli.NewWriterLevel(io.Discard, brotli.DefaultCompression) }}
+)
+
+// Cache makes successful GET responses cacheable: it sends cacheControl,
+// a strong ETag and compresses JSON with brotli or gzip when the client
+// accepts it. Requests whose If-None-Match matches get a bodyless 304.
+//
+// The ETag is a hash of the response body, so any write that changes what a
+// route returns — a new or edited product, an approval, a new category, a
+// review moving a rating — changes its validator, on every instance.
+func Cache(cacheControl string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next(w, r)
+				return
+			}
+
+			buf := &bufferedResponse{ResponseWriter: w, status: http.StatusOK}
+			next(buf, r)
+
+			h := w.Header()
+			h.Add("Vary", "Accept-Encoding")
+			if buf.status != http.StatusOK {
+				w.WriteHeader(buf.status)
+				w.Write(buf.body.Bytes())
+				return
+			}
+
+			encoding := ""
+			if buf.body.Len() >= minCompressSize && isJSON(h.Get("Content-Type")) {
+				encoding = negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			}
+
+			// Each content coding is a different representation, so it gets
+			// its own strong validator.
+			sum := sha256.Sum256(buf.body.Bytes())
+			etag := hex.EncodeToString(sum[:16])
+			if encoding != "" {
+				etag += "-" + encoding
+			}
+			etag = `"` + etag + `"`
+
+			h.Set("ETag", etag)
+			h.Set("Cache-Control", cacheControl)
+
+			if etagMatches(r.Header.Get("If-None-Match"), etag) {
+				h.Del("Content-Type")
+				h.Del("Content-Length")
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			body := buf.body.Bytes()
+			if encoding != "" {
+				compressed, err := compress(encoding, body)
+				if err != nil {
+					Logger(r.Context()).Error("Failed to compress response", "encoding", encoding, "error", err)
+					h.Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+				} else {
+					h.Set("Content-Encoding", encoding)
+					body = compressed
+				}
+			}
+			h.Set("Content-Length", strconv.Itoa(len(body)))
+			w.WriteHeader(http.StatusOK)
+			w.Write(body)
+		}
+	}
+}
+
+// bufferedResponse holds a handler's status and body so Cache can hash and
+// compress it before anything is sent. Headers go straight to the real
+// response.
+type bufferedResponse struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if !b.wroteHeader {
+		b.status = status
+		b.wroteHeader = true
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	b.wroteHeader = true
+	return b.body.Write(p)
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(strings.ToLower(mediaType))
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// negotiateEncoding picks br or gzip from an Accept-Encoding header, by
+// q-value and preferring br on a tie. It returns "" when neither is
+// acceptable.
+func negotiateEncoding(accept string) string {
+	quality := map[string]float64{}
+	for _, part := range strings.Split(accept, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+		q := 1.0
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		quality[coding] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, coding := range []string{"br", "gzip"} {
+		q, ok := quality[coding]
+		if !ok {
+			q, ok = quality["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
+
+func compress(encoding string, body []byte) ([]byte, error) {
+	var out bytes.Buffer
+	switch encoding {
+	case "br":
+		bw := brotliWriters.Get().(*brotli.Writer)
+		defer brotliWriters.Put(bw)
+		bw.Reset(&out)
+		if _, err := bw.Write(body); err != nil {
+			return nil, err
+		}
+		if err := bw.Close(); err != nil {
+			return nil, err
+		}
+	case "gzip":
+		gw := gzipWriters.Get().(*gzip.Writer)
+		defer gzipWriters.Put(gw)
+		gw.Reset(&out)
+		if _, err := gw.Write(body); err != nil {
+			return nil, err
+		}
+		if err := gw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// etagMatches applies If-None-Match's weak comparison: "*" matches anything
+// and W/ prefixes are ignored.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,240 @@
+// backend/internal/middleware/cache_test.go
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+const cacheControl = "public, max-age=60"
+
+// largeJSON is comfortably over minCompressSize.
+var largeJSON = `{"items":[` + strings.Repeat(`{"name":"Hand-woven basket","price":1250},`, 60) + `{}]}`
+
+func cachedHandler(status int, contentType, body string) http.HandlerFunc {
+	return Cache(cacheControl)(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	})
+}
+
+func cacheRequest(handler http.HandlerFunc, method, acceptEncoding, ifNoneMatch string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/api/products", nil)
+	if acceptEncoding != "" {
+		r.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	if ifNoneMatch != "" {
+		r.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, r)
+	return rec
+}
+
+func decodeBody(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+	var reader io.Reader = bytes.NewReader(body)
+	switch encoding {
+	case "gzip":
+		gr, err := gzip.NewReader(reader)
+		if err != nil {
+			t.Fatalf("invalid gzip body: %v", err)
+		}
+		reader = gr
+	case "br":
+		reader = brotli.NewReader(reader)
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("failed to decode %s body: %v", encoding, err)
+	}
+	return string(decoded)
+}
+
+func TestCacheCompressesWithADistinctETagPerEncoding(t *testing.T) {
+	handler := cachedHandler(http.StatusOK, "application/json", largeJSON)
+
+	etags := map[string]string{}
+	for _, tt := range []struct{ accept, wantEncoding string }{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"gzip, br", "br"},
+		{"identity", ""},
+	} {
+		rec := cacheRequest(handler, http.MethodGet, tt.accept, "")
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Accept-Encoding %q: status %d", tt.accept, rec.Code)
+		}
+		if got := rec.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+			t.Errorf("Accept-Encoding %q: Content-Encoding = %q, want %q", tt.accept, got, tt.wantEncoding)
+		}
+		if got := decodeBody(t, tt.wantEncoding, rec.Body.Bytes()); got != largeJSON {
+			t.Errorf("Accept-Encoding %q: body does not round-trip", tt.accept)
+		}
+		if got := rec.Header().Get("Content-Length"); got != strconv.Itoa(rec.Body.Len()) {
+			t.Errorf("Accept-Encoding %q: Content-Length = %s, body is %d bytes", tt.accept, got, rec.Body.Len())
+		}
+		if rec.Header().Get("Cache-Control") != cacheControl || rec.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("Accept-Encoding %q: Cache-Control %q, Vary %q", tt.accept, rec.Header().Get("Cache-Control"), rec.Header().Get("Vary"))
+		}
+
+		etag := rec.Header().Get("ETag")
+		if prev, ok := etags[tt.wantEncoding]; ok && prev != etag {
+			t.Errorf("the %q representation got two ETags: %s and %s", tt.wantEncoding, prev, etag)
+		}
+		etags[tt.wantEncoding] = etag
+	}
+
+	if etags[""] == etags["gzip"] || etags[""] == etags["br"] || etags["gzip"] == etags["br"] {
+		t.Errorf("ETags are not distinct per encoding: %v", etags)
+	}
+	if !strings.HasSuffix(etags["gzip"], `-gzip"`) || !strings.HasSuffix(etags["br"], `-br"`) {
+		t.Errorf("ETags = %v, want the coding in compressed validators", etags)
+	}
+}
+
+func TestCacheNotModified(t *testing.T) {
+	handler := cachedHandler(http.StatusOK, "application/json", largeJSON)
+	gzipETag := cacheRequest(handler, http.MethodGet, "gzip", "").Header().Get("ETag")
+	plainETag := cacheRequest(handler, http.MethodGet, "", "").Header().Get("ETag")
+
+	tests := []struct {
+		name        string
+		accept      string
+		ifNoneMatch string
+		want        int
+	}{
+		{"matching ETag", "gzip", gzipETag, http.StatusNotModified},
+		{"weak form of the ETag", "gzip", "W/" + gzipETag, http.StatusNotModified},
+		{"ETag in a list", "gzip", `"stale", ` + gzipETag, http.StatusNotModified},
+		{"wildcard", "", "*", http.StatusNotModified},
+		{"uncompressed ETag", "", plainETag, http.StatusNotModified},
+		{"ETag of another encoding", "br", gzipETag, http.StatusOK},
+		{"stale ETag", "gzip", `"stale"`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := cacheRequest(handler, http.MethodGet, tt.accept, tt.ifNoneMatch)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want != http.StatusNotModified {
+				return
+			}
+			if rec.Body.Len() != 0 {
+				t.Errorf("304 has a %d-byte body", rec.Body.Len())
+			}
+			for _, header := range []string{"Content-Type", "Content-Length", "Content-Encoding"} {
+				if got := rec.Header().Get(header); got != "" {
+					t.Errorf("304 carries %s: %q", header, got)
+				}
+			}
+			if rec.Header().Get("ETag") == "" || rec.Header().Get("Cache-Control") != cacheControl {
+				t.Errorf("304 should keep ETag and Cache-Control, got %q and %q", rec.Header().Get("ETag"), rec.Header().Get("Cache-Control"))
+			}
+		})
+	}
+}
+
+func TestCachePassesThrough(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		status      int
+		contentType string
+		body        string
+		wantETag    bool
+	}{
+		{"error response", http.MethodGet, http.StatusNotFound, "application/json", largeJSON, false},
+		{"redirect", http.MethodGet, http.StatusFound, "application/json", largeJSON, false},
+		{"small body", http.MethodGet, http.StatusOK, "application/json", `{"id":1}`, true},
+		{"non-JSON body", http.MethodGet, http.StatusOK, "text/csv", largeJSON, true},
+		{"POST", http.MethodPost, http.StatusCreated, "application/json", largeJSON, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := cachedHandler(tt.status, tt.contentType, tt.body)
+			rec := cacheRequest(handler, tt.method, "br, gzip", "")
+
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d", rec.Code, tt.status)
+			}
+			if got := rec.Header().Get("Content-Encoding"); got != "" {
+				t.Errorf("Content-Encoding = %q, want the body sent as is", got)
+			}
+			if got := rec.Header().Get("ETag") != ""; got != tt.wantETag {
+				t.Errorf("has ETag = %v, want %v", got, tt.wantETag)
+			}
+			if rec.Body.String() != tt.body {
+				t.Errorf("body was changed")
+			}
+		})
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"br", "br"},
+		{"gzip, br", "br"},
+		{"GZIP", "gzip"},
+		{"br;q=0, gzip", "gzip"},
+		{"br;q=0, gzip;q=0", ""},
+		{"br;q=0.5, gzip;q=0.8", "gzip"},
+		{"br;q=0.8, gzip;q=0.8", "br"},
+		{"br ; q=0.2 , gzip ; q=0.1", "br"},
+		{"*", "br"},
+		{"*;q=0", ""},
+		{"br;q=0, *", "gzip"},
+		{"gzip;q=0, *;q=0.5", "br"},
+		{"deflate", ""},
+		{"br;q=bogus, gzip", "gzip"},
+	}
+
+	for _, tt := range tests {
+		if got := negotiateEncoding(tt.accept); got != tt.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	const etag = `"abc-gzip"`
+	tests := []struct {
+		ifNoneMatch string
+		want        bool
+	}{
+		{"", false},
+		{`"abc-gzip"`, true},
+		{`W/"abc-gzip"`, true},
+		{`"other", "abc-gzip"`, true},
+		{"*", true},
+		{`"abc"`, false},
+		{`"abc-br"`, false},
+		{`abc-gzip`, false},
+	}
+
+	for _, tt := range tests {
+		if got := etagMatches(tt.ifNoneMatch, etag); got != tt.want {
+			t.Errorf("etagMatches(%q) = %v, want %v", tt.ifNoneMatch, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// backend/internal/middleware/clientip_test.go
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func useTrustedProxies(t *testing.T, proxies ...string) {
+	t.Helper()
+	if err := UseTrustedProxies(proxies); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { trustedProxies = nil })
+}
+
+func TestClientIP(t *testing.T) {
+	useTrustedProxies(t, "10.0.0.0/8", "192.0.2.1", "fd00::/8")
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded []string
+		want      string
+	}{
+		{"direct client", "203.0.113.7:5123", nil, "203.0.113.7"},
+		{"untrusted peer cannot spoof", "203.0.113.7:5123", []string{"1.2.3.4"}, "203.0.113.7"},
+		{"trusted proxy", "10.1.2.3:80", []string{"198.51.100.9"}, "198.51.100.9"},
+		{"client-supplied hops are skipped", "10.1.2.3:80", []string{"1.2.3.4, 198.51.100.9"}, "198.51.100.9"},
+		{"chain of trusted proxies", "10.1.2.3:80", []string{"198.51.100.9, 192.0.2.1, 10.9.9.9"}, "198.51.100.9"},
+		{"several header lines", "10.1.2.3:80", []string{"1.2.3.4", "198.51.100.9, 10.9.9.9"}, "198.51.100.9"},
+		{"garbage hop stops the walk", "10.1.2.3:80", []string{"198.51.100.9, nonsense, 10.9.9.9"}, "10.9.9.9"},
+		{"only trusted hops", "10.1.2.3:80", []string{"10.9.9.9"}, "10.9.9.9"},
+		{"trusted proxy without header", "10.1.2.3:80", nil, "10.1.2.3"},
+		{"IPv6 proxy", "[fd00::1]:443", []string{"2001:db8::5"}, "2001:db8::5"},
+		{"IPv4-mapped peer", "[::ffff:10.1.2.3]:80", []string{"198.51.100.9"}, "198.51.100.9"},
+		{"remote without port", "203.0.113.7", nil, "203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remote
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			if got := ClientIP(r); got != tt.want {
+				t.Errorf("ClientIP = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientIPIgnoresForwardedForWithoutTrustedProxies(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.1.2.3:80"
+	r.Header.Set("X-Forwarded-For", "1.2.3.4")
+
+	if got := ClientIP(r); got != "10.1.2.3" {
+		t.Errorf("ClientIP = %s, want the connection address", got)
+	}
+}
+
+func TestUseTrustedProxiesRejectsInvalidEntries(t *testing.T) {
+	t.Cleanup(func() { trustedProxies = nil })
+	for _, proxy := range []string{"10.0.0.0/33", "example.com", ""} {
+		if err := UseTrustedProxies([]string{proxy}); err == nil {
+			t.Errorf("UseTrustedProxies accepted %q", proxy)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+// backend/internal/middleware/cors.go
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/idempotency"
+)
+
+// CORSPolicy describes which cross-origin callers may use a group of routes.
+type CORSPolicy struct {
+	// AllowedOrigins lists exact origins such as https://craftora.in; "*"
+	// allows any origin and cannot be combined with credentials.
+	AllowedOrigins   []string
+	AllowCredentials bool
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	// MaxAge is how long browsers may cache a preflight response.
+	MaxAge time.Duration
+}
+
+// CORSRule applies Policy to requests whose path is one of Paths (or below
+// it) and whose method is one of Methods. An empty Methods matches any method.
+type CORSRule struct {
+	Name    string
+	Paths   []string
+	Methods []string
+	Policy  CORSPolicy
+}
+
+// CORSConfig is the full policy: the first matching rule wins and Default
+// covers everything else.
+type CORSConfig struct {
+	Rules   []CORSRule
+	Default CORSPolicy
+}
+
+var (
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	defaultCORSHeaders = []string{"Content-Type", "Authorization", RequestIDHeader, idempotency.Header, "If-None-Match",
+		"traceparent", "tracestate"}
+	defaultCORSExposed = []string{RequestIDHeader, "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "Idempotent-Replayed",
+		"Deprecation", "Sunset", "Link", "ETag"}
+)
+
+// NewCORSConfig assembles Craftora's route groups: the read-only catalog is
+// open to publicOrigins, the admin API is limited to adminOrigins and the
+// rest of the API to origins.
+func NewCORSConfig(origins, adminOrigins, publicOrigins []string, credentials bool, maxAge time.Duration) (CORSConfig, error) {
+	base := CORSPolicy{
+		AllowedOrigins:   origins,
+		AllowCredentials: credentials,
+		AllowedMethods:   defaultCORSMethods,
+		AllowedHeaders:   defaultCORSHeaders,
+		ExposedHeaders:   defaultCORSExposed,
+		MaxAge:           maxAge,
+	}
+
+	admin := base
+	admin.AllowedOrigins = adminOrigins
+
+	public := base
+	public.AllowedOrigins = publicOrigins
+	public.AllowCredentials = false
+	public.AllowedMethods = []string{"GET", "HEAD", "OPTIONS"}
+
+	cfg := CORSConfig{
+		Rules: []CORSRule{
+			{Name: "admin", Paths: []string{"/api/admin"}, Policy: admin},
+			{
+				Name: "public catalog",
+				Paths: []string{"/api/products", "/api/categories", "/api/artisans", "/.well-known",
+					"/api/openapi.json", "/api/docs"},
+				Methods: []string{"GET", "HEAD"},
+				Policy:  public,
+			},
+		},
+		Default: base,
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return CORSConfig{}, err
+	}
+	return cfg, nil
+}
+
+// Validate rejects policies browsers would refuse or that would expose
+// authenticated routes to every site.
+func (c CORSConfig) Validate() error {
+	check := func(name string, p CORSPolicy) error {
+		for _, origin := range p.AllowedOrigins {
+			if origin == "*" {
+				if p.AllowCredentials {
+					return fmt.Errorf("cors %s: wildcard origin cannot be combined with credentials", name)
+				}
+				continue
+			}
+			if !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+				return fmt.Errorf("cors %s: origin %q must include the scheme", name, origin)
+			}
+			if strings.HasSuffix(origin, "/") {
+				return fmt.Errorf("cors %s: origin %q must not have a trailing slash", name, origin)
+			}
+		}
+		return nil
+	}
+
+	for _, rule := range c.Rules {
+		if err := check(rule.Name, rule.Policy); err != nil {
+			return err
+		}
+		if rule.Name == "admin" && allowsAnyOrigin(rule.Policy) {
+			return fmt.Errorf("cors admin: wildcard origin is not allowed on admin routes")
+		}
+	}
+	return check("default", c.Default)
+}
+
+// CORS answers preflight requests and adds CORS headers to responses
+// according to the policy for the route group the request falls in.
+func CORS(cfg CORSConfig, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Responses differ by origin, so shared caches must key on it.
+		w.Header().Add("Vary", "Origin")
+
+		origin := r.Header.Get("Origin")
+		requestMethod := r.Header.Get("Access-Control-Request-Method")
+		preflight := r.Method == http.MethodOptions && origin != "" && requestMethod != ""
+
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		method := r.Method
+		if preflight {
+			method = requestMethod
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		policy := cfg.policyFor(r.URL.Path, method)
+		allowed, wildcard := policy.allows(origin)
+		if allowed {
+			if wildcard && !policy.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			if policy.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+		}
+
+		if preflight {
+			if allowed && containsFold(policy.AllowedMethods, requestMethod) {
+				w.Header().Set("Access-Control-Allow-Methods", strings.Join(policy.AllowedMethods, ", "))
+				w.Header().Set("Access-Control-Allow-Headers", strings.Join(policy.AllowedHeaders, ", "))
+				if policy.MaxAge > 0 {
+					w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(policy.MaxAge.Seconds())))
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if allowed && len(policy.ExposedHeaders) > 0 {
+			w.Header().Set("Access-Control-Expose-Headers", strings.Join(policy.ExposedHeaders, ", "))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// policyFor picks the policy for a request. Versioned paths match the rules
+// for their unversioned form, so /api/v1/admin is still an admin route.
+func (c CORSConfig) policyFor(path, method string) CORSPolicy {
+	path = unversionedPath(path)
+	for _, rule := range c.Rules {
+		if len(rule.Methods) > 0 && !containsFold(rule.Methods, method) {
+			continue
+		}
+		for _, prefix := range rule.Paths {
+			if path == prefix || strings.HasPrefix(path, prefix+"/") {
+				return rule.Policy
+			}
+		}
+	}
+	return c.Default
+}
+
+// unversionedPath turns /api/v<N>/... into /api/....
+func unversionedPath(path string) string {
+	rest, ok := strings.CutPrefix(path, "/api/v")
+	if !ok {
+		return path
+	}
+	version, tail, _ := strings.Cut(rest, "/")
+	if version == "" || strings.Trim(version, "0123456789") != "" {
+		return path
+	}
+	return "/api/" + tail
+}
+
+// allows reports whether origin may call routes under p, and whether that is
+// only because p allows any origin.
+func (p CORSPolicy) allows(origin string) (allowed bool, wildcard bool) {
+	for _, o := range p.AllowedOrigins {
+		if o == origin {
+			return true, false
+		}
+	}
+	if allowsAnyOrigin(p) {
+		return true, true
+	}
+	return false, false
+}
+
+func allowsAnyOrigin(p CORSPolicy) bool {
+	for _, o := range p.AllowedOrigins {
+		if o == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+// backend/internal/middleware/cors_test.go
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	appOrigin   = "https://craftora.in"
+	adminOrigin = "https://admin.craftora.in"
+	otherOrigin = "https://shop.example.com"
+)
+
+func testCORS(t *testing.T) http.Handler {
+	t.Helper()
+	cfg, err := NewCORSConfig([]string{appOrigin}, []string{adminOrigin}, []string{"*"}, true, 10*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return CORS(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func corsRequest(handler http.Handler, method, path, origin, preflightMethod string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, nil)
+	if origin != "" {
+		r.Header.Set("Origin", origin)
+	}
+	if preflightMethod != "" {
+		r.Header.Set("Access-Control-Request-Method", preflightMethod)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	return rec
+}
+
+func TestCORSRouteGroups(t *testing.T) {
+	handler := testCORS(t)
+
+	tests := []struct {
+		name            string
+		method, path    string
+		origin          string
+		wantOrigin      string
+		wantCredentials bool
+	}{
+		{"app origin on the API", "POST", "/api/orders", appOrigin, appOrigin, true},
+		{"unknown origin on the API", "POST", "/api/orders", otherOrigin, "", false},
+		{"admin origin on admin routes", "GET", "/api/admin/orders", adminOrigin, adminOrigin, true},
+		{"app origin on admin routes", "GET", "/api/admin/orders", appOrigin, "", false},
+		{"app origin on versioned admin routes", "GET", "/api/v1/admin/orders", appOrigin, "", false},
+		{"admin origin on versioned admin routes", "GET", "/api/v1/admin/orders", adminOrigin, adminOrigin, true},
+		{"admin origin outside admin routes", "POST", "/api/orders", adminOrigin, "", false},
+		{"any origin reads the catalog", "GET", "/api/products/3", otherOrigin, "*", false},
+		{"any origin reads the versioned catalog", "GET", "/api/v2/products", otherOrigin, "*", false},
+		{"catalog reads never carry credentials", "GET", "/api/products", appOrigin, "*", false},
+		{"catalog writes use the API policy", "POST", "/api/products", otherOrigin, "", false},
+		{"catalog prefix does not match lookalikes", "GET", "/api/productsx", otherOrigin, "", false},
+		{"version-like segment that is not a version", "GET", "/api/vx/admin", appOrigin, appOrigin, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := corsRequest(handler, tt.method, tt.path, tt.origin, "")
+
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Credentials") == "true"; got != tt.wantCredentials {
+				t.Errorf("Allow-Credentials = %v, want %v", got, tt.wantCredentials)
+			}
+			if exposed := rec.Header().Get("Access-Control-Expose-Headers"); (tt.wantOrigin != "") != strings.Contains(exposed, "Retry-After") {
+				t.Errorf("Expose-Headers = %q for an origin that is allowed: %v", exposed, tt.wantOrigin != "")
+			}
+			if rec.Code != http.StatusOK {
+				t.Errorf("status %d; CORS must leave actual requests to the handler", rec.Code)
+			}
+		})
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	handler := testCORS(t)
+
+	tests := []struct {
+		name        string
+		path        string
+		origin      string
+		method      string
+		wantAllowed bool
+	}{
+		{"allowed origin and method", "/api/orders", appOrigin, "POST", true},
+		{"disallowed origin", "/api/orders", otherOrigin, "POST", false},
+		{"public catalog read", "/api/products", otherOrigin, "GET", true},
+		{"public catalog write", "/api/products", otherOrigin, "POST", false},
+		{"method outside the policy", "/api/orders", appOrigin, "PATCH", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := corsRequest(handler, http.MethodOptions, tt.path, tt.origin, tt.method)
+
+			if rec.Code != http.StatusNoContent {
+				t.Errorf("preflight status %d, want 204", rec.Code)
+			}
+			vary := rec.Header().Values("Vary")
+			for _, want := range []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"} {
+				if !containsFold(vary, want) {
+					t.Errorf("Vary = %v, missing %s", vary, want)
+				}
+			}
+			methods := rec.Header().Get("Access-Control-Allow-Methods")
+			maxAge := rec.Header().Get("Access-Control-Max-Age")
+			if tt.wantAllowed {
+				if !strings.Contains(methods, tt.method) || maxAge != "600" {
+					t.Errorf("Allow-Methods %q, Max-Age %q; want %s allowed for 600s", methods, maxAge, tt.method)
+				}
+			} else if methods != "" || maxAge != "" {
+				t.Errorf("a refused preflight got Allow-Methods %q, Max-Age %q", methods, maxAge)
+			}
+		})
+	}
+}
+
+func TestCORSWithoutOrigin(t *testing.T) {
+	rec := corsRequest(testCORS(t), "GET", "/api/orders", "", "")
+
+	if rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("same-origin requests must not get CORS headers")
+	}
+	if rec.Header().Get("Vary") != "Origin" {
+		t.Errorf("Vary = %q, want Origin so caches don't serve it cross-origin", rec.Header().Get("Vary"))
+	}
+}
+
+func TestNewCORSConfigRejects(t *testing.T) {
+	tests := []struct {
+		name                string
+		origins, admin, pub []string
+		credentials         bool
+		want                string
+	}{
+		{"wildcard with credentials", []string{"*"}, []string{adminOrigin}, nil, true, "credentials"},
+		{"wildcard on admin routes", []string{appOrigin}, []string{"*"}, nil, false, "admin"},
+		{"origin without a scheme", []string{"craftora.in"}, []string{adminOrigin}, nil, false, "scheme"},
+		{"origin with a trailing slash", []string{appOrigin + "/"}, []string{adminOrigin}, nil, false, "trailing slash"},
+		{"bad public origin", []string{appOrigin}, []string{adminOrigin}, []string{"example.com"}, false, "public catalog"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCORSConfig(tt.origins, tt.admin, tt.pub, tt.credentials, 0)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("NewCORSConfig error = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+
+	// The catalog never sends credentials, so a wildcard there is fine even
+	// when the rest of the API uses them.
+	if _, err := NewCORSConfig([]string{appOrigin}, []string{adminOrigin}, []string{"*"}, true, 0); err != nil {
+		t.Errorf("public wildcard with credentials elsewhere: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+// backend/internal/middleware/decode.go
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"backend/internal/validate"
+)
+
+// MaxBodyBytes caps JSON request bodies.
+const MaxBodyBytes = 1 << 20
+
+// DecodeJSON reads a single JSON object from the request body into dst,
+// rejecting unknown fields and bodies over MaxBodyBytes, then checks dst's
+// validate rules. On failure it writes the error response and returns false.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	return decodeJSON(w, r, dst, false)
+}
+
+// DecodeOptionalJSON is DecodeJSON for endpoints where the body may be
+// omitted entirely, leaving dst at its zero value.
+func DecodeOptionalJSON(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	return decodeJSON(w, r, dst, true)
+}
+
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}, optional bool) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxBodyBytes)
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		if optional && errors.Is(err, io.EOF) {
+			return true
+		}
+		respondDecodeError(w, err)
+		return false
+	}
+	if dec.More() {
+		RespondProblem(w, NewProblem(http.StatusBadRequest, CodeInvalidBody, "Request body must contain a single JSON object"))
+		return false
+	}
+
+	if errs := validate.Struct(dst); len(errs) > 0 {
+		fields := make([]FieldError, len(errs))
+		for i, e := range errs {
+			fields[i] = FieldError{Field: e.Field, Code: e.Code, Message: e.Message}
+		}
+		RespondValidationError(w, fields)
+		return false
+	}
+	return true
+}
+
+func respondDecodeError(w http.ResponseWriter, err error) {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var tooLarge *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &tooLarge):
+		RespondProblem(w, NewProblem(http.StatusRequestEntityTooLarge, CodePayloadTooLarge,
+			fmt.Sprintf("Request body must not exceed %d bytes", tooLarge.Limit)))
+	case errors.Is(err, io.EOF):
+		RespondProblem(w, NewProblem(http.StatusBadRequest, CodeInvalidBody, "Request body is required"))
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		RespondProblem(w, NewProblem(http.StatusBadRequest, CodeInvalidBody, "Invalid request body"))
+	case errors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			RespondProblem(w, NewProblem(http.StatusBadRequest, CodeInvalidBody, "Request body must be a JSON object"))
+			return
+		}
+		RespondValidationError(w, []FieldError{{
+			Field:   field,
+			Code:    FieldInvalid,
+			Message: fmt.Sprintf("%s must be a %s", field, jsonType(typeErr.Type.Kind().String())),
+		}})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		RespondValidationError(w, []FieldError{{
+			Field:   field,
+			Code:    FieldUnknown,
+			Message: "Unknown field " + field,
+		}})
+	default:
+		RespondProblem(w, NewProblem(http.StatusBadRequest, CodeInvalidBody, "Invalid request body"))
+	}
+}
+
+// jsonType names a Go kind the way API clients think of it.
+func jsonType(kind string) string {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"), strings.HasPrefix(kind, "float"):
+		return "number"
+	case kind == "bool":
+		return "boolean"
+	case kind == "slice", kind == "array":
+		return "list"
+	case kind == "struct", kind == "map":
+		return "object"
+	}
+	return kind
+}
@@ -0,0 +1,37 @@
+// backend/internal/middleware/deprecation.go
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Deprecated marks every response from a route that has a replacement with
+// when it was deprecated (RFC 9745), when it will be removed (RFC 8594) and
+// a successor-version link. successor is a path that may use the route's
+// {wildcards}, which are filled in from the request.
+func Deprecated(since, sunset time.Time, successor string, next http.HandlerFunc) http.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(since.Unix(), 10)
+	sunsetDate := sunset.UTC().Format(http.TimeFormat)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("Deprecation", deprecation)
+		h.Set("Sunset", sunsetDate)
+		h.Add("Link", "<"+expandPath(successor, r)+`>; rel="successor-version"`)
+		next(w, r)
+	}
+}
+
+// expandPath replaces each {name} segment of path with r.PathValue(name).
+func expandPath(path string, r *http.Request) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segments[i] = r.PathValue(strings.Trim(segment, "{}"))
+		}
+	}
+	return strings.Join(segments, "/")
+}
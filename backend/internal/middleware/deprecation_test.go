@@ -0,0 +1,72 @@
+// backend/internal/middleware/deprecation_test.go
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDeprecatedHeaders(t *testing.T) {
+	since := time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, time.April, 30, 0, 0, 0, 0, time.FixedZone("IST", 5*3600+1800))
+
+	called := false
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/products/{id}/reviews", Deprecated(since, sunset, "/api/v2/products/{id}/reviews",
+		func(w http.ResponseWriter, r *http.Request) {
+			called = true
+			RespondJSON(w, http.StatusOK, []string{})
+		}))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/products/42/reviews", nil))
+
+	if !called || rec.Code != http.StatusOK {
+		t.Fatalf("handler called = %v, status %d; want the request passed through", called, rec.Code)
+	}
+	tests := []struct{ header, want string }{
+		{"Deprecation", "@1792195200"},
+		{"Sunset", "Thu, 29 Apr 2027 18:30:00 GMT"},
+		{"Link", `</api/v2/products/42/reviews>; rel="successor-version"`},
+	}
+	for _, tt := range tests {
+		if got := rec.Header().Get(tt.header); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestDeprecatedKeepsExistingLinks(t *testing.T) {
+	handler := Deprecated(time.Unix(0, 0), time.Unix(0, 0), "/api/v2/orders", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Link", `</api/openapi.json>; rel="describedby"`)
+	handler(rec, httptest.NewRequest(http.MethodGet, "/api/orders", nil))
+
+	links := rec.Header().Values("Link")
+	if len(links) != 2 || links[1] != `</api/v2/orders>; rel="successor-version"` {
+		t.Errorf("Link = %q, want the existing link followed by the successor", links)
+	}
+}
+
+func TestExpandPath(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.SetPathValue("id", "9")
+	r.SetPathValue("role", "support")
+
+	tests := []struct{ path, want string }{
+		{"/api/v2/orders", "/api/v2/orders"},
+		{"/api/v2/products/{id}/reviews", "/api/v2/products/9/reviews"},
+		{"/api/v2/roles/{role}/users/{id}", "/api/v2/roles/support/users/9"},
+		{"/api/v2/{missing}", "/api/v2/"},
+	}
+	for _, tt := range tests {
+		if got := expandPath(tt.path, r); got != tt.want {
+			t.Errorf("expandPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// backend/internal/middleware/idempotency.go
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"backend/internal/idempotency"
+)
+
+// IdempotencyStore remembers idempotency keys and the responses they produced.
+type IdempotencyStore interface {
+	Begin(ctx context.Context, userID int, key, fingerprint string) (int, *idempotency.Response, error)
+	Complete(ctx context.Context, id int, resp idempotency.Response) error
+	Release(ctx context.Context, id int) error
+}
+
+var idempotencyKeys IdempotencyStore
+
+// UseIdempotency sets where Idempotent stores keys and responses.
+func UseIdempotency(store IdempotencyStore) {
+	idempotencyKeys = store
+}
+
+// Idempotent makes a request safe to retry when the client sends an
+// Idempotency-Key header: the first response is stored and replayed for
+// repeats of the same request, and reusing the key for a different request
+// is rejected with 409. Requests without the header are unaffected. It must
+// be wrapped by Auth, since keys are scoped to the user, and should wrap any
+// rate limit so replays don't spend the client's tokens.
+func Idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotency.Header)
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > 255 {
+			RespondError(w, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+			return
+		}
+
+		claims, ok := r.Context().Value(UserContextKey).(*Claims)
+		if !ok {
+			RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		if idempotencyKeys == nil {
+			RespondError(w, http.StatusInternalServerError, "Idempotency keys are not configured")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodyBytes))
+		if err != nil {
+			RespondError(w, http.StatusRequestEntityTooLarge, "Request body too large")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		fingerprint := idempotency.Fingerprint(r.Method, r.URL.Path, body)
+		id, stored, err := idempotencyKeys.Begin(r.Context(), claims.UserID, key, fingerprint)
+		switch {
+		case errors.Is(err, idempotency.ErrFingerprintMismatch):
+			RespondProblem(w, NewProblem(http.StatusConflict, CodeIdempotencyMismatch, "Idempotency-Key was already used for a different request"))
+			return
+		case errors.Is(err, idempotency.ErrInProgress):
+			w.Header().Set("Retry-After", "1")
+			RespondProblem(w, NewProblem(http.StatusConflict, CodeIdempotencyPending, "A request with this Idempotency-Key is still being processed"))
+			return
+		case err != nil:
+			Logger(r.Context()).Error("Failed to check idempotency key", "error", err)
+			RespondError(w, http.StatusInternalServerError, "Failed to check idempotency key")
+			return
+		}
+
+		if stored != nil {
+			if stored.ContentType != "" {
+				w.Header().Set("Content-Type", stored.ContentType)
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(stored.StatusCode)
+			w.Write(stored.Body)
+			return
+		}
+
+		rec := &responseCapture{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+
+		// The client may have given up waiting; the outcome must still be
+		// recorded so its retry gets this response. Server errors and rate
+		// limits are released instead, since a retry may well succeed.
+		ctx := context.WithoutCancel(r.Context())
+		if rec.status >= http.StatusInternalServerError || rec.status == http.StatusTooManyRequests {
+			if err := idempotencyKeys.Release(ctx, id); err != nil {
+				Logger(r.Context()).Error("Failed to release idempotency key", "error", err)
+			}
+			return
+		}
+		resp := idempotency.Response{
+			StatusCode:  rec.status,
+			ContentType: rec.Header().Get("Content-Type"),
+			Body:        rec.body.Bytes(),
+		}
+		if err := idempotencyKeys.Complete(ctx, id, resp); err != nil {
+			Logger(r.Context()).Error("Failed to store idempotent response", "error", err)
+		}
+	}
+}
+
+// responseCapture passes a response through while keeping a copy of it.
+type responseCapture struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (c *responseCapture) WriteHeader(status int) {
+	if !c.wroteHeader {
+		c.status = status
+		c.wroteHeader = true
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *responseCapture) Write(b []byte) (int, error) {
+	c.wroteHeader = true
+	c.body.Write(b)
+	return c.ResponseWriter.Write(b)
+}
+
+func (c *responseCapture) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
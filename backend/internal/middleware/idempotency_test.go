@@ -0,0 +1,245 @@
+// backend/internal/middleware/idempotency_test.go
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend/internal/idempotency"
+)
+
+// memoryIdempotency mirrors idempotency.Store's outcomes in memory.
+type memoryIdempotency struct {
+	records map[string]*memoryRecord
+	nextID  int
+}
+
+type memoryRecord struct {
+	id          int
+	key         string
+	fingerprint string
+	response    *idempotency.Response
+}
+
+func (m *memoryIdempotency) Begin(ctx context.Context, userID int, key, fingerprint string) (int, *idempotency.Response, error) {
+	scoped := fmt.Sprintf("%d:%s", userID, key)
+	if rec, ok := m.records[scoped]; ok {
+		switch {
+		case rec.fingerprint != fingerprint:
+			return 0, nil, idempotency.ErrFingerprintMismatch
+		case rec.response == nil:
+			return 0, nil, idempotency.ErrInProgress
+		default:
+			return 0, rec.response, nil
+		}
+	}
+	m.nextID++
+	m.records[scoped] = &memoryRecord{id: m.nextID, key: scoped, fingerprint: fingerprint}
+	return m.nextID, nil, nil
+}
+
+func (m *memoryIdempotency) find(id int) *memoryRecord {
+	for _, rec := range m.records {
+		if rec.id == id {
+			return rec
+		}
+	}
+	return nil
+}
+
+func (m *memoryIdempotency) Complete(ctx context.Context, id int, resp idempotency.Response) error {
+	m.find(id).response = &resp
+	return nil
+}
+
+func (m *memoryIdempotency) Release(ctx context.Context, id int) error {
+	delete(m.records, m.find(id).key)
+	return nil
+}
+
+func useMemoryIdempotency(t *testing.T) *memoryIdempotency {
+	store := &memoryIdempotency{records: map[string]*memoryRecord{}}
+	UseIdempotency(store)
+	t.Cleanup(func() { UseIdempotency(nil) })
+	return store
+}
+
+// idempotentHandler counts calls and answers 201, or the status in the body.
+func idempotentHandler(calls *int) http.HandlerFunc {
+	return Idempotent(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		var req struct {
+			Status int `json:"status"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Status == 0 {
+			req.Status = http.StatusCreated
+		}
+		RespondJSON(w, req.Status, map[string]int{"call": *calls})
+	})
+}
+
+func idempotentRequest(handler http.HandlerFunc, userID int, key, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/api/orders", strings.NewReader(body))
+	if key != "" {
+		r.Header.Set(idempotency.Header, key)
+	}
+	if userID != 0 {
+		r = r.WithContext(context.WithValue(r.Context(), UserContextKey, &Claims{UserID: userID}))
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, r)
+	return rec
+}
+
+func problemCode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var p Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatalf("response is not a problem: %s", rec.Body)
+	}
+	return p.Code
+}
+
+func TestIdempotentReplaysTheFirstResponse(t *testing.T) {
+	useMemoryIdempotency(t)
+	calls := 0
+	handler := idempotentHandler(&calls)
+
+	first := idempotentRequest(handler, 1, "k", `{}`)
+	second := idempotentRequest(handler, 1, "k", `{}`)
+
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+	if second.Code != first.Code || second.Body.String() != first.Body.String() {
+		t.Errorf("replay = %d %s, want %d %s", second.Code, second.Body, first.Code, first.Body)
+	}
+	if second.Header().Get("Idempotent-Replayed") != "true" || first.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("Idempotent-Replayed must be set on the replay only")
+	}
+	if second.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("replayed Content-Type = %q", second.Header().Get("Content-Type"))
+	}
+}
+
+func TestIdempotentRejectsAKeyReusedForAnotherRequest(t *testing.T) {
+	useMemoryIdempotency(t)
+	calls := 0
+	handler := idempotentHandler(&calls)
+
+	idempotentRequest(handler, 1, "k", `{"product_id":1}`)
+	rec := idempotentRequest(handler, 1, "k", `{"product_id":2}`)
+
+	if rec.Code != http.StatusConflict || problemCode(t, rec) != CodeIdempotencyMismatch {
+		t.Errorf("reused key: %d %s, want 409 %s", rec.Code, rec.Body, CodeIdempotencyMismatch)
+	}
+	if calls != 1 {
+		t.Errorf("handler ran %d times, want 1", calls)
+	}
+}
+
+func TestIdempotentRejectsARequestStillInProgress(t *testing.T) {
+	store := useMemoryIdempotency(t)
+	calls := 0
+	handler := idempotentHandler(&calls)
+
+	// Claim the key as if another instance were still running the request.
+	if _, _, err := store.Begin(context.Background(), 1, "k", idempotency.Fingerprint(http.MethodPost, "/api/orders", []byte(`{}`))); err != nil {
+		t.Fatal(err)
+	}
+	rec := idempotentRequest(handler, 1, "k", `{}`)
+
+	if rec.Code != http.StatusConflict || problemCode(t, rec) != CodeIdempotencyPending {
+		t.Errorf("in-progress key: %d %s, want 409 %s", rec.Code, rec.Body, CodeIdempotencyPending)
+	}
+	if rec.Header().Get("Retry-After") != "1" {
+		t.Errorf("Retry-After = %q, want 1", rec.Header().Get("Retry-After"))
+	}
+	if calls != 0 {
+		t.Errorf("handler ran %d times, want 0", calls)
+	}
+}
+
+func TestIdempotentReleasesTheKeyAfterAServerError(t *testing.T) {
+	useMemoryIdempotency(t)
+	calls := 0
+	handler := idempotentHandler(&calls)
+
+	failed := idempotentRequest(handler, 1, "k", `{"status":503}`)
+	retried := idempotentRequest(handler, 1, "k", `{"status":503}`)
+
+	if failed.Code != http.StatusServiceUnavailable || retried.Code != http.StatusServiceUnavailable || calls != 2 {
+		t.Errorf("5xx must not be stored: statuses %d, %d after %d calls", failed.Code, retried.Code, calls)
+	}
+}
+
+func TestIdempotentReleasesTheKeyWhenRateLimited(t *testing.T) {
+	useMemoryIdempotency(t)
+	calls := 0
+	handler := idempotentHandler(&calls)
+
+	idempotentRequest(handler, 1, "k", `{"status":429}`)
+	rec := idempotentRequest(handler, 1, "k", `{"status":429}`)
+
+	if rec.Header().Get("Idempotent-Replayed") != "" || calls != 2 {
+		t.Error("a rate-limited response was stored and replayed")
+	}
+}
+
+func TestIdempotentStoresClientErrors(t *testing.T) {
+	useMemoryIdempotency(t)
+	calls := 0
+	handler := idempotentHandler(&calls)
+
+	idempotentRequest(handler, 1, "k", `{"status":422}`)
+	rec := idempotentRequest(handler, 1, "k", `{"status":422}`)
+
+	if rec.Code != http.StatusUnprocessableEntity || calls != 1 {
+		t.Errorf("4xx replay: status %d after %d calls, want 422 after 1", rec.Code, calls)
+	}
+}
+
+func TestIdempotentScopesKeysToTheUser(t *testing.T) {
+	useMemoryIdempotency(t)
+	calls := 0
+	handler := idempotentHandler(&calls)
+
+	idempotentRequest(handler, 1, "k", `{}`)
+	rec := idempotentRequest(handler, 2, "k", `{}`)
+
+	if rec.Header().Get("Idempotent-Replayed") != "" || calls != 2 {
+		t.Error("one user's key replayed another user's response")
+	}
+}
+
+func TestIdempotentRequestValidation(t *testing.T) {
+	useMemoryIdempotency(t)
+	calls := 0
+	handler := idempotentHandler(&calls)
+
+	tests := []struct {
+		name   string
+		userID int
+		key    string
+		want   int
+	}{
+		{"no key skips the store", 1, "", http.StatusCreated},
+		{"key too long", 1, strings.Repeat("k", 256), http.StatusBadRequest},
+		{"key longest allowed", 1, strings.Repeat("k", 255), http.StatusCreated},
+		{"no authenticated user", 0, "k", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := idempotentRequest(handler, tt.userID, tt.key, `{}`); rec.Code != tt.want {
+				t.Errorf("status %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
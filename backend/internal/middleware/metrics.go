@@ -0,0 +1,24 @@
+// backend/internal/middleware/metrics.go
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"backend/internal/metrics"
+)
+
+// Metrics records latency and status for every request, labelled with the
+// mux route pattern rather than the raw path to keep cardinality bounded.
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		done := metrics.RequestStarted()
+		defer done()
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		metrics.ObserveRequest(r.Method, routeOf(r), rec.status, time.Since(start))
+	})
+}
@@ -3,40 +3,82 @@ package middleware
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
-	"os"
 	"strings"
 
+	"backend/internal/apikey"
 	"backend/internal/models"
+	"backend/internal/token"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type contextKey string
 
-const UserContextKey contextKey = "user"
+const (
+	UserContextKey  contextKey = "user"
+	scopeContextKey contextKey = "api_key_scope"
+)
 
 type Claims struct {
-	UserID int             `json:"user_id"`
-	Email  string          `json:"email"`
-	Role   models.UserRole `json:"role"`
+	UserID        int             `json:"user_id"`
+	Email         string          `json:"email"`
+	Role          models.UserRole `json:"role"`
+	EmailVerified bool            `json:"email_verified"`
+	SessionID     int             `json:"sid"`
+	// APIKeyID and Scopes are set when the request authenticated with an API key.
+	APIKeyID int      `json:"-"`
+	Scopes   []string `json:"-"`
 	jwt.RegisteredClaims
 }
 
-func CORS(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+// SessionChecker reports whether the session an access token was issued for
+// is still active, so revoked sessions are rejected before their tokens expire.
+type SessionChecker interface {
+	IsActive(ctx context.Context, sessionID int) (bool, error)
+}
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
+// TokenVerifier checks a signed token and decodes it into claims.
+type TokenVerifier interface {
+	Parse(tokenString string, claims jwt.Claims, opts ...jwt.ParserOption) error
+}
+
+// APIKeyVerifier resolves an API key to the key itself and the account it acts for.
+type APIKeyVerifier interface {
+	Verify(ctx context.Context, plaintext string) (*apikey.Key, *apikey.Owner, error)
+}
+
+// PermissionChecker reports whether a role has been granted a permission.
+type PermissionChecker interface {
+	HasPermission(ctx context.Context, role models.UserRole, permission string) (bool, error)
+}
+
+var (
+	sessions    SessionChecker
+	tokens      TokenVerifier
+	apiKeys     APIKeyVerifier
+	permissions PermissionChecker
+)
+
+// UseSessions makes Auth reject tokens whose session has been revoked.
+func UseSessions(checker SessionChecker) {
+	sessions = checker
+}
+
+// UseTokens sets the verifier Auth checks access tokens with.
+func UseTokens(verifier TokenVerifier) {
+	tokens = verifier
+}
 
-		next.ServeHTTP(w, r)
-	})
+// UseAPIKeys lets Auth accept API keys on routes wrapped with RequireScope.
+func UseAPIKeys(verifier APIKeyVerifier) {
+	apiKeys = verifier
+}
+
+// UsePermissions sets where RequirePermission looks up role grants.
+func UsePermissions(checker PermissionChecker) {
+	permissions = checker
 }
 
 func Auth(next http.HandlerFunc) http.HandlerFunc {
@@ -53,42 +95,123 @@ func Auth(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method")
-			}
-			return []byte(getJWTSecret()), nil
-		})
+		if strings.HasPrefix(tokenString, apikey.Prefix) {
+			authenticateAPIKey(w, r, tokenString, next)
+			return
+		}
+
+		if tokens == nil {
+			RespondError(w, http.StatusInternalServerError, "Token verification is not configured")
+			return
+		}
 
-		if err != nil || !token.Valid {
+		claims := &Claims{}
+		if err := tokens.Parse(tokenString, claims, jwt.WithAudience(token.AccessAudience)); err != nil {
 			RespondError(w, http.StatusUnauthorized, "Invalid token")
 			return
 		}
 
+		if sessions != nil {
+			active, err := sessions.IsActive(r.Context(), claims.SessionID)
+			if err != nil {
+				RespondError(w, http.StatusInternalServerError, "Failed to verify session")
+				return
+			}
+			if !active {
+				RespondError(w, http.StatusUnauthorized, "Session has been revoked")
+				return
+			}
+		}
+
+		setRequestUser(r.Context(), claims.UserID)
 		ctx := context.WithValue(r.Context(), UserContextKey, claims)
 		next(w, r.WithContext(ctx))
 	}
 }
 
-func ArtisanOnly(next http.HandlerFunc) http.HandlerFunc {
+// RequireScope opens a route to API keys that carry scope. Auth rejects API
+// keys on every route not wrapped this way, so it must wrap Auth.
+func RequireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		claims, ok := r.Context().Value(UserContextKey).(*Claims)
-		if !ok {
-			RespondError(w, http.StatusUnauthorized, "Unauthorized")
-			return
-		}
+		ctx := context.WithValue(r.Context(), scopeContextKey, scope)
+		next(w, r.WithContext(ctx))
+	}
+}
 
-		if claims.Role != models.RoleArtisan && claims.Role != models.RoleAdmin {
-			RespondError(w, http.StatusForbidden, "Artisan access required")
-			return
-		}
+func authenticateAPIKey(w http.ResponseWriter, r *http.Request, plaintext string, next http.HandlerFunc) {
+	scope, _ := r.Context().Value(scopeContextKey).(string)
+	if scope == "" {
+		RespondError(w, http.StatusForbidden, "API keys cannot access this route")
+		return
+	}
 
-		next(w, r)
+	if apiKeys == nil {
+		RespondError(w, http.StatusInternalServerError, "API keys are not configured")
+		return
+	}
+
+	key, owner, err := apiKeys.Verify(r.Context(), plaintext)
+	if errors.Is(err, apikey.ErrInvalidKey) {
+		RespondError(w, http.StatusUnauthorized, "Invalid API key")
+		return
 	}
+	if err != nil {
+		RespondError(w, http.StatusInternalServerError, "Failed to verify API key")
+		return
+	}
+
+	if !key.HasScope(scope) {
+		RespondError(w, http.StatusForbidden, "API key is missing the "+scope+" scope")
+		return
+	}
+
+	claims := &Claims{
+		UserID:        owner.UserID,
+		Email:         owner.Email,
+		Role:          owner.Role,
+		EmailVerified: owner.EmailVerified,
+		APIKeyID:      key.ID,
+		Scopes:        key.Scopes,
+	}
+	setRequestUser(r.Context(), claims.UserID)
+	ctx := context.WithValue(r.Context(), UserContextKey, claims)
+	next(w, r.WithContext(ctx))
 }
 
-func AdminOnly(next http.HandlerFunc) http.HandlerFunc {
+// RequirePermission only lets through users whose role has been granted
+// permission. It must be wrapped by Auth.
+func RequirePermission(permission string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := r.Context().Value(UserContextKey).(*Claims)
+			if !ok {
+				RespondError(w, http.StatusUnauthorized, "Unauthorized")
+				return
+			}
+
+			if permissions == nil {
+				RespondError(w, http.StatusInternalServerError, "Permissions are not configured")
+				return
+			}
+
+			allowed, err := permissions.HasPermission(r.Context(), claims.Role, permission)
+			if err != nil {
+				RespondError(w, http.StatusInternalServerError, "Failed to check permissions")
+				return
+			}
+			if !allowed {
+				RespondError(w, http.StatusForbidden, "Missing permission: "+permission)
+				return
+			}
+
+			next(w, r)
+		}
+	}
+}
+
+// VerifiedOnly blocks accounts that have not confirmed their email address yet.
+// It must be wrapped by Auth.
+func VerifiedOnly(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		claims, ok := r.Context().Value(UserContextKey).(*Claims)
 		if !ok {
@@ -96,8 +219,8 @@ func AdminOnly(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		if claims.Role != models.RoleAdmin {
-			RespondError(w, http.StatusForbidden, "Admin access required")
+		if !claims.EmailVerified {
+			RespondError(w, http.StatusForbidden, "Email verification required")
 			return
 		}
 
@@ -105,20 +228,14 @@ func AdminOnly(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-func getJWTSecret() string {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return "your-secret-key-change-in-production"
-	}
-	return secret
-}
-
 func RespondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(data)
 }
 
+// RespondError writes a problem+json error with the generic code for status.
+// Use RespondProblem when the client needs a more specific code.
 func RespondError(w http.ResponseWriter, status int, message string) {
-	RespondJSON(w, status, map[string]string{"error": message})
+	RespondProblem(w, NewProblem(status, codeForStatus(status), message))
 }
@@ -0,0 +1,111 @@
+// backend/internal/middleware/middleware_test.go
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend/internal/apikey"
+	"backend/internal/models"
+)
+
+// fakeAPIKeys accepts the keys it holds; anything else is invalid, as
+// revoked and expired keys are for apikey.Store.
+type fakeAPIKeys struct {
+	keys map[string]*apikey.Key
+	err  error
+}
+
+func (f *fakeAPIKeys) Verify(ctx context.Context, plaintext string) (*apikey.Key, *apikey.Owner, error) {
+	if f.err != nil {
+		return nil, nil, f.err
+	}
+	key, ok := f.keys[plaintext]
+	if !ok {
+		return nil, nil, apikey.ErrInvalidKey
+	}
+	return key, &apikey.Owner{UserID: key.UserID, Email: "artisan@example.com", Role: models.RoleArtisan, EmailVerified: true}, nil
+}
+
+func useAPIKeys(t *testing.T, verifier APIKeyVerifier) {
+	UseAPIKeys(verifier)
+	t.Cleanup(func() { UseAPIKeys(nil) })
+}
+
+func TestAuthWithAPIKeys(t *testing.T) {
+	const (
+		ordersKey   = apikey.Prefix + "orders"
+		productsKey = apikey.Prefix + "products"
+	)
+	useAPIKeys(t, &fakeAPIKeys{keys: map[string]*apikey.Key{
+		ordersKey:   {ID: 1, UserID: 7, Scopes: []string{apikey.ScopeOrdersRead}},
+		productsKey: {ID: 2, UserID: 7, Scopes: []string{apikey.ScopeProductsWrite}},
+	}})
+
+	var got *Claims
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		got = r.Context().Value(UserContextKey).(*Claims)
+		w.WriteHeader(http.StatusNoContent)
+	}
+	scoped := RequireScope(apikey.ScopeOrdersRead, Auth(handler))
+	unscoped := Auth(handler)
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		key     string
+		want    int
+	}{
+		{"key with the route's scope", scoped, ordersKey, http.StatusNoContent},
+		{"key missing the route's scope", scoped, productsKey, http.StatusForbidden},
+		{"revoked, expired or unknown key", scoped, apikey.Prefix + "revoked", http.StatusUnauthorized},
+		{"key on a route without RequireScope", unscoped, ordersKey, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			r := httptest.NewRequest(http.MethodGet, "/api/artisan/orders", nil)
+			r.Header.Set("Authorization", "Bearer "+tt.key)
+			rec := httptest.NewRecorder()
+			tt.handler(rec, r)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want != http.StatusNoContent {
+				if got != nil {
+					t.Error("the handler ran for a rejected key")
+				}
+				return
+			}
+			if got.UserID != 7 || got.APIKeyID != 1 || got.Role != models.RoleArtisan || len(got.Scopes) != 1 {
+				t.Errorf("claims = %+v", got)
+			}
+		})
+	}
+}
+
+func TestAuthWithAPIKeysFailsClosed(t *testing.T) {
+	handler := RequireScope(apikey.ScopeOrdersRead, Auth(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the handler ran")
+	}))
+	request := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/artisan/orders", nil)
+		r.Header.Set("Authorization", "Bearer "+apikey.Prefix+"key")
+		rec := httptest.NewRecorder()
+		handler(rec, r)
+		return rec
+	}
+
+	if rec := request(); rec.Code != http.StatusInternalServerError {
+		t.Errorf("without a key store: status %d, want 500", rec.Code)
+	}
+	useAPIKeys(t, &fakeAPIKeys{err: errors.New("connection refused")})
+	if rec := request(); rec.Code != http.StatusInternalServerError {
+		t.Errorf("when the key store fails: status %d, want 500", rec.Code)
+	}
+}
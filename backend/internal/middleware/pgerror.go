@@ -0,0 +1,107 @@
+// backend/internal/middleware/pgerror.go
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Postgres SQLSTATE codes that mean the client sent something the schema
+// rejects, rather than the server failing.
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgCheckViolation      = "23514"
+	pgNotNullViolation    = "23502"
+	pgStringTooLong       = "22001"
+	pgInvalidText         = "22P02"
+	pgNumericOutOfRange   = "22003"
+)
+
+// constraintFields names the request field and message for constraints
+// clients can trip, keyed by Postgres' constraint name.
+var constraintFields = map[string]FieldError{
+	"users_email_key":                     {Field: "email", Message: "Email already exists"},
+	"artisans_user_id_key":                {Field: "user_id", Message: "Artisan profile already exists"},
+	"categories_slug_key":                 {Field: "slug", Message: "A category with this slug already exists"},
+	"products_category_id_fkey":           {Field: "category_id", Message: "Category does not exist"},
+	"orders_product_id_fkey":              {Field: "product_id", Message: "Product does not exist"},
+	"reviews_product_id_fkey":             {Field: "product_id", Message: "Product does not exist"},
+	"reviews_order_id_fkey":               {Field: "order_id", Message: "Order does not exist"},
+	"reviews_rating_check":                {Field: "rating", Message: "Rating must be between 1 and 5"},
+	"video_call_requests_artisan_id_fkey": {Field: "artisan_id", Message: "Artisan does not exist"},
+	"video_call_requests_product_id_fkey": {Field: "product_id", Message: "Product does not exist"},
+}
+
+// DBProblem maps a Postgres constraint or data error to the 4xx problem it
+// represents. It returns nil for anything else, which is a server error.
+func DBProblem(err error) *Problem {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return nil
+	}
+
+	field, known := constraintFields[pgErr.ConstraintName]
+	if !known && pgErr.ColumnName != "" {
+		field.Field = pgErr.ColumnName
+	}
+
+	var p *Problem
+	var fieldCode string
+	switch pgErr.Code {
+	case pgUniqueViolation:
+		p = NewProblem(http.StatusConflict, CodeAlreadyExists, "Resource already exists")
+		fieldCode = FieldAlreadyExists
+	case pgForeignKeyViolation:
+		// Deleting a row others still point at is a conflict; pointing at a
+		// row that doesn't exist is bad input.
+		if strings.HasPrefix(pgErr.Message, "update or delete") {
+			return NewProblem(http.StatusConflict, CodeStillReferenced, "Resource is still in use")
+		}
+		p = NewProblem(http.StatusUnprocessableEntity, CodeReferenceNotFound, "Referenced resource does not exist")
+		fieldCode = FieldNotFound
+	case pgCheckViolation:
+		p = NewProblem(http.StatusUnprocessableEntity, CodeConstraintViolation, "Value is not allowed")
+		fieldCode = FieldInvalid
+	case pgNotNullViolation:
+		p = NewProblem(http.StatusUnprocessableEntity, CodeValidationFailed, "A required field is missing")
+		fieldCode = FieldRequired
+	case pgStringTooLong:
+		p = NewProblem(http.StatusUnprocessableEntity, CodeValidationFailed, "A field is too long")
+		fieldCode = FieldTooLong
+	case pgNumericOutOfRange:
+		p = NewProblem(http.StatusUnprocessableEntity, CodeValidationFailed, "A number is out of range")
+		fieldCode = FieldOutOfRange
+	case pgInvalidText:
+		p = NewProblem(http.StatusBadRequest, CodeBadRequest, "A field has an invalid value")
+		fieldCode = FieldInvalid
+	default:
+		return nil
+	}
+
+	if field.Message != "" {
+		p.Detail = field.Message
+	}
+	if field.Field != "" {
+		message := field.Message
+		if message == "" {
+			message = p.Detail
+		}
+		p.WithField(field.Field, fieldCode, message)
+	}
+	return p
+}
+
+// RespondDBError answers with the 4xx a constraint violation maps to, or
+// logs err and answers 500 with message.
+func RespondDBError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	if p := DBProblem(err); p != nil {
+		RespondProblem(w, p)
+		return
+	}
+	Logger(r.Context()).Error(message, "error", err)
+	RespondError(w, http.StatusInternalServerError, message)
+}
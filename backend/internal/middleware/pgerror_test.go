@@ -0,0 +1,133 @@
+// backend/internal/middleware/pgerror_test.go
+package middleware
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestDBProblem(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        *pgconn.PgError
+		wantStatus int
+		wantCode   string
+		wantDetail string
+		wantFields []FieldError
+	}{
+		{
+			name:       "unique violation on a known constraint",
+			err:        &pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"},
+			wantStatus: http.StatusConflict, wantCode: CodeAlreadyExists, wantDetail: "Email already exists",
+			wantFields: []FieldError{{"email", FieldAlreadyExists, "Email already exists"}},
+		},
+		{
+			name:       "unique violation on an unknown constraint",
+			err:        &pgconn.PgError{Code: "23505", ConstraintName: "something_key"},
+			wantStatus: http.StatusConflict, wantCode: CodeAlreadyExists, wantDetail: "Resource already exists",
+		},
+		{
+			name:       "foreign key to a missing row, known constraint",
+			err:        &pgconn.PgError{Code: "23503", ConstraintName: "products_category_id_fkey", Message: `insert or update on table "products" violates foreign key constraint`},
+			wantStatus: http.StatusUnprocessableEntity, wantCode: CodeReferenceNotFound, wantDetail: "Category does not exist",
+			wantFields: []FieldError{{"category_id", FieldNotFound, "Category does not exist"}},
+		},
+		{
+			name:       "foreign key to a missing row, unknown constraint",
+			err:        &pgconn.PgError{Code: "23503", ConstraintName: "other_fkey", Message: `insert or update on table "x" violates foreign key constraint`},
+			wantStatus: http.StatusUnprocessableEntity, wantCode: CodeReferenceNotFound, wantDetail: "Referenced resource does not exist",
+		},
+		{
+			name:       "deleting a row that is still referenced",
+			err:        &pgconn.PgError{Code: "23503", ConstraintName: "orders_product_id_fkey", Message: `update or delete on table "products" violates foreign key constraint`},
+			wantStatus: http.StatusConflict, wantCode: CodeStillReferenced, wantDetail: "Resource is still in use",
+		},
+		{
+			name:       "check violation on a known constraint",
+			err:        &pgconn.PgError{Code: "23514", ConstraintName: "reviews_rating_check"},
+			wantStatus: http.StatusUnprocessableEntity, wantCode: CodeConstraintViolation, wantDetail: "Rating must be between 1 and 5",
+			wantFields: []FieldError{{"rating", FieldInvalid, "Rating must be between 1 and 5"}},
+		},
+		{
+			name:       "check violation on an unknown constraint",
+			err:        &pgconn.PgError{Code: "23514", ConstraintName: "products_price_check"},
+			wantStatus: http.StatusUnprocessableEntity, wantCode: CodeConstraintViolation, wantDetail: "Value is not allowed",
+		},
+		{
+			name:       "not null names the column",
+			err:        &pgconn.PgError{Code: "23502", ColumnName: "name"},
+			wantStatus: http.StatusUnprocessableEntity, wantCode: CodeValidationFailed, wantDetail: "A required field is missing",
+			wantFields: []FieldError{{"name", FieldRequired, "A required field is missing"}},
+		},
+		{
+			name:       "string too long",
+			err:        &pgconn.PgError{Code: "22001"},
+			wantStatus: http.StatusUnprocessableEntity, wantCode: CodeValidationFailed, wantDetail: "A field is too long",
+		},
+		{
+			name:       "numeric out of range",
+			err:        &pgconn.PgError{Code: "22003"},
+			wantStatus: http.StatusUnprocessableEntity, wantCode: CodeValidationFailed, wantDetail: "A number is out of range",
+		},
+		{
+			name:       "invalid text representation",
+			err:        &pgconn.PgError{Code: "22P02"},
+			wantStatus: http.StatusBadRequest, wantCode: CodeBadRequest, wantDetail: "A field has an invalid value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Handlers usually wrap the driver error.
+			p := DBProblem(fmt.Errorf("failed to save: %w", tt.err))
+			if p == nil {
+				t.Fatal("DBProblem = nil, want a client error")
+			}
+			if p.Status != tt.wantStatus || p.Code != tt.wantCode || p.Detail != tt.wantDetail {
+				t.Errorf("DBProblem = %d %s %q, want %d %s %q", p.Status, p.Code, p.Detail, tt.wantStatus, tt.wantCode, tt.wantDetail)
+			}
+			if !reflect.DeepEqual(p.Errors, tt.wantFields) {
+				t.Errorf("field errors = %+v, want %+v", p.Errors, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestDBProblemLeavesServerErrorsAlone(t *testing.T) {
+	for name, err := range map[string]error{
+		"no rows":         sql.ErrNoRows,
+		"plain error":     errors.New("connection reset by peer"),
+		"serialization":   &pgconn.PgError{Code: "40001"},
+		"undefined table": &pgconn.PgError{Code: "42P01"},
+		"nil":             nil,
+	} {
+		if p := DBProblem(err); p != nil {
+			t.Errorf("%s: DBProblem = %+v, want nil", name, p)
+		}
+	}
+}
+
+func TestRespondDBError(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/api/categories", nil)
+
+	rec := httptest.NewRecorder()
+	RespondDBError(rec, r, &pgconn.PgError{Code: "23505", ConstraintName: "categories_slug_key"}, "Failed to create category")
+	body := decodeProblem(t, rec)
+	if rec.Code != http.StatusConflict || body["code"] != CodeAlreadyExists || body["error"] != "A category with this slug already exists" {
+		t.Errorf("constraint violation: %d %v", rec.Code, body)
+	}
+
+	rec = httptest.NewRecorder()
+	RespondDBError(rec, r, sql.ErrNoRows, "Failed to create category")
+	body = decodeProblem(t, rec)
+	if rec.Code != http.StatusInternalServerError || body["code"] != CodeInternal || body["error"] != "Failed to create category" {
+		t.Errorf("server error: %d %v", rec.Code, body)
+	}
+}
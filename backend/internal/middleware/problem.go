@@ -0,0 +1,131 @@
+// backend/internal/middleware/problem.go
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Error codes are part of the API contract: clients switch on them, so
+// existing values must never change meaning.
+const (
+	CodeBadRequest          = "bad_request"
+	CodeInvalidBody         = "invalid_body"
+	CodeValidationFailed    = "validation_failed"
+	CodeUnauthorized        = "unauthorized"
+	CodeForbidden           = "forbidden"
+	CodeNotFound            = "not_found"
+	CodeMethodNotAllowed    = "method_not_allowed"
+	CodeConflict            = "conflict"
+	CodeAlreadyExists       = "already_exists"
+	CodeReferenceNotFound   = "reference_not_found"
+	CodeStillReferenced     = "still_referenced"
+	CodeConstraintViolation = "constraint_violation"
+	CodePayloadTooLarge     = "payload_too_large"
+	CodeRateLimited         = "rate_limited"
+	CodeIdempotencyMismatch = "idempotency_key_reused"
+	CodeIdempotencyPending  = "idempotency_key_in_progress"
+	CodeInternal            = "internal_error"
+	CodeUnavailable         = "service_unavailable"
+)
+
+// Field-level codes used in Problem.Errors.
+const (
+	FieldRequired      = "required"
+	FieldInvalid       = "invalid"
+	FieldTooShort      = "too_short"
+	FieldTooLong       = "too_long"
+	FieldOutOfRange    = "out_of_range"
+	FieldUnknown       = "unknown_field"
+	FieldAlreadyExists = "already_exists"
+	FieldNotFound      = "not_found"
+)
+
+// FieldError describes what is wrong with one request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Problem is an RFC 7807 problem details body. Error repeats Detail for
+// clients written against the original {"error": "..."} responses.
+type Problem struct {
+	Type   string       `json:"type"`
+	Title  string       `json:"title"`
+	Status int          `json:"status"`
+	Detail string       `json:"detail,omitempty"`
+	Code   string       `json:"code"`
+	Errors []FieldError `json:"errors,omitempty"`
+	Error  string       `json:"error"`
+}
+
+// NewProblem builds a problem with a stable code and a human-readable detail.
+func NewProblem(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// WithField adds a field error to the problem.
+func (p *Problem) WithField(field, code, message string) *Problem {
+	p.Errors = append(p.Errors, FieldError{Field: field, Code: code, Message: message})
+	return p
+}
+
+// RespondProblem writes p as application/problem+json.
+func RespondProblem(w http.ResponseWriter, p *Problem) {
+	if p.Detail == "" {
+		p.Detail = p.Title
+	}
+	p.Error = p.Detail
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// RespondValidationError reports invalid request fields. The first field's
+// message doubles as the detail so simple clients can show it directly.
+func RespondValidationError(w http.ResponseWriter, fields []FieldError) {
+	p := NewProblem(http.StatusUnprocessableEntity, CodeValidationFailed, "Request validation failed")
+	if len(fields) > 0 {
+		p.Detail = fields[0].Message
+	}
+	p.Errors = fields
+	RespondProblem(w, p)
+}
+
+// codeForStatus picks the generic code for errors reported through
+// RespondError without a more specific one.
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusUnprocessableEntity:
+		return CodeValidationFailed
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
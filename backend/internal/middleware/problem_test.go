@@ -0,0 +1,110 @@
+// backend/internal/middleware/problem_test.go
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// decodeProblem checks the problem+json framing and returns the raw fields,
+// so tests see exactly what clients receive.
+func decodeProblem(t *testing.T, rec *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+	if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("Content-Type = %q, want application/problem+json", ct)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body is not JSON: %s", rec.Body)
+	}
+	return body
+}
+
+func TestRespondErrorShape(t *testing.T) {
+	rec := httptest.NewRecorder()
+	RespondError(rec, http.StatusNotFound, "Product not found")
+
+	want := map[string]interface{}{
+		"type":   "about:blank",
+		"title":  "Not Found",
+		"status": float64(404),
+		"detail": "Product not found",
+		"code":   CodeNotFound,
+		// Clients written against the original {"error": "..."} bodies
+		// still find the message where they expect it.
+		"error": "Product not found",
+	}
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status %d, want 404", rec.Code)
+	}
+	if got := decodeProblem(t, rec); !reflect.DeepEqual(got, want) {
+		t.Errorf("body = %v, want %v", got, want)
+	}
+}
+
+func TestRespondProblemDefaultsDetailToTitle(t *testing.T) {
+	rec := httptest.NewRecorder()
+	RespondProblem(rec, NewProblem(http.StatusConflict, CodeConflict, ""))
+
+	body := decodeProblem(t, rec)
+	if body["detail"] != "Conflict" || body["error"] != "Conflict" {
+		t.Errorf("detail %v, error %v; want both to fall back to the title", body["detail"], body["error"])
+	}
+	if _, ok := body["errors"]; ok {
+		t.Error("errors must be omitted when there are no field errors")
+	}
+}
+
+func TestRespondValidationError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	RespondValidationError(rec, []FieldError{
+		{Field: "email", Code: FieldRequired, Message: "email is required"},
+		{Field: "password", Code: FieldTooShort, Message: "password must be at least 8 characters"},
+	})
+
+	body := decodeProblem(t, rec)
+	if rec.Code != http.StatusUnprocessableEntity || body["code"] != CodeValidationFailed {
+		t.Errorf("status %d code %v, want 422 %s", rec.Code, body["code"], CodeValidationFailed)
+	}
+	if body["detail"] != "email is required" || body["error"] != "email is required" {
+		t.Errorf("detail %v, error %v; want the first field's message", body["detail"], body["error"])
+	}
+	fields, _ := body["errors"].([]interface{})
+	if len(fields) != 2 {
+		t.Fatalf("errors = %v, want 2 field errors", body["errors"])
+	}
+	first := fields[0].(map[string]interface{})
+	if first["field"] != "email" || first["code"] != FieldRequired || first["message"] != "email is required" {
+		t.Errorf("first field error = %v", first)
+	}
+}
+
+func TestCodeForStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   string
+	}{
+		{http.StatusBadRequest, CodeBadRequest},
+		{http.StatusUnauthorized, CodeUnauthorized},
+		{http.StatusForbidden, CodeForbidden},
+		{http.StatusNotFound, CodeNotFound},
+		{http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+		{http.StatusConflict, CodeConflict},
+		{http.StatusRequestEntityTooLarge, CodePayloadTooLarge},
+		{http.StatusUnprocessableEntity, CodeValidationFailed},
+		{http.StatusTooManyRequests, CodeRateLimited},
+		{http.StatusServiceUnavailable, CodeUnavailable},
+		{http.StatusInternalServerError, CodeInternal},
+		{http.StatusBadGateway, CodeInternal},
+		{http.StatusTeapot, CodeBadRequest},
+	}
+
+	for _, tt := range tests {
+		if got := codeForStatus(tt.status); got != tt.want {
+			t.Errorf("codeForStatus(%d) = %s, want %s", tt.status, got, tt.want)
+		}
+	}
+}
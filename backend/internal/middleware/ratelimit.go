@@ -0,0 +1,69 @@
+// backend/internal/middleware/ratelimit.go
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/internal/ratelimit"
+)
+
+var limiter ratelimit.Store = ratelimit.NewMemoryStore()
+
+// UseRateLimiter replaces the in-memory bucket store, e.g. with one shared
+// by every API instance.
+func UseRateLimiter(store ratelimit.Store) {
+	limiter = store
+}
+
+// RateKey picks the bucket a request is counted against.
+type RateKey func(r *http.Request) string
+
+// ByIP counts requests per client IP.
+func ByIP(r *http.Request) string {
+	return "ip:" + ClientIP(r)
+}
+
+// ByUser counts requests per authenticated user, falling back to the client
+// IP when there is none. Routes using it must be wrapped by Auth.
+func ByUser(r *http.Request) string {
+	if claims, ok := r.Context().Value(UserContextKey).(*Claims); ok {
+		return "user:" + strconv.Itoa(claims.UserID)
+	}
+	return ByIP(r)
+}
+
+// RateLimit allows each key limit requests to the routes in group, answering
+// 429 with Retry-After once the bucket is empty. Routes sharing a group name
+// share buckets.
+func RateLimit(group string, limit ratelimit.Limit, key RateKey) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			result, err := limiter.Take(r.Context(), group+":"+key(r), limit)
+			if err != nil {
+				// Don't take the API down with the limiter's backend.
+				Logger(r.Context()).Error("Rate limiter unavailable", "group", group, "error", err)
+				next(w, r)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit.Burst))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			if !result.Allowed {
+				seconds := int(math.Ceil(result.RetryAfter.Seconds()))
+				if seconds < 1 {
+					seconds = 1
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(seconds))
+				Logger(r.Context()).Warn("Rate limit exceeded", "group", group,
+					"retry_after", time.Duration(seconds)*time.Second)
+				RespondError(w, http.StatusTooManyRequests, "Too many requests, please try again later")
+				return
+			}
+
+			next(w, r)
+		}
+	}
+}
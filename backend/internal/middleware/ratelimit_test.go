@@ -0,0 +1,72 @@
+// backend/internal/middleware/ratelimit_test.go
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend/internal/ratelimit"
+)
+
+func TestRateLimit(t *testing.T) {
+	UseRateLimiter(ratelimit.NewMemoryStore())
+	t.Cleanup(func() { UseRateLimiter(ratelimit.NewMemoryStore()) })
+
+	handler := RateLimit("test", ratelimit.PerMinute(1, 2), ByIP)(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	request := func(remote string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.RemoteAddr = remote
+		rec := httptest.NewRecorder()
+		handler(rec, r)
+		return rec
+	}
+
+	tests := []struct {
+		remote        string
+		wantStatus    int
+		wantRemaining string
+		wantRetry     string
+	}{
+		{"203.0.113.1:1", http.StatusNoContent, "1", ""},
+		{"203.0.113.1:2", http.StatusNoContent, "0", ""},
+		{"203.0.113.1:3", http.StatusTooManyRequests, "0", "60"},
+		{"203.0.113.2:1", http.StatusNoContent, "1", ""},
+	}
+
+	for i, tt := range tests {
+		rec := request(tt.remote)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("request %d from %s: status %d, want %d", i+1, tt.remote, rec.Code, tt.wantStatus)
+		}
+		if got := rec.Header().Get("X-RateLimit-Remaining"); got != tt.wantRemaining {
+			t.Errorf("request %d: X-RateLimit-Remaining = %q, want %q", i+1, got, tt.wantRemaining)
+		}
+		if got := rec.Header().Get("Retry-After"); got != tt.wantRetry {
+			t.Errorf("request %d: Retry-After = %q, want %q", i+1, got, tt.wantRetry)
+		}
+		if got := rec.Header().Get("X-RateLimit-Limit"); got != "2" {
+			t.Errorf("request %d: X-RateLimit-Limit = %q, want 2", i+1, got)
+		}
+	}
+}
+
+// A spoofed X-Forwarded-For from an untrusted client must not buy a fresh bucket.
+func TestRateLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	UseRateLimiter(ratelimit.NewMemoryStore())
+	t.Cleanup(func() { UseRateLimiter(ratelimit.NewMemoryStore()) })
+
+	handler := RateLimit("test", ratelimit.PerMinute(1, 1), ByIP)(func(w http.ResponseWriter, r *http.Request) {})
+	for i, forwarded := range []string{"1.1.1.1", "2.2.2.2"} {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.RemoteAddr = "203.0.113.1:1"
+		r.Header.Set("X-Forwarded-For", forwarded)
+		rec := httptest.NewRecorder()
+		handler(rec, r)
+		if want := []int{http.StatusOK, http.StatusTooManyRequests}[i]; rec.Code != want {
+			t.Errorf("request %d: status %d, want %d", i+1, rec.Code, want)
+		}
+	}
+}
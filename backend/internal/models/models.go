@@ -1,23 +1,42 @@
 // ==================== FILE 1: backend/internal/models/models.go ====================
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type UserRole string
 
 const (
-	RoleBuyer   UserRole = "buyer"
-	RoleArtisan UserRole = "artisan"
-	RoleAdmin   UserRole = "admin"
+	RoleBuyer     UserRole = "buyer"
+	RoleArtisan   UserRole = "artisan"
+	RoleAdmin     UserRole = "admin"
+	RoleSupport   UserRole = "support"
+	RoleModerator UserRole = "moderator"
 )
 
+// Roles lists every role an account can hold.
+var Roles = []UserRole{RoleBuyer, RoleArtisan, RoleAdmin, RoleSupport, RoleModerator}
+
+// Valid reports whether r is one of the known roles.
+func (r UserRole) Valid() bool {
+	for _, role := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
 type User struct {
-	ID           int       `json:"id"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"-"`
-	Name         string    `json:"name"`
-	Role         UserRole  `json:"role"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID            int       `json:"id"`
+	Email         string    `json:"email"`
+	PasswordHash  string    `json:"-"`
+	Name          string    `json:"name"`
+	Role          UserRole  `json:"role"`
+	EmailVerified bool      `json:"email_verified"`
+	CreatedAt     time.Time `json:"created_at"`
 }
 
 type Artisan struct {
@@ -84,6 +103,8 @@ const (
 	OrderCancelled OrderStatus = "cancelled"
 )
 
+// Order.AddressID picks a saved address when placing an order. The address is
+// copied into ShippingDetails so later edits don't change past orders.
 type Order struct {
 	ID              int         `json:"id"`
 	UserID          int         `json:"user_id"`
@@ -93,11 +114,60 @@ type Order struct {
 	TotalAmount     float64     `json:"total_amount"`
 	Status          OrderStatus `json:"status"`
 	ShippingAddress string      `json:"shipping_address"`
+	AddressID       *int        `json:"address_id,omitempty"`
+	ShippingDetails *Address    `json:"shipping_details,omitempty"`
 	EstimatedETA    time.Time   `json:"estimated_eta"`
 	CreatedAt       time.Time   `json:"created_at"`
 	UpdatedAt       time.Time   `json:"updated_at"`
 }
 
+// BuyerOrder is an order as listed for the buyer who placed it.
+type BuyerOrder struct {
+	Order
+	ProductName  string  `json:"product_name"`
+	ProductImage string  `json:"product_image"`
+	ProductPrice float64 `json:"product_price"`
+	ArtisanName  string  `json:"artisan_name"`
+}
+
+type Address struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Name      string    `json:"name"`
+	Line1     string    `json:"line1"`
+	Line2     string    `json:"line2"`
+	City      string    `json:"city"`
+	State     string    `json:"state"`
+	PINCode   string    `json:"pin_code"`
+	Country   string    `json:"country"`
+	Phone     string    `json:"phone"`
+	IsDefault bool      `json:"is_default"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Format renders the address as a postal label, one part per line.
+func (a Address) Format() string {
+	lines := []string{a.Name, a.Line1}
+	if a.Line2 != "" {
+		lines = append(lines, a.Line2)
+	}
+	lines = append(lines, a.City+", "+a.State+" "+a.PINCode, a.Country, "Phone: "+a.Phone)
+	return strings.Join(lines, "\n")
+}
+
+type AddressRequest struct {
+	Name      string `json:"name" validate:"required,max=255"`
+	Line1     string `json:"line1" validate:"required,max=255"`
+	Line2     string `json:"line2" validate:"max=255"`
+	City      string `json:"city" validate:"required,max=100"`
+	State     string `json:"state" validate:"required,max=100"`
+	PINCode   string `json:"pin_code" validate:"required,max=20"`
+	Country   string `json:"country" validate:"required,max=100"`
+	Phone     string `json:"phone" validate:"required,max=20"`
+	IsDefault bool   `json:"is_default"`
+}
+
 type OrderProgress struct {
 	ID          int       `json:"id"`
 	OrderID     int       `json:"order_id"`
@@ -124,28 +194,284 @@ type ReviewWithUser struct {
 	UserName string `json:"user_name"`
 }
 
+// Request bodies for the catalog, order and review endpoints. Their validate
+// tags are checked by middleware.DecodeJSON.
+
+type ArtisanProfileRequest struct {
+	BusinessName     string `json:"business_name" validate:"required,max=255"`
+	CraftType        string `json:"craft_type" validate:"required,max=100"`
+	Region           string `json:"region" validate:"required,max=100"`
+	Bio              string `json:"bio" validate:"max=5000"`
+	VerificationDocs string `json:"verification_docs" validate:"max=5000"`
+}
+
+// UpdateArtisanProfileRequest covers the fields an artisan may change after
+// onboarding.
+type UpdateArtisanProfileRequest struct {
+	BusinessName string `json:"business_name" validate:"required,max=255"`
+	Region       string `json:"region" validate:"required,max=100"`
+	Bio          string `json:"bio" validate:"max=5000"`
+}
+
+type CategoryRequest struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Slug        string `json:"slug" validate:"required,max=100"`
+	Description string `json:"description" validate:"max=2000"`
+	ImageURL    string `json:"image_url" validate:"max=2000"`
+}
+
+type ProductRequest struct {
+	CategoryID   int     `json:"category_id" validate:"required,min=1"`
+	Name         string  `json:"name" validate:"required,max=255"`
+	Description  string  `json:"description" validate:"max=10000"`
+	AIStory      string  `json:"ai_story" validate:"max=10000"`
+	Price        float64 `json:"price" validate:"gt=0,max=99999999"`
+	MaterialCost float64 `json:"material_cost" validate:"min=0,max=99999999"`
+	LaborCost    float64 `json:"labor_cost" validate:"min=0,max=99999999"`
+	Materials    string  `json:"materials" validate:"max=1000"`
+	CraftingTime int     `json:"crafting_time" validate:"min=0,max=8760"`
+	ImageURLs    string  `json:"image_urls" validate:"max=10000"`
+	Stock        int     `json:"stock" validate:"min=0,max=100000"`
+}
+
+type UpdateProductRequest struct {
+	Name         string  `json:"name" validate:"required,max=255"`
+	Description  string  `json:"description" validate:"max=10000"`
+	Price        float64 `json:"price" validate:"gt=0,max=99999999"`
+	Stock        int     `json:"stock" validate:"min=0,max=100000"`
+	Materials    string  `json:"materials" validate:"max=1000"`
+	CraftingTime int     `json:"crafting_time" validate:"min=0,max=8760"`
+}
+
+// CreateOrderRequest ships to AddressID, or to ShippingAddress for clients
+// that still send free text, or else to the buyer's default address.
+type CreateOrderRequest struct {
+	ProductID       int    `json:"product_id" validate:"required,min=1"`
+	Quantity        int    `json:"quantity" validate:"min=1,max=100"`
+	ShippingAddress string `json:"shipping_address" validate:"max=1000"`
+	AddressID       *int   `json:"address_id" validate:"min=1"`
+}
+
+type CheckoutRequest struct {
+	ProductID       int    `json:"product_id" validate:"required,min=1"`
+	Quantity        int    `json:"quantity" validate:"min=1,max=100"`
+	ShippingAddress string `json:"shipping_address" validate:"max=1000"`
+	AddressID       *int   `json:"address_id" validate:"min=1"`
+	PaymentMethod   string `json:"payment_method" validate:"max=50"`
+}
+
+type UpdateOrderStatusRequest struct {
+	Status OrderStatus `json:"status" validate:"required,oneof=pending confirmed crafting shipping delivered cancelled"`
+}
+
+type OrderProgressRequest struct {
+	Stage       string `json:"stage" validate:"required,max=100"`
+	Description string `json:"description" validate:"max=2000"`
+	ImageURL    string `json:"image_url" validate:"max=2000"`
+}
+
+// CreateReviewRequest.OrderID is optional; 0 leaves the review unlinked.
+type CreateReviewRequest struct {
+	ProductID int    `json:"product_id" validate:"required,min=1"`
+	OrderID   int    `json:"order_id" validate:"min=0"`
+	Rating    int    `json:"rating" validate:"min=1,max=5"`
+	Comment   string `json:"comment" validate:"max=5000"`
+	MediaURLs string `json:"media_urls" validate:"max=10000"`
+}
+
+type VideoCallRequest struct {
+	ArtisanID int `json:"artisan_id" validate:"required,min=1"`
+	ProductID int `json:"product_id" validate:"required,min=1"`
+}
+
 type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
 }
 
+// RegisterRequest has no role: self-registration always creates a buyer.
+// Artisans are created through onboarding and admins through invitations.
 type RegisterRequest struct {
-	Email    string   `json:"email"`
-	Password string   `json:"password"`
-	Name     string   `json:"name"`
-	Role     UserRole `json:"role"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+	Name     string `json:"name" validate:"required,max=255"`
 }
 
 type AuthResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token         string   `json:"token"`
+	RefreshToken  string   `json:"refresh_token"`
+	ExpiresIn     int      `json:"expires_in"`
+	User          User     `json:"user"`
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+// TwoFactorChallenge is returned by Login instead of tokens when a second
+// factor is needed. When EnrollmentRequired is set the role demands 2FA and
+// the account has none yet, so the secret to enroll is included.
+type TwoFactorChallenge struct {
+	TwoFactorRequired  bool   `json:"two_factor_required"`
+	ChallengeToken     string `json:"challenge_token"`
+	ExpiresIn          int    `json:"expires_in"`
+	EnrollmentRequired bool   `json:"enrollment_required"`
+	Secret             string `json:"secret,omitempty"`
+	ProvisioningURI    string `json:"provisioning_uri,omitempty"`
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+type TwoFactorSetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password     string `json:"password" validate:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type RolePolicy struct {
+	Role             UserRole  `json:"role"`
+	RequireTwoFactor bool      `json:"require_two_factor"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+type UpdateRolePolicyRequest struct {
+	RequireTwoFactor bool `json:"require_two_factor"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type AdminInvitation struct {
+	ID         int        `json:"id"`
+	Email      string     `json:"email"`
+	InvitedBy  int        `json:"invited_by"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// RolePermissions lists what a role is allowed to do.
+type RolePermissions struct {
+	Role        UserRole `json:"role"`
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateRolePermissionsRequest struct {
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateUserRoleRequest struct {
+	Role UserRole `json:"role" validate:"required"`
+}
+
+// CreateAPIKeyRequest issues an artisan API key. ExpiresInDays of 0 means the
+// key does not expire.
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" validate:"required,max=100"`
+	Scopes        []string `json:"scopes" validate:"required"`
+	ExpiresInDays int      `json:"expires_in_days" validate:"min=0,max=3650"`
+}
+
+// AccountLockout records an account or client IP locked out after repeated failed logins.
+type AccountLockout struct {
+	ID             int        `json:"id"`
+	UserID         *int       `json:"user_id"`
+	Email          *string    `json:"email"`
+	IPAddress      *string    `json:"ip_address"`
+	Scope          string     `json:"scope"`
+	FailedAttempts int        `json:"failed_attempts"`
+	LockedUntil    time.Time  `json:"locked_until"`
+	UnlockedAt     *time.Time `json:"unlocked_at"`
+	UnlockedBy     *int       `json:"unlocked_by"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type CreateInvitationRequest struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Name     string `json:"name" validate:"max=255"`
+	Password string `json:"password" validate:"max=72"`
+}
+
+// UpdateProfileRequest changes the caller's name and/or email. Changing the
+// email requires the current password and re-verification.
+type UpdateProfileRequest struct {
+	Name            *string `json:"name" validate:"max=255"`
+	Email           *string `json:"email" validate:"max=255"`
+	CurrentPassword string  `json:"current_password"`
+}
+
+// UpdateProfileResponse is the updated user. When the email changed, every
+// earlier session has been signed out and the tokens replace the caller's.
+type UpdateProfileResponse struct {
+	User
+	Token        string `json:"token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int    `json:"expires_in,omitempty"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,max=72"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type Payment struct {
+	ID            int       `json:"id"`
+	OrderID       int       `json:"order_id"`
+	Amount        float64   `json:"amount"`
+	PlatformFee   float64   `json:"platform_fee"`
+	ArtisanAmount float64   `json:"artisan_amount"`
+	PaymentMethod string    `json:"payment_method"`
+	PaymentStatus string    `json:"payment_status"`
+	TransactionID string    `json:"transaction_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type LogoutRequest struct {
+	AllSessions bool `json:"all_sessions"`
 }
 
 type ProductStoryRequest struct {
-	CraftType string `json:"craft_type"`
-	Region    string `json:"region"`
-	Materials string `json:"materials"`
-	Name      string `json:"name"`
+	CraftType string `json:"craft_type" validate:"max=100"`
+	Region    string `json:"region" validate:"max=100"`
+	Materials string `json:"materials" validate:"required,max=1000"`
+	Name      string `json:"name" validate:"required,max=255"`
 }
 
 type ProductStoryResponse struct {
@@ -171,3 +497,16 @@ type Analytics struct {
 	PendingArtisans int     `json:"pending_artisans"`
 	PendingProducts int     `json:"pending_products"`
 }
+
+// Page is one page of a /api/v2 list response.
+type Page[T any] struct {
+	Data       []T        `json:"data"`
+	Pagination Pagination `json:"pagination"`
+}
+
+type Pagination struct {
+	Page       int `json:"page"`
+	PerPage    int `json:"per_page"`
+	Total      int `json:"total"`
+	TotalPages int `json:"total_pages"`
+}
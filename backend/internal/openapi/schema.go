@@ -0,0 +1,231 @@
+// backend/internal/openapi/schema.go
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemas turns Go types into component schemas. Named structs are added to
+// the components once and referenced from then on, so the document describes
+// exactly what encoding/json produces for the types the handlers use.
+type schemas struct {
+	components map[string]*Schema
+	types      map[string]reflect.Type
+	enums      map[reflect.Type][]string
+	names      map[reflect.Type]string
+}
+
+func newSchemas() *schemas {
+	return &schemas{
+		components: map[string]*Schema{},
+		types:      map[string]reflect.Type{},
+		enums:      map[reflect.Type][]string{},
+		names:      map[reflect.Type]string{},
+	}
+}
+
+// enum documents a named string type by its allowed values.
+func (s *schemas) enum(v interface{}, values ...string) {
+	s.enums[reflect.TypeOf(v)] = values
+}
+
+// rename gives v's type a schema name other than its Go name, for types
+// whose name only makes sense inside their package.
+func (s *schemas) rename(v interface{}, name string) {
+	s.names[reflect.TypeOf(v)] = name
+}
+
+// ref returns a schema for v's type, registering it if needed.
+func (s *schemas) ref(v interface{}) *Schema {
+	return s.of(reflect.TypeOf(v))
+}
+
+func (s *schemas) of(t reflect.Type) *Schema {
+	if values, ok := s.enums[t]; ok {
+		return &Schema{Type: "string", Enum: values}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		inner := s.of(t.Elem())
+		if inner.Ref != "" {
+			return &Schema{AllOf: []*Schema{inner}, Nullable: true}
+		}
+		nullable := *inner
+		nullable.Nullable = true
+		return &nullable
+	case reflect.Struct:
+		if t == timeType {
+			return &Schema{Type: "string", Format: "date-time"}
+		}
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return s.component(t)
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: s.of(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.of(t.Elem())}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	}
+	panic("openapi: unsupported type " + t.String())
+}
+
+// component registers a named struct under components/schemas. Two different
+// Go types with the same name would silently share a schema, so that panics.
+func (s *schemas) component(t reflect.Type) *Schema {
+	name, ok := s.names[t]
+	if !ok {
+		name = schemaName(t.Name())
+	}
+	ref := &Schema{Ref: "#/components/schemas/" + name}
+	if existing, ok := s.types[name]; ok {
+		if existing != t {
+			panic("openapi: schema name " + name + " used by both " + existing.String() + " and " + t.String())
+		}
+		return ref
+	}
+	s.types[name] = t
+	s.components[name] = nil // placeholder so recursive types terminate
+	s.components[name] = s.object(t)
+	return ref
+}
+
+func (s *schemas) object(t reflect.Type) *Schema {
+	obj := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	s.addFields(obj, t)
+	return obj
+}
+
+func (s *schemas) addFields(obj *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.addFields(obj, embedded)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := s.of(field.Type)
+		if applyRules(prop, field.Type, field.Tag.Get("validate")) {
+			obj.Required = append(obj.Required, name)
+		}
+		obj.Properties[name] = prop
+	}
+}
+
+// applyRules copies the validate tag rules checked by the validate package
+// onto prop and reports whether the field is required.
+func applyRules(prop *Schema, t reflect.Type, tag string) bool {
+	if tag == "" || tag == "-" {
+		return false
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		key, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch key {
+		case "required":
+			required = true
+			if t.Kind() == reflect.String && prop.MinLength == nil {
+				prop.MinLength = intPtr(1)
+			}
+			if t.Kind() == reflect.Slice && prop.MinItems == nil {
+				prop.MinItems = intPtr(1)
+			}
+		case "min", "max", "gt":
+			n, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				panic("openapi: bad " + key + " argument " + arg)
+			}
+			switch t.Kind() {
+			case reflect.String:
+				if key == "min" {
+					prop.MinLength = intPtr(int(n))
+				} else {
+					prop.MaxLength = intPtr(int(n))
+				}
+			case reflect.Slice, reflect.Map:
+				if key == "min" {
+					prop.MinItems = intPtr(int(n))
+				} else {
+					prop.MaxItems = intPtr(int(n))
+				}
+			default:
+				switch key {
+				case "min":
+					prop.Minimum = &n
+				case "max":
+					prop.Maximum = &n
+				case "gt":
+					prop.Minimum = &n
+					prop.ExclusiveMinimum = true
+				}
+			}
+		case "email":
+			prop.Format = "email"
+		case "oneof":
+			prop.Enum = strings.Fields(arg)
+		}
+	}
+	return required
+}
+
+// schemaName derives a schema name from a Go type name. Instances of generic
+// types are named after their type argument: Page[models.Order] is OrderPage.
+func schemaName(name string) string {
+	generic, arg, ok := strings.Cut(name, "[")
+	if !ok {
+		return exportedName(name)
+	}
+	arg = strings.TrimSuffix(arg, "]")
+	if i := strings.LastIndex(arg, "."); i >= 0 {
+		arg = arg[i+1:]
+	}
+	return exportedName(arg) + exportedName(generic)
+}
+
+func exportedName(name string) string {
+	if name == "" {
+		return name
+	}
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+func intPtr(n int) *int {
+	return &n
+}
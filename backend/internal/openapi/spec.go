@@ -0,0 +1,657 @@
+// backend/internal/openapi/spec.go
+package openapi
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/apikey"
+	"backend/internal/idempotency"
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/rbac"
+	"backend/internal/token"
+)
+
+type access int
+
+const (
+	public   access = iota
+	signedIn        // access token from login
+	scoped          // access token, or an API key granted scope
+)
+
+// operation describes one route. route must match the pattern registered on
+// the mux in cmd/api exactly; a test there keeps the two in step.
+type operation struct {
+	route       string
+	id          string
+	tag         string
+	summary     string
+	description string
+	access      access
+	scope       string
+	permission  string
+	verified    bool
+	query       []Parameter
+	body        interface{}
+	optional    bool
+	status      int
+	response    interface{}
+	contentType string
+	idempotent  bool
+	limited     bool
+	paged       bool
+	// cached responses carry an ETag and may be compressed; see middleware.Cache.
+	cached bool
+	// unversioned routes are not repeated under /api/v1.
+	unversioned bool
+	// superseded v1 routes have a /api/v2 replacement at the same path.
+	superseded bool
+}
+
+// oneOf documents a response that is one of several types.
+type oneOf []interface{}
+
+var tags = []Tag{
+	{Name: "Auth", Description: "Registration, login, tokens and two-factor authentication"},
+	{Name: "Account", Description: "The signed-in user's own profile and data"},
+	{Name: "Catalog", Description: "Public products, categories and artisan profiles"},
+	{Name: "Orders", Description: "Placing and tracking orders"},
+	{Name: "Addresses", Description: "Saved shipping addresses"},
+	{Name: "Reviews", Description: "Product reviews"},
+	{Name: "Artisan", Description: "Workshop management for artisans"},
+	{Name: "API keys", Description: "Keys artisans use for integrations"},
+	{Name: "AI", Description: "Generated stories, confidence scores and delivery estimates"},
+	{Name: "Video calls", Description: "Live calls between buyers and artisans"},
+	{Name: "Admin", Description: "Moderation, analytics and security administration"},
+	{Name: "Operations", Description: "Health checks, metrics and this document"},
+}
+
+var operations = []operation{
+	// Operations
+	{route: "GET /metrics", id: "getMetrics", tag: "Operations", summary: "Prometheus metrics",
+		description: "Requires `Authorization: Bearer <METRICS_TOKEN>` when the server sets one.",
+		response:    "", contentType: "text/plain"},
+	{route: "GET /healthz", id: "getHealth", tag: "Operations", summary: "Liveness check", response: healthStatus{}},
+	{route: "GET /readyz", id: "getReadiness", tag: "Operations", summary: "Readiness check",
+		description: "Fails with 503 while the instance drains or the database is unreachable.", response: healthStatus{}},
+	{route: "GET /api/openapi.json", id: "getOpenAPI", tag: "Operations", summary: "This OpenAPI document",
+		response: map[string]interface{}{}, unversioned: true},
+	{route: "GET /api/docs", id: "getDocs", tag: "Operations", summary: "Interactive API documentation",
+		response: "", contentType: "text/html", unversioned: true},
+
+	// Auth
+	{route: "GET /.well-known/jwks.json", id: "getJWKS", tag: "Auth", summary: "Public keys for verifying access tokens", response: token.JWKSet{}},
+	{route: "POST /api/auth/register", id: "register", tag: "Auth", summary: "Create a buyer account",
+		body: models.RegisterRequest{}, status: http.StatusCreated, response: oneOf{models.AuthResponse{}, models.TwoFactorChallenge{}}, limited: true},
+	{route: "POST /api/auth/login", id: "login", tag: "Auth", summary: "Log in",
+		description: "Returns tokens, or a two-factor challenge to complete with /api/auth/login/2fa.",
+		body:        models.LoginRequest{}, response: oneOf{models.AuthResponse{}, models.TwoFactorChallenge{}}, limited: true},
+	{route: "POST /api/auth/login/2fa", id: "loginTwoFactor", tag: "Auth", summary: "Complete a two-factor login",
+		body: models.TwoFactorLoginRequest{}, response: models.AuthResponse{}, limited: true},
+	{route: "POST /api/auth/refresh", id: "refreshToken", tag: "Auth", summary: "Exchange a refresh token for new tokens",
+		body: models.RefreshRequest{}, response: models.AuthResponse{}, limited: true},
+	{route: "POST /api/auth/logout", id: "logout", tag: "Auth", summary: "End this session, or every session",
+		access: signedIn, body: models.LogoutRequest{}, optional: true, response: message{}},
+	{route: "POST /api/auth/forgot-password", id: "forgotPassword", tag: "Auth", summary: "Email a password reset link",
+		body: models.ForgotPasswordRequest{}, response: message{}, limited: true},
+	{route: "POST /api/auth/reset-password", id: "resetPassword", tag: "Auth", summary: "Set a new password with a reset token",
+		body: models.ResetPasswordRequest{}, response: message{}, limited: true},
+	{route: "POST /api/auth/verify-email", id: "verifyEmail", tag: "Auth", summary: "Confirm an email address",
+		body: models.VerifyEmailRequest{}, response: message{}, limited: true},
+	{route: "POST /api/auth/resend-verification", id: "resendVerification", tag: "Auth", summary: "Send the verification email again",
+		access: signedIn, response: message{}, limited: true},
+	{route: "POST /api/auth/accept-invitation", id: "acceptInvitation", tag: "Auth", summary: "Accept an admin invitation",
+		body: models.AcceptInvitationRequest{}, response: oneOf{models.AuthResponse{}, models.TwoFactorChallenge{}}, limited: true},
+	{route: "POST /api/auth/2fa/setup", id: "setupTwoFactor", tag: "Auth", summary: "Start two-factor enrollment",
+		access: signedIn, response: models.TwoFactorSetupResponse{}},
+	{route: "POST /api/auth/2fa/enable", id: "enableTwoFactor", tag: "Auth", summary: "Confirm enrollment and get recovery codes",
+		access: signedIn, body: models.TwoFactorCodeRequest{}, response: models.RecoveryCodesResponse{}},
+	{route: "POST /api/auth/2fa/disable", id: "disableTwoFactor", tag: "Auth", summary: "Turn off two-factor authentication",
+		access: signedIn, body: models.DisableTwoFactorRequest{}, response: message{}},
+	{route: "POST /api/auth/2fa/recovery-codes", id: "regenerateRecoveryCodes", tag: "Auth", summary: "Replace the recovery codes",
+		access: signedIn, body: models.TwoFactorCodeRequest{}, response: models.RecoveryCodesResponse{}},
+
+	// Account
+	{route: "GET /api/me", id: "getMe", tag: "Account", summary: "The signed-in user", access: signedIn, response: models.User{}},
+	{route: "PUT /api/me", id: "updateMe", tag: "Account", summary: "Change name or email",
+		description: "A new email needs current_password and must be verified again. Changing it signs out every session " +
+			"and returns a new token pair for this client.",
+		access: signedIn, body: models.UpdateProfileRequest{}, response: models.UpdateProfileResponse{}},
+	{route: "DELETE /api/me", id: "deleteMe", tag: "Account", summary: "Delete the account",
+		access: signedIn, body: models.DeleteAccountRequest{}, response: message{}},
+	{route: "PUT /api/me/password", id: "changePassword", tag: "Account", summary: "Change password",
+		access: signedIn, body: models.ChangePasswordRequest{}, response: message{}},
+	{route: "GET /api/me/export", id: "exportData", tag: "Account", summary: "Download everything held about the account",
+		access: signedIn, query: []Parameter{
+			queryParam("format", "`zip` for a zip archive instead of JSON", "json", "zip"),
+		}, response: accountExport{}},
+
+	// Catalog
+	{route: "GET /api/products", id: "listProducts", tag: "Catalog", summary: "Search approved products",
+		query: productQuery, response: []models.ProductWithDetails{}, cached: true, superseded: true},
+	{route: "GET /api/products/{id}", id: "getProduct", tag: "Catalog", summary: "A product with its artisan",
+		response: models.ProductWithDetails{}, cached: true},
+	{route: "GET /api/products/{id}/reviews", id: "listProductReviews", tag: "Reviews", summary: "Reviews of a product", response: []models.ReviewWithUser{}, superseded: true},
+	{route: "GET /api/categories", id: "listCategories", tag: "Catalog", summary: "Product categories", response: []models.Category{}, cached: true},
+	{route: "GET /api/artisans/{id}", id: "getArtisan", tag: "Catalog", summary: "An artisan's public profile", response: models.Artisan{}},
+
+	// Orders
+	{route: "POST /api/orders", id: "createOrder", tag: "Orders", summary: "Place an order",
+		access: signedIn, body: models.CreateOrderRequest{}, status: http.StatusCreated, response: models.Order{},
+		idempotent: true, limited: true},
+	{route: "POST /api/orders/with-payment", id: "checkout", tag: "Orders", summary: "Place and pay for an order",
+		access: signedIn, verified: true, body: models.CheckoutRequest{}, status: http.StatusCreated, response: checkoutResult{},
+		idempotent: true, limited: true},
+	{route: "GET /api/orders", id: "listOrders", tag: "Orders", summary: "The buyer's orders", access: signedIn,
+		response: []models.BuyerOrder{}, superseded: true},
+	{route: "GET /api/orders/{id}", id: "getOrder", tag: "Orders", summary: "An order with its progress", access: signedIn, response: orderDetails{}},
+
+	// Addresses
+	{route: "GET /api/addresses", id: "listAddresses", tag: "Addresses", summary: "Saved addresses", access: signedIn, response: []models.Address{}},
+	{route: "POST /api/addresses", id: "createAddress", tag: "Addresses", summary: "Save an address",
+		access: signedIn, body: models.AddressRequest{}, status: http.StatusCreated, response: models.Address{}},
+	{route: "PUT /api/addresses/{id}", id: "updateAddress", tag: "Addresses", summary: "Change a saved address",
+		access: signedIn, body: models.AddressRequest{}, response: models.Address{}},
+	{route: "DELETE /api/addresses/{id}", id: "deleteAddress", tag: "Addresses", summary: "Delete a saved address", access: signedIn, response: message{}},
+
+	// Reviews
+	{route: "POST /api/reviews", id: "createReview", tag: "Reviews", summary: "Review a product",
+		access: signedIn, body: models.CreateReviewRequest{}, status: http.StatusCreated, response: models.Review{},
+		idempotent: true, limited: true},
+
+	// Artisan
+	{route: "POST /api/artisan/onboard", id: "onboardArtisan", tag: "Artisan", summary: "Open a workshop",
+		access: signedIn, verified: true, body: models.ArtisanProfileRequest{}, status: http.StatusCreated, response: models.Artisan{}},
+	{route: "PUT /api/artisan/profile", id: "updateArtisanProfile", tag: "Artisan", summary: "Edit the workshop profile",
+		access: scoped, scope: apikey.ScopeProfileWrite, permission: rbac.ArtisanProfile,
+		body: models.UpdateArtisanProfileRequest{}, response: message{}},
+	{route: "POST /api/artisan/products", id: "createProduct", tag: "Artisan", summary: "List a product for approval",
+		access: scoped, scope: apikey.ScopeProductsWrite, permission: rbac.ProductsWrite,
+		body: models.ProductRequest{}, status: http.StatusCreated, response: models.Product{}},
+	{route: "PUT /api/artisan/products/{id}", id: "updateProduct", tag: "Artisan", summary: "Edit a product",
+		access: scoped, scope: apikey.ScopeProductsWrite, permission: rbac.ProductsWrite,
+		body: models.UpdateProductRequest{}, response: message{}},
+	{route: "GET /api/artisan/orders", id: "listArtisanOrders", tag: "Artisan", summary: "Orders for the workshop",
+		access: scoped, scope: apikey.ScopeOrdersRead, permission: rbac.OrdersFulfill, response: []artisanOrder{}, superseded: true},
+	{route: "PUT /api/artisan/orders/{id}/status", id: "updateOrderStatus", tag: "Artisan", summary: "Move an order to a new status",
+		access: scoped, scope: apikey.ScopeOrdersWrite, permission: rbac.OrdersFulfill,
+		body: models.UpdateOrderStatusRequest{}, response: message{}},
+	{route: "POST /api/artisan/orders/{id}/progress", id: "addOrderProgress", tag: "Artisan", summary: "Post a crafting progress update",
+		access: scoped, scope: apikey.ScopeOrdersWrite, permission: rbac.OrdersFulfill,
+		body: models.OrderProgressRequest{}, status: http.StatusCreated, response: models.OrderProgress{}},
+	{route: "GET /api/artisan/earnings", id: "getEarnings", tag: "Artisan", summary: "Earnings summary",
+		access: scoped, scope: apikey.ScopeEarningsRead, permission: rbac.EarningsRead, response: earnings{}},
+
+	// API keys
+	{route: "POST /api/artisan/api-keys", id: "createAPIKey", tag: "API keys", summary: "Issue an API key",
+		description: "The plaintext key is only returned here.",
+		access:      signedIn, permission: rbac.APIKeysManage, body: models.CreateAPIKeyRequest{}, status: http.StatusCreated, response: createdAPIKey{}},
+	{route: "GET /api/artisan/api-keys", id: "listAPIKeys", tag: "API keys", summary: "Issued API keys",
+		access: signedIn, permission: rbac.APIKeysManage, response: []apikey.Key{}},
+	{route: "DELETE /api/artisan/api-keys/{id}", id: "revokeAPIKey", tag: "API keys", summary: "Revoke an API key",
+		access: signedIn, permission: rbac.APIKeysManage, response: message{}},
+
+	// AI
+	{route: "POST /api/ai/generate-story", id: "generateProductStory", tag: "AI", summary: "Write a product story",
+		access: signedIn, permission: rbac.AIStories, body: models.ProductStoryRequest{}, response: models.ProductStoryResponse{}, limited: true},
+	{route: "GET /api/ai/confidence-score/{productId}", id: "getConfidenceScore", tag: "AI", summary: "How trustworthy a listing looks",
+		response: models.ConfidenceScoreResponse{}},
+	{route: "GET /api/ai/delivery-eta/{orderId}", id: "getDeliveryETA", tag: "AI", summary: "Estimated delivery for an order",
+		access: signedIn, response: models.ETAResponse{}},
+
+	// Video calls
+	{route: "POST /api/video-call/request", id: "requestVideoCall", tag: "Video calls", summary: "Ask an artisan for a call",
+		access: signedIn, verified: true, body: models.VideoCallRequest{}, status: http.StatusCreated, response: videoCallStatus{},
+		idempotent: true, limited: true},
+	{route: "GET /api/video-call/pending", id: "listPendingVideoCalls", tag: "Video calls", summary: "Calls waiting for the artisan",
+		access: signedIn, permission: rbac.VideoCallsAnswer, response: []videoCall{}, superseded: true},
+	{route: "PUT /api/video-call/{id}/accept", id: "acceptVideoCall", tag: "Video calls", summary: "Accept a call",
+		access: signedIn, permission: rbac.VideoCallsAnswer, response: videoCallStatus{}},
+	{route: "GET /api/video-call/{id}/status", id: "getVideoCallStatus", tag: "Video calls", summary: "Status of a call request",
+		access: signedIn, response: videoCallStatus{}},
+
+	// Admin
+	{route: "GET /api/admin/pending-artisans", id: "listPendingArtisans", tag: "Admin", summary: "Artisans awaiting verification",
+		access: signedIn, permission: rbac.ArtisansApprove, response: []models.Artisan{}, superseded: true},
+	{route: "PUT /api/admin/artisans/{id}/verify", id: "verifyArtisan", tag: "Admin", summary: "Verify an artisan",
+		access: signedIn, permission: rbac.ArtisansApprove, response: message{}},
+	{route: "GET /api/admin/pending-products", id: "listPendingProducts", tag: "Admin", summary: "Products awaiting approval",
+		access: signedIn, permission: rbac.ProductsApprove, response: []pendingProduct{}, superseded: true},
+	{route: "PUT /api/admin/products/{id}/approve", id: "approveProduct", tag: "Admin", summary: "Approve a product",
+		access: signedIn, permission: rbac.ProductsApprove, response: message{}},
+	{route: "POST /api/admin/categories", id: "createCategory", tag: "Admin", summary: "Add a category",
+		access: signedIn, permission: rbac.CategoriesManage, body: models.CategoryRequest{}, status: http.StatusCreated, response: models.Category{}},
+	{route: "GET /api/admin/analytics", id: "getAnalytics", tag: "Admin", summary: "Marketplace totals",
+		access: signedIn, permission: rbac.AnalyticsRead, response: models.Analytics{}},
+	{route: "GET /api/admin/orders", id: "adminListOrders", tag: "Admin", summary: "The latest 200 orders",
+		access: signedIn, permission: rbac.OrdersRead, query: []Parameter{
+			queryParam("status", "Only orders in this status"),
+			integerParam("user_id", "Only orders placed by this user"),
+		}, response: []adminOrder{}, superseded: true},
+	{route: "POST /api/admin/users/{id}/revoke-sessions", id: "revokeUserSessions", tag: "Admin", summary: "Sign a user out everywhere and revoke their API keys",
+		access: signedIn, permission: rbac.UsersManage, response: sessionsRevoked{}},
+	{route: "POST /api/admin/users/{id}/unlock", id: "unlockUser", tag: "Admin", summary: "Lift a login lockout",
+		access: signedIn, permission: rbac.UsersManage, response: userUnlocked{}},
+	{route: "PUT /api/admin/users/{id}/role", id: "updateUserRole", tag: "Admin", summary: "Change a user's role",
+		description: "Moves a user between buyer, support and moderator. Admins are only added by invitation.",
+		access:      signedIn, permission: rbac.SecurityManage, body: models.UpdateUserRoleRequest{}, response: roleUpdated{}},
+	{route: "GET /api/admin/lockouts", id: "listLockouts", tag: "Admin", summary: "Login lockouts",
+		access: signedIn, permission: rbac.UsersManage, query: []Parameter{
+			queryParam("active", "`true` to list only lockouts still in force", "true", "false"),
+		}, response: []models.AccountLockout{}},
+	{route: "POST /api/admin/invitations", id: "createInvitation", tag: "Admin", summary: "Invite an admin",
+		access: signedIn, permission: rbac.InvitationsManage, body: models.CreateInvitationRequest{}, status: http.StatusCreated, response: models.AdminInvitation{}},
+	{route: "GET /api/admin/invitations", id: "listInvitations", tag: "Admin", summary: "Admin invitations",
+		access: signedIn, permission: rbac.InvitationsManage, response: []models.AdminInvitation{}},
+	{route: "DELETE /api/admin/invitations/{id}", id: "revokeInvitation", tag: "Admin", summary: "Revoke an invitation",
+		access: signedIn, permission: rbac.InvitationsManage, response: message{}},
+	{route: "GET /api/admin/security/roles", id: "listRolePolicies", tag: "Admin", summary: "Two-factor policy per role",
+		access: signedIn, permission: rbac.SecurityManage, response: []models.RolePolicy{}},
+	{route: "PUT /api/admin/security/roles/{role}", id: "updateRolePolicy", tag: "Admin", summary: "Change a role's two-factor policy",
+		access: signedIn, permission: rbac.SecurityManage, body: models.UpdateRolePolicyRequest{}, response: models.RolePolicy{}},
+	{route: "GET /api/admin/security/permissions", id: "listPermissions", tag: "Admin", summary: "Permissions and what each role holds",
+		access: signedIn, permission: rbac.SecurityManage, response: permissionCatalog{}},
+	{route: "PUT /api/admin/security/roles/{role}/permissions", id: "updateRolePermissions", tag: "Admin", summary: "Replace a role's permissions",
+		access: signedIn, permission: rbac.SecurityManage, body: models.UpdateRolePermissionsRequest{}, response: models.RolePermissions{}},
+
+	// v2
+	{route: "GET /api/v2/products", id: "listProductsV2", tag: "Catalog", summary: "Search approved products, a page at a time",
+		query: productQuery, response: models.Page[models.ProductWithDetails]{}, paged: true, cached: true},
+	{route: "GET /api/v2/products/{id}/reviews", id: "listProductReviewsV2", tag: "Reviews", summary: "Reviews of a product, a page at a time",
+		response: models.Page[models.ReviewWithUser]{}, paged: true},
+	{route: "GET /api/v2/orders", id: "listOrdersV2", tag: "Orders", summary: "The buyer's orders, a page at a time",
+		access: signedIn, response: models.Page[models.BuyerOrder]{}, paged: true},
+	{route: "GET /api/v2/artisan/orders", id: "listArtisanOrdersV2", tag: "Artisan", summary: "Orders for the workshop, a page at a time",
+		access: scoped, scope: apikey.ScopeOrdersRead, permission: rbac.OrdersFulfill, response: models.Page[artisanOrder]{}, paged: true},
+	{route: "GET /api/v2/video-call/pending", id: "listPendingVideoCallsV2", tag: "Video calls", summary: "Calls waiting for the artisan, a page at a time",
+		access: signedIn, permission: rbac.VideoCallsAnswer, response: models.Page[videoCall]{}, paged: true},
+	{route: "GET /api/v2/admin/pending-artisans", id: "listPendingArtisansV2", tag: "Admin", summary: "Artisans awaiting verification, a page at a time",
+		access: signedIn, permission: rbac.ArtisansApprove, response: models.Page[models.Artisan]{}, paged: true},
+	{route: "GET /api/v2/admin/pending-products", id: "listPendingProductsV2", tag: "Admin", summary: "Products awaiting approval, a page at a time",
+		access: signedIn, permission: rbac.ProductsApprove, response: models.Page[pendingProduct]{}, paged: true},
+	{route: "GET /api/v2/admin/orders", id: "adminListOrdersV2", tag: "Admin", summary: "All orders, a page at a time",
+		access: signedIn, permission: rbac.OrdersRead, query: []Parameter{
+			queryParam("status", "Only orders in this status"),
+			integerParam("user_id", "Only orders placed by this user"),
+		}, response: models.Page[adminOrder]{}, paged: true},
+}
+
+var productQuery = []Parameter{
+	queryParam("category", "Category slug"),
+	queryParam("region", "Artisan region"),
+	queryParam("craft_type", "Artisan craft type"),
+	queryParam("search", "Matches name and description"),
+	numberParam("min_price", "Lowest price"),
+	numberParam("max_price", "Highest price"),
+	queryParam("sort", "Sort order; best match by default", "price_asc", "price_desc", "rating", "newest"),
+}
+
+// Response bodies the handlers build inline, described here.
+
+type message struct {
+	Message string `json:"message"`
+}
+
+type healthStatus struct {
+	Status   string `json:"status"`
+	Database string `json:"database,omitempty"`
+}
+
+type orderDetails struct {
+	models.Order
+	ProductName  string                 `json:"product_name"`
+	ProductImage string                 `json:"product_image"`
+	ArtisanName  string                 `json:"artisan_name"`
+	Progress     []models.OrderProgress `json:"progress"`
+}
+
+type artisanOrder struct {
+	models.Order
+	ProductName string `json:"product_name"`
+	BuyerName   string `json:"buyer_name"`
+}
+
+type adminOrder struct {
+	models.Order
+	ProductName string `json:"product_name"`
+	ArtisanName string `json:"artisan_name"`
+	BuyerEmail  string `json:"buyer_email"`
+}
+
+type pendingProduct struct {
+	ID          int     `json:"id"`
+	Name        string  `json:"name"`
+	Price       float64 `json:"price"`
+	CreatedAt   string  `json:"created_at"`
+	ArtisanName string  `json:"artisan_name"`
+}
+
+type checkoutResult struct {
+	OrderID         int       `json:"order_id"`
+	TotalAmount     float64   `json:"total_amount"`
+	ArtisanAmount   float64   `json:"artisan_amount"`
+	PlatformFee     float64   `json:"platform_fee"`
+	Message         string    `json:"message"`
+	EstimatedETA    time.Time `json:"estimated_eta"`
+	ShippingAddress string    `json:"shipping_address"`
+}
+
+type earnings struct {
+	TotalEarnings   float64 `json:"total_earnings"`
+	CompletedAmount float64 `json:"completed_amount"`
+	PendingAmount   float64 `json:"pending_amount"`
+	TotalOrders     int     `json:"total_orders"`
+	CompletedOrders int     `json:"completed_orders"`
+	PendingOrders   int     `json:"pending_orders"`
+	PlatformFeeRate float64 `json:"platform_fee_rate"`
+}
+
+type createdAPIKey struct {
+	Key    string     `json:"key"`
+	APIKey apikey.Key `json:"api_key"`
+}
+
+type videoCall struct {
+	ID          int       `json:"id"`
+	BuyerID     int       `json:"buyer_id"`
+	ArtisanID   int       `json:"artisan_id"`
+	ProductID   int       `json:"product_id"`
+	RoomName    string    `json:"room_name"`
+	Status      string    `json:"status"`
+	BuyerName   string    `json:"buyer_name"`
+	ProductName string    `json:"product_name"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type videoCallStatus struct {
+	ID       int    `json:"id,omitempty"`
+	Status   string `json:"status"`
+	RoomName string `json:"room_name,omitempty"`
+}
+
+type sessionsRevoked struct {
+	Message         string `json:"message"`
+	RevokedSessions int64  `json:"revoked_sessions"`
+	RevokedAPIKeys  int64  `json:"revoked_api_keys"`
+}
+
+type userUnlocked struct {
+	Message  string `json:"message"`
+	Unlocked int64  `json:"unlocked"`
+}
+
+type roleUpdated struct {
+	Message string          `json:"message"`
+	UserID  int             `json:"user_id"`
+	Role    models.UserRole `json:"role"`
+}
+
+type permissionCatalog struct {
+	Permissions []rbac.Permission        `json:"permissions"`
+	Roles       []models.RolePermissions `json:"roles"`
+}
+
+type accountExport struct {
+	ExportedAt time.Time        `json:"exported_at"`
+	Profile    models.User      `json:"profile"`
+	Artisan    *models.Artisan  `json:"artisan,omitempty"`
+	Addresses  []models.Address `json:"addresses"`
+	Orders     []models.Order   `json:"orders"`
+	Reviews    []models.Review  `json:"reviews"`
+	Payments   []models.Payment `json:"payments"`
+	VideoCalls []videoCall      `json:"video_calls"`
+	Sessions   []accountSession `json:"sessions"`
+}
+
+type accountSession struct {
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func build() *Document {
+	s := newSchemas()
+	roles := make([]string, len(models.Roles))
+	for i, role := range models.Roles {
+		roles[i] = string(role)
+	}
+	s.enum(models.UserRole(""), roles...)
+	s.enum(models.OrderStatus(""), string(models.OrderPending), string(models.OrderConfirmed), string(models.OrderCrafting),
+		string(models.OrderShipping), string(models.OrderDelivered), string(models.OrderCancelled))
+	s.rename(apikey.Key{}, "APIKey")
+
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:   "Craftora API",
+			Version: "1.0.0",
+			Description: "Marketplace API for handcrafted goods. Errors are RFC 7807 problem details " +
+				"with a stable `code`; validation failures list each field under `errors`.\n\n" +
+				"Routes under `/api` are version 1 and are also served under `/api/v1`. " +
+				"`/api/v2` list endpoints return one page of results in `data` with `pagination` details; " +
+				"the v1 routes they replace are deprecated.",
+		},
+		Servers: []Server{{URL: "/"}},
+		Tags:    tags,
+		Paths:   map[string]PathItem{},
+		Components: Components{
+			Responses: map[string]Response{
+				"Problem": {
+					Description: "Error",
+					Content:     map[string]MediaType{"application/problem+json": {Schema: s.ref(middleware.Problem{})}},
+				},
+			},
+			SecuritySchemes: map[string]SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT",
+					Description: "Access token from login or refresh."},
+				"apiKeyAuth": {Type: "http", Scheme: "bearer",
+					Description: "Artisan API key (`" + apikey.Prefix + "...`), accepted only on routes that list a scope."},
+			},
+		},
+	}
+
+	for _, op := range operations {
+		method, path, ok := strings.Cut(op.route, " ")
+		if !ok {
+			panic("openapi: route without a method: " + op.route)
+		}
+		doc.add(method, path, op.build(s, path))
+
+		// Unversioned /api routes are v1 and are served under /api/v1 too.
+		if strings.HasPrefix(path, "/api/") && !strings.HasPrefix(path, "/api/v2/") && !op.unversioned {
+			v1 := "/api/v1" + strings.TrimPrefix(path, "/api")
+			versioned := op.build(s, v1)
+			versioned.OperationID += "V1"
+			doc.add(method, v1, versioned)
+		}
+	}
+
+	doc.Components.Schemas = s.components
+	return doc
+}
+
+func (d *Document) add(method, path string, o *Operation) {
+	item := d.Paths[path]
+	if item == nil {
+		item = PathItem{}
+		d.Paths[path] = item
+	}
+	key := strings.ToLower(method)
+	if item[key] != nil {
+		panic("openapi: duplicate operation " + method + " " + path)
+	}
+	item[key] = o
+}
+
+func (op operation) build(s *schemas, path string) *Operation {
+	o := &Operation{
+		Tags:        []string{op.tag},
+		Summary:     op.summary,
+		OperationID: op.id,
+		Responses:   map[string]Response{"default": {Ref: "#/components/responses/Problem"}},
+	}
+
+	var notes []string
+	if op.description != "" {
+		notes = append(notes, op.description)
+	}
+	if op.permission != "" {
+		notes = append(notes, "Requires the `"+op.permission+"` permission.")
+	}
+	if op.scope != "" {
+		notes = append(notes, "API keys need the `"+op.scope+"` scope.")
+	}
+	if op.verified {
+		notes = append(notes, "Requires a verified email address.")
+	}
+	if op.superseded {
+		o.Deprecated = true
+		successor := "/api/v2" + strings.TrimPrefix(strings.TrimPrefix(path, "/api/v1"), "/api")
+		notes = append(notes, "Replaced by `"+successor+"`; responses carry `Deprecation`, `Sunset` and `Link` headers.")
+	}
+	o.Description = strings.Join(notes, " ")
+
+	switch op.access {
+	case signedIn:
+		o.Security = []map[string][]string{{"bearerAuth": {}}}
+	case scoped:
+		o.Security = []map[string][]string{{"bearerAuth": {}}, {"apiKeyAuth": {}}}
+	}
+
+	o.Parameters = append(o.Parameters, pathParams(path)...)
+	o.Parameters = append(o.Parameters, op.query...)
+	if op.paged {
+		o.Parameters = append(o.Parameters,
+			Parameter{Name: "page", In: "query", Description: "Page number, from 1", Schema: &Schema{Type: "integer", Minimum: float(1)}},
+			Parameter{Name: "per_page", In: "query", Description: "Results per page (default 20)",
+				Schema: &Schema{Type: "integer", Minimum: float(1), Maximum: float(100)}},
+		)
+	}
+	if op.idempotent {
+		o.Parameters = append(o.Parameters, Parameter{
+			Name: idempotency.Header, In: "header",
+			Description: "Makes the request safe to retry: repeats with the same key replay the first response.",
+			Schema:      &Schema{Type: "string", MaxLength: intPtr(255)},
+		})
+	}
+
+	if op.cached {
+		o.Parameters = append(o.Parameters, Parameter{
+			Name: "If-None-Match", In: "header",
+			Description: "ETag of a cached copy; answered with 304 if it is still current.",
+			Schema:      &Schema{Type: "string"},
+		})
+	}
+
+	if op.body != nil {
+		o.RequestBody = &RequestBody{
+			Required: !op.optional,
+			Content:  map[string]MediaType{"application/json": {Schema: s.ref(op.body)}},
+		}
+	}
+
+	status := op.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := Response{Description: http.StatusText(status)}
+	if op.response != nil {
+		contentType := op.contentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		success.Content = map[string]MediaType{contentType: {Schema: responseSchema(s, op.response)}}
+	}
+	if op.cached {
+		success.Headers = map[string]Header{
+			"ETag":          {Description: "Strong validator for this representation", Schema: &Schema{Type: "string"}},
+			"Cache-Control": {Description: "How long the response may be reused", Schema: &Schema{Type: "string"}},
+		}
+		o.Responses["304"] = Response{Description: "The copy named in If-None-Match is still current"}
+	}
+	o.Responses[strconv.Itoa(status)] = success
+
+	if op.access != public {
+		o.Responses["401"] = Response{Ref: "#/components/responses/Problem"}
+	}
+	if op.body != nil {
+		o.Responses["422"] = Response{Ref: "#/components/responses/Problem"}
+	}
+	if op.limited {
+		o.Responses["429"] = Response{
+			Description: "Rate limit exceeded",
+			Headers: map[string]Header{
+				"Retry-After": {Description: "Seconds until a request will be allowed", Schema: &Schema{Type: "integer"}},
+			},
+			Content: map[string]MediaType{"application/problem+json": {Schema: s.ref(middleware.Problem{})}},
+		}
+	}
+	return o
+}
+
+func responseSchema(s *schemas, v interface{}) *Schema {
+	if alternatives, ok := v.(oneOf); ok {
+		schema := &Schema{}
+		for _, alt := range alternatives {
+			schema.OneOf = append(schema.OneOf, s.ref(alt))
+		}
+		return schema
+	}
+	return s.ref(v)
+}
+
+// pathParams documents the {name} segments of path. Names ending in "id" are
+// integer IDs; anything else is a string.
+func pathParams(path string) []Parameter {
+	var params []Parameter
+	for _, segment := range strings.Split(path, "/") {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+		name := strings.Trim(segment, "{}")
+		schema := &Schema{Type: "string"}
+		if strings.HasSuffix(strings.ToLower(name), "id") {
+			schema = &Schema{Type: "integer", Format: "int32"}
+		}
+		params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: schema})
+	}
+	return params
+}
+
+func float(n float64) *float64 {
+	return &n
+}
+
+func queryParam(name, description string, values ...string) Parameter {
+	schema := &Schema{Type: "string"}
+	if len(values) > 0 {
+		schema.Enum = values
+	}
+	return Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+func numberParam(name, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: "number"}}
+}
+
+func integerParam(name, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: "integer"}}
+}
+
+// Routes lists every documented route as "METHOD /path", sorted.
+func Routes() []string {
+	var routes []string
+	for path, item := range Spec().Paths {
+		for method := range item {
+			routes = append(routes, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(routes)
+	return routes
+}
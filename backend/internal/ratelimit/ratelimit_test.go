@@ -0,0 +1,146 @@
+// backend/internal/ratelimit/ratelimit_test.go
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// clock is a manual time source for MemoryStore.
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestStore() (*MemoryStore, *clock) {
+	c := &clock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s := NewMemoryStore()
+	s.now = c.now
+	return s, c
+}
+
+func take(t *testing.T, s *MemoryStore, key string, limit Limit) Result {
+	t.Helper()
+	result, err := s.Take(context.Background(), key, limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestLimitConstructors(t *testing.T) {
+	if got := PerMinute(30, 5); got.Rate != 0.5 || got.Burst != 5 {
+		t.Errorf("PerMinute(30, 5) = %+v", got)
+	}
+	if got := PerHour(7200, 10); got.Rate != 2 || got.Burst != 10 {
+		t.Errorf("PerHour(7200, 10) = %+v", got)
+	}
+}
+
+func TestBurstThenReject(t *testing.T) {
+	s, _ := newTestStore()
+	limit := Limit{Rate: 1, Burst: 3}
+
+	for i, wantRemaining := range []int{2, 1, 0} {
+		result := take(t, s, "k", limit)
+		if !result.Allowed || result.Remaining != wantRemaining {
+			t.Fatalf("request %d = %+v, want allowed with %d remaining", i+1, result, wantRemaining)
+		}
+	}
+
+	result := take(t, s, "k", limit)
+	if result.Allowed {
+		t.Fatal("request beyond the burst was allowed")
+	}
+	if result.RetryAfter != time.Second {
+		t.Errorf("RetryAfter = %v, want 1s", result.RetryAfter)
+	}
+}
+
+func TestRefill(t *testing.T) {
+	limit := Limit{Rate: 0.5, Burst: 2} // one token every two seconds
+
+	tests := []struct {
+		name      string
+		wait      time.Duration
+		wantOK    bool
+		wantRetry time.Duration
+	}{
+		{"no time passed", 0, false, 2 * time.Second},
+		{"half a token", time.Second, false, time.Second},
+		{"one token", 2 * time.Second, true, 0},
+		{"refill is capped at the burst", time.Hour, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, c := newTestStore()
+			take(t, s, "k", limit)
+			take(t, s, "k", limit)
+
+			c.advance(tt.wait)
+			result := take(t, s, "k", limit)
+			if result.Allowed != tt.wantOK || result.RetryAfter != tt.wantRetry {
+				t.Errorf("after %v: %+v, want allowed=%v retry=%v", tt.wait, result, tt.wantOK, tt.wantRetry)
+			}
+		})
+	}
+}
+
+func TestRefillNeverExceedsBurst(t *testing.T) {
+	s, c := newTestStore()
+	limit := Limit{Rate: 10, Burst: 2}
+
+	take(t, s, "k", limit)
+	c.advance(time.Hour)
+	allowed := 0
+	for i := 0; i < 5; i++ {
+		if take(t, s, "k", limit).Allowed {
+			allowed++
+		}
+	}
+	if allowed != limit.Burst {
+		t.Errorf("%d requests allowed after a long idle period, want the burst of %d", allowed, limit.Burst)
+	}
+}
+
+func TestKeysAreIndependent(t *testing.T) {
+	s, _ := newTestStore()
+	limit := Limit{Rate: 1, Burst: 1}
+
+	take(t, s, "a", limit)
+	if take(t, s, "a", limit).Allowed {
+		t.Error("second request for a was allowed")
+	}
+	if !take(t, s, "b", limit).Allowed {
+		t.Error("b was limited by a's bucket")
+	}
+}
+
+func TestZeroRateDoesNotRefill(t *testing.T) {
+	s, c := newTestStore()
+	limit := Limit{Rate: 0, Burst: 1}
+
+	take(t, s, "k", limit)
+	c.advance(time.Hour)
+	if result := take(t, s, "k", limit); result.Allowed || result.RetryAfter != 24*time.Hour {
+		t.Errorf("zero-rate bucket: %+v", result)
+	}
+}
+
+func TestSweepDropsRefilledBuckets(t *testing.T) {
+	s, c := newTestStore()
+	limit := Limit{Rate: 1, Burst: 2}
+
+	take(t, s, "idle", limit)
+	c.advance(2 * time.Minute)
+	take(t, s, "active", limit)
+
+	if _, ok := s.buckets["idle"]; ok {
+		t.Error("refilled bucket was not swept")
+	}
+	if _, ok := s.buckets["active"]; !ok {
+		t.Error("bucket in use was swept")
+	}
+}
@@ -0,0 +1,238 @@
+// backend/internal/rbac/rbac.go
+package rbac
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"backend/internal/models"
+)
+
+// Permissions guarded by middleware.RequirePermission.
+const (
+	ProductsWrite    = "products.write"
+	OrdersFulfill    = "orders.fulfill"
+	ArtisanProfile   = "artisan.profile"
+	EarningsRead     = "earnings.read"
+	AIStories        = "ai.stories"
+	VideoCallsAnswer = "video_calls.answer"
+	APIKeysManage    = "api_keys.manage"
+
+	ArtisansApprove   = "artisans.approve"
+	ProductsApprove   = "products.approve"
+	CategoriesManage  = "categories.manage"
+	AnalyticsRead     = "analytics.read"
+	OrdersRead        = "orders.read"
+	UsersManage       = "users.manage"
+	InvitationsManage = "invitations.manage"
+	SecurityManage    = "security.manage"
+)
+
+// Permission describes something a role can be allowed to do.
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+var Permissions = []Permission{
+	{ProductsWrite, "Create and edit own products"},
+	{OrdersFulfill, "View and update orders for own workshop"},
+	{ArtisanProfile, "Edit own artisan profile"},
+	{EarningsRead, "View own earnings"},
+	{AIStories, "Generate product stories"},
+	{VideoCallsAnswer, "Answer video call requests"},
+	{APIKeysManage, "Create and revoke own API keys"},
+	{ArtisansApprove, "Review and verify artisan applications"},
+	{ProductsApprove, "Review and approve products"},
+	{CategoriesManage, "Create categories"},
+	{AnalyticsRead, "View marketplace analytics, including revenue"},
+	{OrdersRead, "View all orders"},
+	{UsersManage, "Revoke sessions, unlock accounts and view lockouts"},
+	{InvitationsManage, "Invite administrators"},
+	{SecurityManage, "Manage roles, permissions and two-factor policies"},
+}
+
+var artisanPermissions = []string{
+	ProductsWrite, OrdersFulfill, ArtisanProfile, EarningsRead, AIStories, VideoCallsAnswer, APIKeysManage,
+}
+
+// defaultGrants is what each role starts with. Admins get every permission.
+// Permissions granted here are only seeded once, so changes made by admins
+// survive restarts.
+var defaultGrants = map[models.UserRole][]string{
+	models.RoleArtisan:   artisanPermissions,
+	models.RoleSupport:   {OrdersRead, UsersManage},
+	models.RoleModerator: {ProductsApprove},
+}
+
+// cacheTTL bounds how long a permission change takes to reach every instance.
+const cacheTTL = 30 * time.Second
+
+// Store resolves role permissions from the database, caching them briefly
+// since every protected request needs them.
+type Store struct {
+	db *sql.DB
+
+	mu       sync.RWMutex
+	grants   map[models.UserRole]map[string]bool
+	loadedAt time.Time
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Seed registers known permissions and grants new ones to their default
+// roles. It is safe to run on every start.
+func Seed(ctx context.Context, db *sql.DB) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, p := range Permissions {
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO permissions (name, description) VALUES ($1, $2)
+			ON CONFLICT (name) DO NOTHING
+		`, p.Name, p.Description)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			continue
+		}
+
+		for _, role := range models.Roles {
+			if role != models.RoleAdmin && !contains(defaultGrants[role], p.Name) {
+				continue
+			}
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO role_permissions (role, permission) VALUES ($1, $2)
+				ON CONFLICT DO NOTHING
+			`, role, p.Name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// HasPermission reports whether role has been granted permission.
+func (s *Store) HasPermission(ctx context.Context, role models.UserRole, permission string) (bool, error) {
+	grants, err := s.load(ctx)
+	if err != nil {
+		return false, err
+	}
+	return grants[role][permission], nil
+}
+
+// RolePermissions lists every role with its granted permissions.
+func (s *Store) RolePermissions(ctx context.Context) (map[models.UserRole][]string, error) {
+	grants, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[models.UserRole][]string, len(models.Roles))
+	for _, role := range models.Roles {
+		perms := []string{}
+		for p := range grants[role] {
+			perms = append(perms, p)
+		}
+		sort.Strings(perms)
+		result[role] = perms
+	}
+	return result, nil
+}
+
+// SetRolePermissions replaces everything granted to role.
+func (s *Store) SetRolePermissions(ctx context.Context, role models.UserRole, permissions []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM role_permissions WHERE role = $1`, role); err != nil {
+		return err
+	}
+	for _, p := range permissions {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO role_permissions (role, permission) VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, role, p)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.grants = nil
+	s.mu.Unlock()
+	return nil
+}
+
+// Known reports whether permission is one the application checks.
+func Known(permission string) bool {
+	for _, p := range Permissions {
+		if p.Name == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Store) load(ctx context.Context) (map[models.UserRole]map[string]bool, error) {
+	s.mu.RLock()
+	grants, loadedAt := s.grants, s.loadedAt
+	s.mu.RUnlock()
+	if grants != nil && time.Since(loadedAt) < cacheTTL {
+		return grants, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT role, permission FROM role_permissions`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grants = map[models.UserRole]map[string]bool{}
+	for rows.Next() {
+		var role models.UserRole
+		var permission string
+		if err := rows.Scan(&role, &permission); err != nil {
+			return nil, err
+		}
+		if grants[role] == nil {
+			grants[role] = map[string]bool{}
+		}
+		grants[role][permission] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.grants, s.loadedAt = grants, time.Now()
+	s.mu.Unlock()
+	return grants, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+// backend/internal/session/session.go
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/internal/securetoken"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+type Session struct {
+	ID         int       `json:"id"`
+	UserID     int       `json:"user_id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Store persists login sessions and their rotating refresh tokens.
+type Store struct {
+	db *sql.DB
+	// refreshTTL is how long a refresh token stays usable without being rotated.
+	refreshTTL time.Duration
+}
+
+func NewStore(db *sql.DB, refreshTTL time.Duration) *Store {
+	return &Store{db: db, refreshTTL: refreshTTL}
+}
+
+// Create starts a new session for the user and returns it with its first refresh token.
+func (s *Store) Create(ctx context.Context, userID int, userAgent, ipAddress string) (*Session, string, error) {
+	token, hash, err := securetoken.New()
+	if err != nil {
+		return nil, "", err
+	}
+
+	sess := &Session{UserID: userID, UserAgent: userAgent, IPAddress: ipAddress}
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO sessions (user_id, refresh_token_hash, user_agent, ip_address, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, expires_at, last_used_at, created_at
+	`, userID, hash, userAgent, ipAddress, time.Now().Add(s.refreshTTL)).Scan(
+		&sess.ID, &sess.ExpiresAt, &sess.LastUsedAt, &sess.CreatedAt)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return sess, token, nil
+}
+
+// Rotate exchanges a refresh token for a new one. Presenting a token that has
+// already been rotated revokes the whole session, since it means the token
+// was copied.
+func (s *Store) Rotate(ctx context.Context, refreshToken, userAgent, ipAddress string) (*Session, string, error) {
+	oldHash := securetoken.Hash(refreshToken)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer tx.Rollback()
+
+	var sess Session
+	var revokedAt sql.NullTime
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, user_id, expires_at, revoked_at, created_at
+		FROM sessions WHERE refresh_token_hash = $1
+		FOR UPDATE
+	`, oldHash).Scan(&sess.ID, &sess.UserID, &sess.ExpiresAt, &revokedAt, &sess.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		res, err := tx.ExecContext(ctx, `
+			UPDATE sessions SET revoked_at = NOW()
+			WHERE previous_token_hash = $1 AND revoked_at IS NULL
+		`, oldHash)
+		if err != nil {
+			return nil, "", err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			if err := tx.Commit(); err != nil {
+				return nil, "", err
+			}
+			return nil, "", ErrRefreshTokenReused
+		}
+		return nil, "", ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if revokedAt.Valid || time.Now().After(sess.ExpiresAt) {
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	token, newHash, err := securetoken.New()
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE sessions SET refresh_token_hash = $1, previous_token_hash = $2,
+			user_agent = $3, ip_address = $4, expires_at = $5, last_used_at = NOW()
+		WHERE id = $6
+		RETURNING expires_at, last_used_at
+	`, newHash, oldHash, userAgent, ipAddress, time.Now().Add(s.refreshTTL), sess.ID).Scan(
+		&sess.ExpiresAt, &sess.LastUsedAt)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, "", err
+	}
+
+	sess.UserAgent = userAgent
+	sess.IPAddress = ipAddress
+	return &sess, token, nil
+}
+
+// Revoke ends a single session. Access tokens issued for it stop working immediately.
+func (s *Store) Revoke(ctx context.Context, sessionID int) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE sessions SET revoked_at = NOW()
+		WHERE id = $1 AND revoked_at IS NULL
+	`, sessionID)
+	return err
+}
+
+// RevokeAll ends every active session belonging to the user.
+func (s *Store) RevokeAll(ctx context.Context, userID int) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE sessions SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// RevokeOthers ends every active session of the user except keepID, e.g.
+// after a password change made from that session.
+func (s *Store) RevokeOthers(ctx context.Context, userID, keepID int) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE sessions SET revoked_at = NOW()
+		WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL
+	`, userID, keepID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// IsActive reports whether the session exists, has not been revoked and has not expired.
+func (s *Store) IsActive(ctx context.Context, sessionID int) (bool, error) {
+	var active bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM sessions
+			WHERE id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		)
+	`, sessionID).Scan(&active)
+	return active, err
+}
@@ -0,0 +1,150 @@
+// backend/internal/session/session_test.go
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"backend/internal/database/dbtest"
+)
+
+func newTestStore(t *testing.T) (*Store, int) {
+	t.Helper()
+	db := dbtest.Open(t)
+	userID, _ := dbtest.CreateUser(t, db)
+	return NewStore(db, time.Hour), userID
+}
+
+func isActive(t *testing.T, s *Store, id int) bool {
+	t.Helper()
+	active, err := s.IsActive(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return active
+}
+
+func TestRotateIssuesANewToken(t *testing.T) {
+	s, userID := newTestStore(t)
+	ctx := context.Background()
+
+	sess, first, err := s.Create(ctx, userID, "curl", "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, second, err := s.Rotate(ctx, first, "firefox", "10.0.0.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rotated.ID != sess.ID || rotated.UserID != userID {
+		t.Errorf("Rotate returned session %d of user %d, want %d of %d", rotated.ID, rotated.UserID, sess.ID, userID)
+	}
+	if second == first || second == "" {
+		t.Error("Rotate did not issue a new refresh token")
+	}
+	if rotated.UserAgent != "firefox" || rotated.IPAddress != "10.0.0.2" {
+		t.Errorf("Rotate kept the old client details: %+v", rotated)
+	}
+	if _, _, err := s.Rotate(ctx, second, "firefox", "10.0.0.2"); err != nil {
+		t.Errorf("the rotated token was rejected: %v", err)
+	}
+}
+
+func TestRotateDetectsReuse(t *testing.T) {
+	s, userID := newTestStore(t)
+	ctx := context.Background()
+
+	sess, stolen, err := s.Create(ctx, userID, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, current, err := s.Rotate(ctx, stolen, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := s.Rotate(ctx, stolen, "", ""); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("replaying a rotated token: %v, want ErrRefreshTokenReused", err)
+	}
+	if isActive(t, s, sess.ID) {
+		t.Error("the session survived refresh token reuse")
+	}
+	if _, _, err := s.Rotate(ctx, current, "", ""); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("the legitimate token after reuse: %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestRotateRejects(t *testing.T) {
+	s, userID := newTestStore(t)
+	ctx := context.Background()
+
+	_, revoked, err := s.Create(ctx, userID, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.RevokeAll(ctx, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	expiring := NewStore(s.db, -time.Minute)
+	_, expired, err := expiring.Create(ctx, userID, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"unknown token", "not-a-token"},
+		{"empty token", ""},
+		{"revoked session", revoked},
+		{"expired session", expired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := s.Rotate(ctx, tt.token, "", ""); !errors.Is(err, ErrInvalidRefreshToken) {
+				t.Errorf("Rotate = %v, want ErrInvalidRefreshToken", err)
+			}
+		})
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	s, userID := newTestStore(t)
+	ctx := context.Background()
+
+	create := func() *Session {
+		sess, _, err := s.Create(ctx, userID, "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sess
+	}
+	a, b, c, d := create(), create(), create(), create()
+
+	if err := s.Revoke(ctx, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	if isActive(t, s, a.ID) || !isActive(t, s, b.ID) {
+		t.Error("Revoke must end exactly one session")
+	}
+
+	if n, err := s.RevokeOthers(ctx, userID, b.ID); err != nil || n != 2 {
+		t.Errorf("RevokeOthers = %d, %v; want 2 sessions ended", n, err)
+	}
+	if !isActive(t, s, b.ID) || isActive(t, s, c.ID) || isActive(t, s, d.ID) {
+		t.Error("RevokeOthers must keep only the current session")
+	}
+
+	if n, err := s.RevokeAll(ctx, userID); err != nil || n != 1 {
+		t.Errorf("RevokeAll = %d, %v; want 1 session ended", n, err)
+	}
+	if isActive(t, s, b.ID) {
+		t.Error("RevokeAll left a session active")
+	}
+}
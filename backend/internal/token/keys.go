@@ -0,0 +1,164 @@
+// backend/internal/token/keys.go
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"backend/internal/config"
+)
+
+// Load builds the token service from the configured keys:
+//
+//   - KeysDir (JWT_KEYS_DIR): directory of PEM files named <kid>.pem. Private
+//     keys (PKCS#8 Ed25519 or RSA) can sign; public keys only verify, which is
+//     how retired keys are kept around during rotation.
+//   - PrivateKey (JWT_PRIVATE_KEY): a single inline PEM private key, for
+//     platforms that only offer environment secrets. Its kid is ActiveKeyID or
+//     the key's thumbprint, and must not collide with a kid from KeysDir.
+//   - ActiveKeyID (JWT_ACTIVE_KID): which key signs new tokens. Optional when
+//     exactly one private key is configured.
+//
+// Without any key an ephemeral Ed25519 key is generated, so tokens do not
+// survive a restart. That is only suitable for local development, and the
+// config package refuses it in production.
+func Load(cfg config.JWT) (*Service, error) {
+	var keys []*Key
+
+	if dir := cfg.KeysDir; dir != "" {
+		loaded, err := loadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, loaded...)
+	}
+
+	activeID := cfg.ActiveKeyID
+
+	if inline := cfg.PrivateKey; inline != "" {
+		k, err := ParsePEM([]byte(inline))
+		if err != nil {
+			return nil, fmt.Errorf("JWT_PRIVATE_KEY: %w", err)
+		}
+		k.ID = activeID
+		if k.ID == "" {
+			k.ID = Thumbprint(k)
+		}
+		for _, existing := range keys {
+			if existing.ID == k.ID {
+				return nil, fmt.Errorf("JWT_PRIVATE_KEY has kid %q, which %s already uses; give one of them another ID", k.ID, cfg.KeysDir)
+			}
+		}
+		keys = append(keys, k)
+	}
+
+	if len(keys) == 0 {
+		k, err := GenerateEd25519()
+		if err != nil {
+			return nil, err
+		}
+		slog.Warn("No JWT signing keys configured; using an ephemeral key, tokens will not survive a restart",
+			"key_id", k.ID)
+		keys = append(keys, k)
+	}
+
+	if activeID == "" {
+		var signers []string
+		for _, k := range keys {
+			if k.PrivateKey != nil {
+				signers = append(signers, k.ID)
+			}
+		}
+		if len(signers) != 1 {
+			return nil, fmt.Errorf("JWT_ACTIVE_KID must be set when %d signing keys are configured", len(signers))
+		}
+		activeID = signers[0]
+	}
+
+	return NewService(keys, activeID)
+}
+
+// GenerateEd25519 creates a fresh signing key identified by its thumbprint.
+func GenerateEd25519() (*Key, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	k := &Key{Algorithm: AlgEdDSA, PrivateKey: priv, PublicKey: pub}
+	k.ID = Thumbprint(k)
+	return k, nil
+}
+
+func loadDir(dir string) ([]*Key, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	keys := make([]*Key, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key %s: %w", path, err)
+		}
+		k, err := ParsePEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", path, err)
+		}
+		k.ID = strings.TrimSuffix(filepath.Base(path), ".pem")
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// ParsePEM reads an Ed25519 or RSA key. Private keys may be PKCS#8 or PKCS#1,
+// public keys must be PKIX.
+func ParsePEM(data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := parsed.(type) {
+	case ed25519.PrivateKey:
+		return &Key{Algorithm: AlgEdDSA, PrivateKey: key, PublicKey: key.Public()}, nil
+	case *rsa.PrivateKey:
+		if key.N.BitLen() < 2048 {
+			return nil, errors.New("RSA keys must be at least 2048 bits")
+		}
+		return &Key{Algorithm: AlgRS256, PrivateKey: key, PublicKey: key.Public()}, nil
+	case ed25519.PublicKey:
+		return &Key{Algorithm: AlgEdDSA, PublicKey: key}, nil
+	case *rsa.PublicKey:
+		return &Key{Algorithm: AlgRS256, PublicKey: key}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+}
@@ -0,0 +1,118 @@
+// backend/internal/token/token.go
+package token
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// Issuer is set on every token Craftora signs.
+	Issuer = "craftora"
+	// AccessAudience identifies API access tokens, as opposed to other signed
+	// artifacts such as admin invitations.
+	AccessAudience = "craftora-api"
+)
+
+const (
+	AlgEdDSA = "EdDSA"
+	AlgRS256 = "RS256"
+)
+
+// Key is a signing or verification key identified by its kid.
+// Retired keys have no private part and only verify tokens issued before rotation.
+type Key struct {
+	ID         string
+	Algorithm  string
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+}
+
+func (k *Key) method() jwt.SigningMethod {
+	if k.Algorithm == AlgRS256 {
+		return jwt.SigningMethodRS256
+	}
+	return jwt.SigningMethodEdDSA
+}
+
+// Service signs tokens with the active key and verifies them against every
+// configured key, so tokens signed before a rotation stay valid until they expire.
+type Service struct {
+	active *Key
+	keys   map[string]*Key
+}
+
+func NewService(keys []*Key, activeID string) (*Service, error) {
+	s := &Service{keys: make(map[string]*Key, len(keys))}
+	for _, k := range keys {
+		if k.ID == "" {
+			return nil, errors.New("token: key without an ID")
+		}
+		if _, dup := s.keys[k.ID]; dup {
+			return nil, fmt.Errorf("token: duplicate key ID %q", k.ID)
+		}
+		s.keys[k.ID] = k
+	}
+
+	active, ok := s.keys[activeID]
+	if !ok {
+		return nil, fmt.Errorf("token: active key %q not found", activeID)
+	}
+	if active.PrivateKey == nil {
+		return nil, fmt.Errorf("token: active key %q has no private key", activeID)
+	}
+	s.active = active
+
+	return s, nil
+}
+
+// Sign returns a compact JWS for the claims, tagged with the active key's kid.
+func (s *Service) Sign(claims jwt.Claims) (string, error) {
+	t := jwt.NewWithClaims(s.active.method(), claims)
+	t.Header["kid"] = s.active.ID
+	return t.SignedString(s.active.PrivateKey)
+}
+
+// Parse verifies a token's signature and issuer and decodes it into claims.
+// Extra options such as jwt.WithAudience narrow what is accepted.
+func (s *Service) Parse(tokenString string, claims jwt.Claims, opts ...jwt.ParserOption) error {
+	opts = append([]jwt.ParserOption{
+		jwt.WithValidMethods([]string{AlgEdDSA, AlgRS256}),
+		jwt.WithIssuer(Issuer),
+		jwt.WithExpirationRequired(),
+	}, opts...)
+
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := s.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key ID %q", kid)
+		}
+		if t.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("unexpected signing method %q for key %q", t.Method.Alg(), kid)
+		}
+		return key.PublicKey, nil
+	}, opts...)
+	return err
+}
+
+// ActiveKeyID returns the kid new tokens are signed with.
+func (s *Service) ActiveKeyID() string {
+	return s.active.ID
+}
+
+// JWKS returns the public half of every key as an RFC 7517 key set.
+func (s *Service) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, k := range s.keys {
+		if jwk, ok := publicJWK(k); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
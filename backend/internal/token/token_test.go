@@ -0,0 +1,314 @@
+// backend/internal/token/token_test.go
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"backend/internal/config"
+)
+
+func newKey(t *testing.T) *Key {
+	t.Helper()
+	k, err := GenerateEd25519()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func newService(t *testing.T, keys []*Key, activeID string) *Service {
+	t.Helper()
+	s, err := NewService(keys, activeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// retired drops the private half, as rotation does with a replaced key.
+func retired(k *Key) *Key {
+	return &Key{ID: k.ID, Algorithm: k.Algorithm, PublicKey: k.PublicKey}
+}
+
+func validClaims() *jwt.RegisteredClaims {
+	return &jwt.RegisteredClaims{
+		Issuer:    Issuer,
+		Subject:   "42",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}
+}
+
+func sign(t *testing.T, s *Service, claims jwt.Claims) string {
+	t.Helper()
+	signed, err := s.Sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestSignTagsTokensWithTheActiveKid(t *testing.T) {
+	k := newKey(t)
+	s := newService(t, []*Key{k}, k.ID)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(sign(t, s, validClaims()), &jwt.RegisteredClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Header["kid"] != k.ID || parsed.Header["alg"] != AlgEdDSA {
+		t.Errorf("header = %v, want kid %s and alg %s", parsed.Header, k.ID, AlgEdDSA)
+	}
+}
+
+func TestRotationKeepsOldTokensValid(t *testing.T) {
+	previous, next := newKey(t), newKey(t)
+
+	before := newService(t, []*Key{previous}, previous.ID)
+	after := newService(t, []*Key{retired(previous), next}, next.ID)
+	dropped := newService(t, []*Key{next}, next.ID)
+
+	oldToken := sign(t, before, validClaims())
+	newToken := sign(t, after, validClaims())
+
+	tests := []struct {
+		name    string
+		service *Service
+		token   string
+		wantErr bool
+	}{
+		{"old token, before rotation", before, oldToken, false},
+		{"old token, retired key still published", after, oldToken, false},
+		{"new token, after rotation", after, newToken, false},
+		{"old token, retired key removed", dropped, oldToken, true},
+		{"new token, service that never had the key", before, newToken, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.service.Parse(tt.token, &jwt.RegisteredClaims{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	edKey := newKey(t)
+	rsaPriv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newService(t, []*Key{edKey}, edKey.ID)
+
+	// forge signs with an arbitrary method and key under a chosen kid.
+	forge := func(method jwt.SigningMethod, kid string, key interface{}, claims jwt.Claims) string {
+		tok := jwt.NewWithClaims(method, claims)
+		if kid != "" {
+			tok.Header["kid"] = kid
+		}
+		signed, err := tok.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	_, strangerKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	noExpiry := validClaims()
+	noExpiry.ExpiresAt = nil
+	expired := validClaims()
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	otherIssuer := validClaims()
+	otherIssuer.Issuer = "someone-else"
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"unknown kid", forge(jwt.SigningMethodEdDSA, "unknown", strangerKey, validClaims())},
+		{"missing kid", forge(jwt.SigningMethodEdDSA, "", edKey.PrivateKey, validClaims())},
+		{"known kid signed by another key", forge(jwt.SigningMethodEdDSA, edKey.ID, strangerKey, validClaims())},
+		{"RS256 under an EdDSA kid", forge(jwt.SigningMethodRS256, edKey.ID, rsaPriv, validClaims())},
+		{"HS256", forge(jwt.SigningMethodHS256, edKey.ID, []byte("secret"), validClaims())},
+		{"alg none", forge(jwt.SigningMethodNone, edKey.ID, jwt.UnsafeAllowNoneSignatureType, validClaims())},
+		{"no expiry", sign(t, s, noExpiry)},
+		{"expired", sign(t, s, expired)},
+		{"wrong issuer", sign(t, s, otherIssuer)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.Parse(tt.token, &jwt.RegisteredClaims{}); err == nil {
+				t.Error("Parse accepted the token")
+			}
+		})
+	}
+}
+
+func TestParseAppliesExtraOptions(t *testing.T) {
+	k := newKey(t)
+	s := newService(t, []*Key{k}, k.ID)
+	claims := validClaims()
+	claims.Audience = jwt.ClaimStrings{"invitation"}
+	signed := sign(t, s, claims)
+
+	if err := s.Parse(signed, &jwt.RegisteredClaims{}, jwt.WithAudience(AccessAudience)); err == nil {
+		t.Error("Parse accepted a token for another audience")
+	}
+	if err := s.Parse(signed, &jwt.RegisteredClaims{}, jwt.WithAudience("invitation")); err != nil {
+		t.Errorf("Parse rejected the right audience: %v", err)
+	}
+}
+
+func TestNewServiceRejects(t *testing.T) {
+	a, b := newKey(t), newKey(t)
+	duplicate := newKey(t)
+	duplicate.ID = a.ID
+
+	tests := []struct {
+		name     string
+		keys     []*Key
+		activeID string
+	}{
+		{"key without an ID", []*Key{{Algorithm: AlgEdDSA, PrivateKey: a.PrivateKey, PublicKey: a.PublicKey}}, ""},
+		{"duplicate kid", []*Key{a, duplicate}, a.ID},
+		{"unknown active kid", []*Key{a, b}, "missing"},
+		{"active key without a private half", []*Key{retired(a), b}, a.ID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewService(tt.keys, tt.activeID); err == nil {
+				t.Error("NewService accepted the keys")
+			}
+		})
+	}
+}
+
+func TestJWKSPublishesEveryPublicKey(t *testing.T) {
+	a, b := newKey(t), newKey(t)
+	s := newService(t, []*Key{a, retired(b)}, a.ID)
+
+	set := s.JWKS()
+	if len(set.Keys) != 2 {
+		t.Fatalf("JWKS has %d keys, want 2", len(set.Keys))
+	}
+	if set.Keys[0].Kid > set.Keys[1].Kid {
+		t.Error("JWKS keys are not sorted by kid")
+	}
+	for _, jwk := range set.Keys {
+		if jwk.Kty != "OKP" || jwk.Crv != "Ed25519" || jwk.Alg != AlgEdDSA || jwk.Use != "sig" || jwk.X == "" {
+			t.Errorf("unexpected JWK %+v", jwk)
+		}
+	}
+}
+
+// RFC 8037 appendix A.3.
+func TestThumbprintMatchesRFC8037(t *testing.T) {
+	x, _ := base64.RawURLEncoding.DecodeString("11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo")
+	k := &Key{Algorithm: AlgEdDSA, PublicKey: ed25519.PublicKey(x)}
+
+	if got, want := Thumbprint(k), "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k"; got != want {
+		t.Errorf("Thumbprint = %s, want %s", got, want)
+	}
+}
+
+func writePEM(t *testing.T, path string, k *Key, public bool) string {
+	t.Helper()
+	var block *pem.Block
+	if public {
+		der, err := x509.MarshalPKIXPublicKey(k.PublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	} else {
+		der, err := x509.MarshalPKCS8PrivateKey(k.PrivateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+	data := pem.EncodeToMemory(block)
+	if path != "" {
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return string(data)
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	current, previous, spare := newKey(t), newKey(t), newKey(t)
+	writePEM(t, filepath.Join(dir, "2026-10.pem"), current, false)
+	writePEM(t, filepath.Join(dir, "2026-04.pem"), previous, true)
+
+	tests := []struct {
+		name       string
+		cfg        config.JWT
+		wantActive string
+		wantErr    string
+	}{
+		{"single private key in the directory is active", config.JWT{KeysDir: dir}, "2026-10", ""},
+		{"inline key named by the active kid", config.JWT{PrivateKey: writePEM(t, "", spare, false), ActiveKeyID: "inline"}, "inline", ""},
+		{"inline key without a kid uses its thumbprint", config.JWT{PrivateKey: writePEM(t, "", spare, false)}, Thumbprint(spare), ""},
+		{"inline key next to the directory", config.JWT{KeysDir: dir, PrivateKey: writePEM(t, "", spare, false), ActiveKeyID: "inline"}, "inline", ""},
+		{"inline kid collides with a file", config.JWT{KeysDir: dir, PrivateKey: writePEM(t, "", spare, false), ActiveKeyID: "2026-04"}, "", "already uses"},
+		{"two signers need an active kid", config.JWT{KeysDir: dir, PrivateKey: writePEM(t, "", spare, false)}, "", "JWT_ACTIVE_KID"},
+		{"active kid names a retired key", config.JWT{KeysDir: dir, ActiveKeyID: "2026-04"}, "", "no private key"},
+		{"inline key is not PEM", config.JWT{PrivateKey: "not a key"}, "", "JWT_PRIVATE_KEY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Load(tt.cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load error = %v, want one mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.ActiveKeyID() != tt.wantActive {
+				t.Errorf("active kid = %s, want %s", s.ActiveKeyID(), tt.wantActive)
+			}
+		})
+	}
+}
+
+func TestLoadWithoutKeysGeneratesEphemeralKey(t *testing.T) {
+	s, err := Load(config.JWT{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Parse(sign(t, s, validClaims()), &jwt.RegisteredClaims{}); err != nil {
+		t.Errorf("ephemeral key cannot verify its own token: %v", err)
+	}
+}
+
+func TestParsePEMRejectsShortRSAKeys(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(priv)})
+	if _, err := ParsePEM(data); err == nil {
+		t.Error("ParsePEM accepted a 1024-bit RSA key")
+	}
+}
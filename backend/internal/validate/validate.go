@@ -0,0 +1,202 @@
+// backend/internal/validate/validate.go
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Rule codes reported in FieldError.Code.
+const (
+	CodeRequired   = "required"
+	CodeInvalid    = "invalid"
+	CodeTooShort   = "too_short"
+	CodeTooLong    = "too_long"
+	CodeOutOfRange = "out_of_range"
+)
+
+// FieldError describes one failed rule, naming the field as it appears in JSON.
+type FieldError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+// Struct checks v, a struct or pointer to one, against the rules in its
+// `validate` tags and returns every failure in field order. Rules are
+// comma-separated:
+//
+//	required    must not be the zero value (blank for strings)
+//	min=N       strings and slices: at least N long; numbers: at least N
+//	max=N       strings and slices: at most N long; numbers: at most N
+//	gt=N        numbers: greater than N
+//	email       a single email address
+//	oneof=a b   one of the space-separated values
+//
+// Pointer fields are only checked when set, unless they are required.
+func Struct(v interface{}) []FieldError {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs []FieldError
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("validate")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		name := jsonName(field)
+		if fe, ok := checkField(name, rv.Field(i), tag); !ok {
+			errs = append(errs, fe)
+		}
+	}
+	return errs
+}
+
+// checkField applies the rules in tag to value and reports the first failure.
+func checkField(name string, value reflect.Value, tag string) (FieldError, bool) {
+	rules := strings.Split(tag, ",")
+
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			for _, rule := range rules {
+				if rule == "required" {
+					return FieldError{name, CodeRequired, name + " is required"}, false
+				}
+			}
+			return FieldError{}, true
+		}
+		value = value.Elem()
+	}
+
+	for _, rule := range rules {
+		key, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch key {
+		case "required":
+			if isBlank(value) {
+				return FieldError{name, CodeRequired, name + " is required"}, false
+			}
+		case "min", "max", "gt":
+			if key != "gt" && hasLength(value) && isBlank(value) {
+				// Empty optional strings and lists are left to "required".
+				continue
+			}
+			if fe, ok := checkBound(name, value, key, arg); !ok {
+				return fe, false
+			}
+		case "email":
+			if s := value.String(); s != "" {
+				if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s {
+					return FieldError{name, CodeInvalid, name + " must be a valid email address"}, false
+				}
+			}
+		case "oneof":
+			if s := fmt.Sprint(value.Interface()); s != "" {
+				options := strings.Fields(arg)
+				if !contains(options, s) {
+					return FieldError{name, CodeInvalid, name + " must be one of: " + strings.Join(options, ", ")}, false
+				}
+			}
+		default:
+			panic("validate: unknown rule " + key + " on " + name)
+		}
+	}
+	return FieldError{}, true
+}
+
+func checkBound(name string, value reflect.Value, rule, arg string) (FieldError, bool) {
+	limit, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		panic("validate: bad " + rule + " argument on " + name)
+	}
+
+	switch value.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		n := value.Len()
+		unit := "items"
+		if value.Kind() == reflect.String {
+			n = utf8.RuneCountInString(value.String())
+			unit = "characters"
+		}
+		switch {
+		case rule == "min" && float64(n) < limit:
+			return FieldError{name, CodeTooShort, fmt.Sprintf("%s must be at least %s %s", name, arg, unit)}, false
+		case rule == "max" && float64(n) > limit:
+			return FieldError{name, CodeTooLong, fmt.Sprintf("%s must be at most %s %s", name, arg, unit)}, false
+		}
+		return FieldError{}, true
+	}
+
+	var n float64
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = value.Float()
+	default:
+		panic("validate: " + rule + " on unsupported field " + name)
+	}
+
+	switch {
+	case rule == "min" && n < limit:
+		return FieldError{name, CodeOutOfRange, fmt.Sprintf("%s must be at least %s", name, arg)}, false
+	case rule == "max" && n > limit:
+		return FieldError{name, CodeOutOfRange, fmt.Sprintf("%s must be at most %s", name, arg)}, false
+	case rule == "gt" && n <= limit:
+		return FieldError{name, CodeOutOfRange, fmt.Sprintf("%s must be greater than %s", name, arg)}, false
+	}
+	return FieldError{}, true
+}
+
+func isBlank(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+func hasLength(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		return true
+	}
+	return false
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
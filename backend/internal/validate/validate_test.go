@@ -0,0 +1,132 @@
+// backend/internal/validate/validate_test.go
+package validate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type signup struct {
+	Email    string   `json:"email" validate:"required,email"`
+	Password string   `json:"password" validate:"required,min=8,max=72"`
+	Name     string   `json:"name,omitempty" validate:"max=5"`
+	Role     string   `json:"role" validate:"oneof=buyer seller"`
+	Age      int      `json:"age" validate:"min=18,max=120"`
+	Price    float64  `json:"price" validate:"gt=0"`
+	Tags     []string `json:"tags" validate:"min=1,max=2"`
+	Rating   *int     `json:"rating" validate:"min=1,max=5"`
+	Stock    *int     `json:"stock" validate:"required"`
+	NoJSON   string   `validate:"required"`
+	Skipped  string   `json:"skipped" validate:"-"`
+	internal string   `validate:"required"`
+}
+
+func intPtr(n int) *int { return &n }
+
+func valid() signup {
+	return signup{
+		Email:    "ana@example.com",
+		Password: "correct horse",
+		Role:     "buyer",
+		Age:      30,
+		Price:    1,
+		Tags:     []string{"craft"},
+		Stock:    intPtr(3),
+		NoJSON:   "x",
+	}
+}
+
+func TestStruct(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*signup)
+		want   []FieldError
+	}{
+		{"valid", func(*signup) {}, nil},
+		{"blank required string", func(s *signup) { s.Email = "   " },
+			[]FieldError{{"email", CodeRequired, "email is required"}}},
+		{"invalid email", func(s *signup) { s.Email = "Ana <ana@example.com>" },
+			[]FieldError{{"email", CodeInvalid, "email must be a valid email address"}}},
+		{"string too short", func(s *signup) { s.Password = "short" },
+			[]FieldError{{"password", CodeTooShort, "password must be at least 8 characters"}}},
+		{"string length counts runes", func(s *signup) { s.Name = "ééééé" }, nil},
+		{"string too long", func(s *signup) { s.Name = "abcdef" },
+			[]FieldError{{"name", CodeTooLong, "name must be at most 5 characters"}}},
+		{"empty optional string skips bounds", func(s *signup) { s.Name = "" }, nil},
+		{"oneof", func(s *signup) { s.Role = "admin" },
+			[]FieldError{{"role", CodeInvalid, "role must be one of: buyer, seller"}}},
+		{"empty oneof is left to required", func(s *signup) { s.Role = "" }, nil},
+		{"number below min", func(s *signup) { s.Age = 17 },
+			[]FieldError{{"age", CodeOutOfRange, "age must be at least 18"}}},
+		{"number above max", func(s *signup) { s.Age = 121 },
+			[]FieldError{{"age", CodeOutOfRange, "age must be at most 120"}}},
+		{"gt is exclusive", func(s *signup) { s.Price = 0 },
+			[]FieldError{{"price", CodeOutOfRange, "price must be greater than 0"}}},
+		{"too many items", func(s *signup) { s.Tags = []string{"a", "b", "c"} },
+			[]FieldError{{"tags", CodeTooLong, "tags must be at most 2 items"}}},
+		{"nil optional pointer", func(s *signup) { s.Rating = nil }, nil},
+		{"set optional pointer is checked", func(s *signup) { s.Rating = intPtr(6) },
+			[]FieldError{{"rating", CodeOutOfRange, "rating must be at most 5"}}},
+		{"nil required pointer", func(s *signup) { s.Stock = nil },
+			[]FieldError{{"stock", CodeRequired, "stock is required"}}},
+		{"required also applies to the pointed-to value", func(s *signup) { s.Stock = intPtr(0) },
+			[]FieldError{{"stock", CodeRequired, "stock is required"}}},
+		{"field without a JSON name", func(s *signup) { s.NoJSON = "" },
+			[]FieldError{{"NoJSON", CodeRequired, "NoJSON is required"}}},
+		{"first failing rule per field, fields in order", func(s *signup) { s.Email = ""; s.Age = 0 },
+			[]FieldError{
+				{"email", CodeRequired, "email is required"},
+				{"age", CodeOutOfRange, "age must be at least 18"},
+			}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := valid()
+			tt.modify(&s)
+			if got := Struct(&s); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Struct = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructIgnoresNonStructs(t *testing.T) {
+	var nilPtr *signup
+	for _, v := range []interface{}{nil, nilPtr, 42, "text", []signup{{}}} {
+		if errs := Struct(v); errs != nil {
+			t.Errorf("Struct(%#v) = %v, want nil", v, errs)
+		}
+	}
+}
+
+func TestStructPanicsOnBadTags(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{"unknown rule", &struct {
+			A string `validate:"requird"`
+		}{}, "unknown rule requird"},
+		{"bound without a number", &struct {
+			A int `validate:"min=ten"`
+		}{}, "bad min argument"},
+		{"bound on an unsupported kind", &struct {
+			A bool `validate:"max=1"`
+		}{}, "unsupported field"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				r := recover()
+				if msg, _ := r.(string); !strings.Contains(msg, tt.want) {
+					t.Errorf("panic = %v, want one mentioning %q", r, tt.want)
+				}
+			}()
+			Struct(tt.v)
+		})
+	}
+}
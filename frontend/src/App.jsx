@@ -4,6 +4,7 @@
 import { BrowserRouter, Routes, Route, Navigate } from 'react-router-dom'
 import { useState, useEffect } from 'react'
 import Navbar from './components/Navbar'
+import { logout } from './api/axios'
 import Home from './pages/Home'
 import AuthPage from './pages/AuthPage'
 import ProductDetail from './pages/ProductDetail'
@@ -40,7 +41,9 @@ function App() {
   }, [])
 
   const handleLogout = () => {
+    logout().catch(() => {})
     localStorage.removeItem('token')
+    localStorage.removeItem('refresh_token')
     localStorage.removeItem('user')
     setUser(null)
   }
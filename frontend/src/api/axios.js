@@ -19,9 +19,49 @@ api.interceptors.request.use((config) => {
   return config
 })
 
+// Exchange the refresh token for a new pair once when an access token expires
+api.interceptors.response.use(
+  (response) => response,
+  async (error) => {
+    const original = error.config
+    const refreshToken = localStorage.getItem('refresh_token')
+    if (error.response?.status !== 401 || original._retried || !refreshToken || original.url === '/auth/refresh') {
+      return Promise.reject(error)
+    }
+    original._retried = true
+    try {
+      const { data } = await api.post('/auth/refresh', { refresh_token: refreshToken })
+      localStorage.setItem('token', data.token)
+      localStorage.setItem('refresh_token', data.refresh_token)
+      original.headers.Authorization = `Bearer ${data.token}`
+      return api(original)
+    } catch (refreshError) {
+      localStorage.removeItem('token')
+      localStorage.removeItem('refresh_token')
+      return Promise.reject(error)
+    }
+  }
+)
+
 // Auth APIs
 export const register = (data) => api.post('/auth/register', data)
 export const login = (data) => api.post('/auth/login', data)
+export const loginTwoFactor = (data) => api.post('/auth/login/2fa', data)
+export const logout = () =>
+  api.post('/auth/logout', null, { headers: { Authorization: `Bearer ${localStorage.getItem('token')}` } })
+
+// Account APIs
+export const getMe = () => api.get('/me')
+export const updateMe = (data) => api.put('/me', data)
+export const changePassword = (data) => api.put('/me/password', data)
+export const exportMyData = (format = 'json') => api.get('/me/export', { params: { format }, responseType: 'blob' })
+export const deleteAccount = (password) => api.delete('/me', { data: { password } })
+
+// Address APIs
+export const getAddresses = () => api.get('/addresses')
+export const createAddress = (data) => api.post('/addresses', data)
+export const updateAddress = (id, data) => api.put(`/addresses/${id}`, data)
+export const deleteAddress = (id) => api.delete(`/addresses/${id}`)
 
 // Product APIs
 export const getProducts = (params) => api.get('/products', { params })
@@ -30,8 +70,12 @@ export const getCategories = () => api.get('/categories')
 export const createProduct = (data) => api.post('/artisan/products', data)
 export const updateProduct = (id, data) => api.put(`/artisan/products/${id}`, data)
 
+// A fresh key per user action; retries of the same request reuse it, so the
+// server never creates the order, review or call twice
+const idempotent = () => ({ headers: { 'Idempotency-Key': crypto.randomUUID() } })
+
 // Order APIs
-export const createOrder = (data) => api.post('/orders', data)
+export const createOrder = (data) => api.post('/orders', data, idempotent())
 export const getUserOrders = () => api.get('/orders')
 export const getOrderDetails = (id) => api.get(`/orders/${id}`)
 export const getArtisanOrders = () => api.get('/artisan/orders')
@@ -44,7 +88,7 @@ export const updateArtisanProfile = (data) => api.put('/artisan/profile', data)
 export const getArtisanProfile = (id) => api.get(`/artisans/${id}`)
 
 // Review APIs
-export const createReview = (data) => api.post('/reviews', data)
+export const createReview = (data) => api.post('/reviews', data, idempotent())
 export const getProductReviews = (productId) => api.get(`/products/${productId}/reviews`)
 
 // AI APIs
@@ -61,7 +105,7 @@ export const createCategory = (data) => api.post('/admin/categories', data)
 export const getAnalytics = () => api.get('/admin/analytics')
 
 // Video Call APIs
-export const requestVideoCall = (data) => api.post('/video-call/request', data);
+export const requestVideoCall = (data) => api.post('/video-call/request', data, idempotent());
 
 export const getPendingVideoCalls = () => api.get('/video-call/pending');
 
@@ -118,13 +118,14 @@ export default function AddProduct() {
         throw new Error('Please fill all required fields')
       }
 
+      // The platform fee is set by the server; the field above is only a preview.
+      const { platform_fee: _platformFee, ...fields } = formData
       const productData = {
-        ...formData,
+        ...fields,
         category_id: parseInt(formData.category_id),
         price: parseFloat(formData.price),
         material_cost: parseFloat(formData.material_cost) || 0,
         labor_cost: parseFloat(formData.labor_cost) || 0,
-        platform_fee: parseFloat(formData.platform_fee) || 0,
         crafting_time: parseInt(formData.crafting_time) || 24,
         stock: parseInt(formData.stock)
       }
@@ -287,7 +288,7 @@ export default function AddProduct() {
             </div>
 
             <div>
-              <label className="block text-gray-700 font-medium mb-2">Platform Fee (10% auto)</label>
+              <label className="block text-gray-700 font-medium mb-2">Platform Fee (estimate)</label>
               <input
                 type="number"
                 step="0.01"
@@ -1,7 +1,7 @@
 // ===== FILE 4: frontend/src/pages/AuthPage.jsx =====
 import { useState } from 'react'
 import { useNavigate } from 'react-router-dom'
-import { login, register } from '../api/axios'
+import { login, register, loginTwoFactor } from '../api/axios'
 
 export default function AuthPage({ setUser }) {
   const [isLogin, setIsLogin] = useState(true)
@@ -12,6 +12,8 @@ export default function AuthPage({ setUser }) {
     role: 'buyer'
   })
   const [error, setError] = useState('')
+  const [challenge, setChallenge] = useState(null)
+  const [otp, setOtp] = useState('')
   const [loading, setLoading] = useState(false)
   const navigate = useNavigate()
 
@@ -21,22 +23,37 @@ export default function AuthPage({ setUser }) {
     setLoading(true)
 
     try {
-      const response = isLogin
-        ? await login({ email: formData.email, password: formData.password })
-        : await register(formData)
+      const response = challenge
+        ? await loginTwoFactor({ challenge_token: challenge.challenge_token, code: otp })
+        : isLogin
+          ? await login({ email: formData.email, password: formData.password })
+          : await register({ email: formData.email, password: formData.password, name: formData.name })
+
+      // Accounts with two-factor authentication answer with a challenge first
+      if (response.data.two_factor_required) {
+        setChallenge(response.data)
+        return
+      }
+      if (response.data.recovery_codes) {
+        alert('Save these recovery codes somewhere safe:\n\n' + response.data.recovery_codes.join('\n'))
+      }
 
-      const { token, user } = response.data;
+      const { token, refresh_token, user } = response.data;
       console.log(response.data)
       console.log(user)
       // Frontend-only: add isOnboarded flag if missing
       user.isOnboarded = user.isOnboarded || false;
 
       localStorage.setItem("token", token);
+      localStorage.setItem("refresh_token", refresh_token);
       localStorage.setItem("user", JSON.stringify(user));
       setUser(user);
 
-      // Redirect based on role and onboarding
-      if (user.role === "artisan") {
+      // Redirect based on role and onboarding. Everyone registers as a buyer;
+      // artisans upgrade by completing onboarding.
+      if (!isLogin && formData.role === "artisan") {
+        navigate("/artisan/onboard");
+      } else if (user.role === "artisan") {
         navigate(user.isOnboarded ? "/artisan/dashboard" : "/artisan/onboard");
       } else if (user.role === "admin") {
         navigate("/admin");
@@ -73,77 +90,90 @@ export default function AuthPage({ setUser }) {
             </div>
           )}
 
-          {!isLogin && (
-            <div className="mb-4">
-              <label className="block text-gray-700 font-medium mb-2">Full Name</label>
+          {challenge ? (
+            <div className="mb-6">
+              {challenge.enrollment_required && (
+                <p className="text-gray-700 mb-4">
+                  Your account requires two-factor authentication. Add this key to your authenticator app:{' '}
+                  <span className="font-mono break-all">{challenge.secret}</span>
+                </p>
+              )}
+              <label className="block text-gray-700 font-medium mb-2">Authentication Code</label>
               <input
                 type="text"
-                value={formData.name}
-                onChange={(e) => setFormData({ ...formData, name: e.target.value })}
+                inputMode="numeric"
+                autoComplete="one-time-code"
+                value={otp}
+                onChange={(e) => setOtp(e.target.value)}
                 className="w-full px-4 py-3 border border-gray-300 rounded-lg focus:outline-none focus:ring-2 focus:ring-[#ff5000]"
                 required
               />
             </div>
-          )}
+          ) : (
+            <>
+            {!isLogin && (
+              <div className="mb-4">
+                <label className="block text-gray-700 font-medium mb-2">Full Name</label>
+                <input
+                  type="text"
+                  value={formData.name}
+                  onChange={(e) => setFormData({ ...formData, name: e.target.value })}
+                  className="w-full px-4 py-3 border border-gray-300 rounded-lg focus:outline-none focus:ring-2 focus:ring-[#ff5000]"
+                  required
+                />
+              </div>
+            )}
 
-          <div className="mb-4">
-            <label className="block text-gray-700 font-medium mb-2">Email</label>
-            <input
-              type="email"
-              value={formData.email}
-              onChange={(e) => setFormData({ ...formData, email: e.target.value })}
-              className="w-full px-4 py-3 border border-gray-300 rounded-lg focus:outline-none focus:ring-2 focus:ring-[#ff5000]"
-              required
-            />
-          </div>
+            <div className="mb-4">
+              <label className="block text-gray-700 font-medium mb-2">Email</label>
+              <input
+                type="email"
+                value={formData.email}
+                onChange={(e) => setFormData({ ...formData, email: e.target.value })}
+                className="w-full px-4 py-3 border border-gray-300 rounded-lg focus:outline-none focus:ring-2 focus:ring-[#ff5000]"
+                required
+              />
+            </div>
 
-          <div className="mb-4">
-            <label className="block text-gray-700 font-medium mb-2">Password</label>
-            <input
-              type="password"
-              value={formData.password}
-              onChange={(e) => setFormData({ ...formData, password: e.target.value })}
-              className="w-full px-4 py-3 border border-gray-300 rounded-lg focus:outline-none focus:ring-2 focus:ring-[#ff5000]"
-              required
-            />
-          </div>
+            <div className="mb-4">
+              <label className="block text-gray-700 font-medium mb-2">Password</label>
+              <input
+                type="password"
+                value={formData.password}
+                onChange={(e) => setFormData({ ...formData, password: e.target.value })}
+                className="w-full px-4 py-3 border border-gray-300 rounded-lg focus:outline-none focus:ring-2 focus:ring-[#ff5000]"
+                required
+              />
+            </div>
 
-          {!isLogin && (
-            <div className="mb-6">
-              <label className="block text-gray-700 font-medium mb-2">Register As</label>
-              <div className="flex space-x-4">
-                <label className="flex items-center space-x-2 cursor-pointer">
-                  <input
-                    type="radio"
-                    value="buyer"
-                    checked={formData.role === 'buyer'}
-                    onChange={(e) => setFormData({ ...formData, role: e.target.value })}
-                    className="w-4 h-4 text-[#ff5000]"
-                  />
-                  <span>Buyer</span>
-                </label>
-                <label className="flex items-center space-x-2 cursor-pointer">
-                  <input
-                    type="radio"
-                    value="artisan"
-                    checked={formData.role === 'artisan'}
-                    onChange={(e) => setFormData({ ...formData, role: e.target.value })}
-                    className="w-4 h-4 text-[#ff5000]"
-                  />
-                  <span>Artisan</span>
-                </label>
-                <label className="flex items-center space-x-2 cursor-pointer">
-                  <input
-                    type="radio"
-                    value="admin"
-                    checked={formData.role === 'admin'}
-                    onChange={(e) => setFormData({ ...formData, role: e.target.value })}
-                    className="w-4 h-4 text-[#ff5000]"
-                  />
-                  <span>Admin</span>
-                </label>
+            {!isLogin && (
+              <div className="mb-6">
+                <label className="block text-gray-700 font-medium mb-2">Register As</label>
+                <div className="flex space-x-4">
+                  <label className="flex items-center space-x-2 cursor-pointer">
+                    <input
+                      type="radio"
+                      value="buyer"
+                      checked={formData.role === 'buyer'}
+                      onChange={(e) => setFormData({ ...formData, role: e.target.value })}
+                      className="w-4 h-4 text-[#ff5000]"
+                    />
+                    <span>Buyer</span>
+                  </label>
+                  <label className="flex items-center space-x-2 cursor-pointer">
+                    <input
+                      type="radio"
+                      value="artisan"
+                      checked={formData.role === 'artisan'}
+                      onChange={(e) => setFormData({ ...formData, role: e.target.value })}
+                      className="w-4 h-4 text-[#ff5000]"
+                    />
+                    <span>Artisan</span>
+                  </label>
+                </div>
               </div>
-            </div>
+            )}
+            </>
           )}
 
           <button
@@ -151,7 +181,7 @@ export default function AuthPage({ setUser }) {
             disabled={loading}
             className="w-full bg-[#ff5000] text-white py-3 rounded-lg font-semibold hover:bg-[#e64800] transition disabled:opacity-50"
           >
-            {loading ? 'Processing...' : isLogin ? 'Login' : 'Sign Up'}
+            {loading ? 'Processing...' : challenge ? 'Verify' : isLogin ? 'Login' : 'Sign Up'}
           </button>
 
           <div className="mt-6 text-center">